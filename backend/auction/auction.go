@@ -0,0 +1,317 @@
+package auction
+
+import (
+	"agro-connect/availability"
+	"agro-connect/database"
+	"agro-connect/models"
+	"agro-connect/notify"
+	"agro-connect/realtime"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// SnipeWindow is the anti-sniping window: a bid this close to the end
+	// moves the end to SnipeWindow after the bid
+	SnipeWindow = 2 * time.Minute
+	// schedulerInterval is how often ended auctions are closed
+	schedulerInterval = 15 * time.Second
+)
+
+// Errors of auction operations
+var (
+	ErrNotLive    = errors.New("auction is not taking bids")
+	ErrOwnAuction = errors.New("you cannot bid on your own auction")
+	ErrClosed     = errors.New("auction is already closed")
+	ErrNotEnded   = errors.New("auction has not ended yet")
+	ErrHasBids    = errors.New("an auction with bids cannot be cancelled")
+)
+
+// BidTooLowError is returned for a bid below the current minimum
+type BidTooLowError struct {
+	Minimum float64
+}
+
+func (e *BidTooLowError) Error() string {
+	return fmt.Sprintf("bid at least %g per unit", e.Minimum)
+}
+
+// MinimumBid returns the lowest bid per unit the auction accepts next
+func MinimumBid(auction models.Auction) float64 {
+	if auction.HighestBid == nil {
+		return auction.StartPrice
+	}
+	return math.Round((*auction.HighestBid+auction.MinIncrement)*100) / 100
+}
+
+// View returns the auction as bidders see it, without the reserve price
+func View(auction models.Auction) models.Auction {
+	auction.ReserveMet = auction.HighestBid != nil && *auction.HighestBid >= auction.ReservePrice
+	auction.ReservePrice = 0
+	return auction
+}
+
+// lockAuction loads an auction and locks it for the rest of the
+// transaction, so concurrent bids are checked against each other
+func lockAuction(tx *gorm.DB, id uint) (models.Auction, error) {
+	var auction models.Auction
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&auction, id).Error
+	return auction, err
+}
+
+// PlaceBid records a buyer's bid per unit if it beats the highest bid by the
+// minimum increment. A bid within SnipeWindow of the end extends the
+// auction.
+func PlaceBid(auctionID, buyerID uint, amount float64, now time.Time) (models.Auction, models.AuctionBid, error) {
+	var auction models.Auction
+	var bid models.AuctionBid
+	var outbid uint
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if auction, err = lockAuction(tx, auctionID); err != nil {
+			return err
+		}
+		switch {
+		case auction.FarmerID == buyerID:
+			return ErrOwnAuction
+		case !auction.Live(now):
+			return ErrNotLive
+		}
+		if minimum := MinimumBid(auction); amount < minimum {
+			return &BidTooLowError{Minimum: minimum}
+		}
+
+		bid = models.AuctionBid{AuctionID: auction.ID, BuyerID: buyerID, Amount: amount}
+		if err := tx.Create(&bid).Error; err != nil {
+			return err
+		}
+
+		if auction.HighestBidderID != nil && *auction.HighestBidderID != buyerID {
+			outbid = *auction.HighestBidderID
+		}
+		auction.HighestBid = &bid.Amount
+		auction.HighestBidderID = &bid.BuyerID
+		auction.BidCount++
+		if auction.EndsAt.Sub(now) < SnipeWindow {
+			auction.EndsAt = now.Add(SnipeWindow)
+			auction.Extensions++
+		}
+		return tx.Model(&auction).Updates(map[string]interface{}{
+			"highest_bid":       auction.HighestBid,
+			"highest_bidder_id": auction.HighestBidderID,
+			"bid_count":         auction.BidCount,
+			"ends_at":           auction.EndsAt,
+			"extensions":        auction.Extensions,
+		}).Error
+	})
+	if err != nil {
+		return auction, bid, err
+	}
+
+	publish(auction)
+	if outbid != 0 {
+		notify.Send(outbid, notify.TypeOffer, notify.EventAuctionOutbid, auction.ID, auctionParams(auction))
+	}
+	return auction, bid, nil
+}
+
+// Close ends an auction at or after its end. The highest bid at or above
+// the reserve wins the lot and becomes an accepted offer and an order, which
+// keeps the stock reserved for the lot; otherwise the lot stays unsold and
+// returns to the listing.
+func Close(auctionID uint, now time.Time) (models.Auction, *models.Order, error) {
+	var auction models.Auction
+	var order *models.Order
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if auction, err = lockAuction(tx, auctionID); err != nil {
+			return err
+		}
+		switch {
+		case auction.Status != models.AuctionOpen:
+			return ErrClosed
+		case now.Before(auction.EndsAt):
+			return ErrNotEnded
+		}
+
+		auction.ClosedAt = &now
+		auction.Status = models.AuctionUnsold
+		if auction.HighestBid != nil && *auction.HighestBid >= auction.ReservePrice {
+			if order, err = award(tx, auction, availability.Day(now)); err != nil {
+				return err
+			}
+			auction.Status = models.AuctionSold
+			auction.WinnerID = auction.HighestBidderID
+			auction.OrderID = &order.ID
+		} else if err := releaseLot(tx, auction, now); err != nil {
+			return err
+		}
+		return tx.Model(&auction).Updates(map[string]interface{}{
+			"status":    auction.Status,
+			"winner_id": auction.WinnerID,
+			"order_id":  auction.OrderID,
+			"closed_at": auction.ClosedAt,
+		}).Error
+	})
+	if err != nil {
+		return auction, nil, err
+	}
+
+	publish(auction)
+	params := auctionParams(auction)
+	if order != nil {
+		params["order_id"] = order.ID
+		notify.Send(order.BuyerID, notify.TypeOrder, notify.EventAuctionWon, order.ID, params)
+		notify.Send(auction.FarmerID, notify.TypeOrder, notify.EventAuctionSold, order.ID, params)
+		realtime.Publish(order.BuyerID, realtime.EventOrderUpdated, *order)
+		realtime.Publish(order.FarmerID, realtime.EventOrderUpdated, *order)
+	} else {
+		notify.Send(auction.FarmerID, notify.TypeProduct, notify.EventAuctionUnsold, auction.ID, params)
+	}
+	return auction, order, nil
+}
+
+// award turns the winning bid into an accepted offer and an order for the
+// whole lot. The lot goes back to the listing if the order is canceled.
+func award(tx *gorm.DB, auction models.Auction, today models.Date) (*models.Order, error) {
+	offer := models.Offer{
+		BuyerID:         *auction.HighestBidderID,
+		ProductID:       auction.ProductID,
+		VariantID:       auction.VariantID,
+		Quantity:        auction.Quantity,
+		Unit:            auction.Unit,
+		Price:           *auction.HighestBid,
+		ListingQuantity: auction.Quantity,
+		ListingPrice:    *auction.HighestBid,
+		Status:          "ACCEPTED",
+		PickupDate:      today.String(),
+	}
+	if err := tx.Create(&offer).Error; err != nil {
+		return nil, err
+	}
+	order := models.Order{
+		OfferID:       offer.ID,
+		FarmerID:      auction.FarmerID,
+		BuyerID:       offer.BuyerID,
+		ProductID:     auction.ProductID,
+		VariantID:     auction.VariantID,
+		OrderDate:     today.String(),
+		Status:        "processing",
+		StockQuantity: auction.Quantity, // reserved when the auction opened
+	}
+	if err := tx.Create(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// releaseLot returns the stock reserved for an auction that did not sell to
+// its listing
+func releaseLot(tx *gorm.DB, auction models.Auction, now time.Time) error {
+	return availability.ReturnStock(tx, auction.ProductID, auction.VariantID, auction.Quantity, availability.Day(now))
+}
+
+// Cancel cancels an open auction nobody has bid on yet and returns the lot
+// to the listing
+func Cancel(auctionID uint, now time.Time) (models.Auction, error) {
+	var auction models.Auction
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if auction, err = lockAuction(tx, auctionID); err != nil {
+			return err
+		}
+		switch {
+		case auction.Status != models.AuctionOpen:
+			return ErrClosed
+		case auction.BidCount > 0:
+			return ErrHasBids
+		}
+		auction.Status = models.AuctionCancelled
+		auction.ClosedAt = &now
+		if err := releaseLot(tx, auction, now); err != nil {
+			return err
+		}
+		return tx.Model(&auction).Updates(map[string]interface{}{
+			"status":    auction.Status,
+			"closed_at": auction.ClosedAt,
+		}).Error
+	})
+	if err == nil {
+		publish(auction)
+	}
+	return auction, err
+}
+
+// StartScheduler periodically closes auctions that have ended
+func StartScheduler() {
+	go func() {
+		CloseDue(time.Now())
+		ticker := time.NewTicker(schedulerInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			CloseDue(now)
+		}
+	}()
+}
+
+// CloseDue closes every open auction whose end has passed at now
+func CloseDue(now time.Time) {
+	var ids []uint
+	if err := database.DB.Model(&models.Auction{}).
+		Where("status = ? AND ends_at <= ?", models.AuctionOpen, now).
+		Pluck("id", &ids).Error; err != nil {
+		log.Printf("Failed to load ended auctions: %v", err)
+		return
+	}
+	for _, id := range ids {
+		// A bid may have extended the auction since it was loaded
+		if _, _, err := Close(id, now); err != nil && !errors.Is(err, ErrClosed) && !errors.Is(err, ErrNotEnded) {
+			log.Printf("Failed to close auction %d: %v", id, err)
+		}
+	}
+}
+
+// publish pushes the state of an auction to its farmer and everyone who bid
+func publish(auction models.Auction) {
+	realtime.Publish(auction.FarmerID, realtime.EventAuctionUpdated, auction)
+
+	var bidders []uint
+	if err := database.DB.Model(&models.AuctionBid{}).
+		Where("auction_id = ?", auction.ID).
+		Distinct("buyer_id").
+		Pluck("buyer_id", &bidders).Error; err != nil {
+		log.Printf("Failed to load bidders of auction %d: %v", auction.ID, err)
+		return
+	}
+	view := View(auction)
+	for _, bidder := range bidders {
+		realtime.Publish(bidder, realtime.EventAuctionUpdated, view)
+	}
+}
+
+// auctionParams are the notification parameters describing an auction
+func auctionParams(auction models.Auction) map[string]interface{} {
+	var product models.Product
+	database.DB.Unscoped().Select("name_en, name_np").First(&product, auction.ProductID)
+	productNp := product.NameNp
+	if productNp == "" {
+		productNp = product.NameEn
+	}
+	params := map[string]interface{}{
+		"auction_id": auction.ID,
+		"product":    product.NameEn,
+		"product_np": productNp,
+		"quantity":   auction.Quantity,
+		"unit":       auction.Unit,
+	}
+	if auction.HighestBid != nil {
+		params["price"] = *auction.HighestBid
+	}
+	return params
+}
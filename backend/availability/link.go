@@ -0,0 +1,47 @@
+package availability
+
+import (
+	"agro-connect/config"
+	"agro-connect/models"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// linkValidity is how long a one-click extend link can be used
+const linkValidity = 7 * 24 * time.Hour
+
+// ExtendURL returns a signed link that extends a listing by days without
+// signing in, for expiry reminders sent by SMS or email. The link opens a
+// confirmation page and is bound to the listing's current end, so it
+// extends the listing once however often it is followed.
+func ExtendURL(product models.Product, days int, now time.Time) string {
+	expires := now.Add(linkValidity).Unix()
+	until := product.AvailableTo.String()
+	query := url.Values{
+		"days":      {strconv.Itoa(days)},
+		"until":     {until},
+		"expires":   {strconv.FormatInt(expires, 10)},
+		"signature": {signExtend(product.ID, days, until, expires)},
+	}
+	return fmt.Sprintf("%s/products/%d/extend?%s", config.App.PublicURL, product.ID, query.Encode())
+}
+
+// VerifyExtendLink checks the signature and expiry of an extend link issued
+// while the listing ended on until
+func VerifyExtendLink(productID uint, days int, until string, expires int64, signature string, now time.Time) bool {
+	if now.Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(signExtend(productID, days, until, expires)))
+}
+
+func signExtend(productID uint, days int, until string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(config.App.LinkSigningKey))
+	fmt.Fprintf(mac, "extend:%d:%d:%s:%d", productID, days, until, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,159 @@
+package availability
+
+import (
+	"agro-connect/database"
+	"agro-connect/models"
+	"agro-connect/notify"
+	"log"
+	"time"
+)
+
+const (
+	// schedulerInterval is how often listing statuses are brought up to date
+	schedulerInterval = 15 * time.Minute
+	// DefaultExtendDays is how long a listing is extended by when no period
+	// is given, including from the link in expiry reminders
+	DefaultExtendDays = 7
+	// MaxExtendDays limits a single extension
+	MaxExtendDays = 90
+)
+
+// StartScheduler periodically activates, expires and sells out listings and
+// reminds farmers of listings about to expire
+func StartScheduler() {
+	go func() {
+		RunDue(time.Now())
+		ticker := time.NewTicker(schedulerInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			RunDue(now)
+		}
+	}()
+}
+
+// RunDue updates the status of every listing whose quantity or dates call
+// for a change at now, then sends the expiry reminders that are due
+func RunDue(now time.Time) {
+	today := Day(now).String()
+	live := []models.ProductStatus{models.ProductStatusScheduled, models.ProductStatusAvailable}
+
+	steps := []struct {
+		name   string
+		status models.ProductStatus
+		where  string
+		args   []interface{}
+	}{
+		{"sell out", models.ProductStatusSoldOut,
+			"status IN ? AND quantity <= 0", []interface{}{live}},
+		{"expire", models.ProductStatusExpired,
+			"status IN ? AND available_to < ?", []interface{}{live, today}},
+		{"activate", models.ProductStatusAvailable,
+			"status = ? AND (available_from IS NULL OR available_from <= ?)",
+			[]interface{}{models.ProductStatusScheduled, today}},
+	}
+	for _, step := range steps {
+		result := database.DB.Model(&models.Product{}).Where(step.where, step.args...).Update("status", step.status)
+		if result.Error != nil {
+			log.Printf("Failed to %s listings: %v", step.name, result.Error)
+		} else if result.RowsAffected > 0 {
+			log.Printf("Availability: %s %d listings", step.name, result.RowsAffected)
+		}
+	}
+
+	remindExpiring(now)
+}
+
+// remindExpiring notifies farmers once about available listings that expire
+// by the end of tomorrow
+func remindExpiring(now time.Time) {
+	tomorrow := models.NewDate(Day(now).AddDate(0, 0, 1)).String()
+
+	var products []models.Product
+	if err := database.DB.Where("status = ? AND available_to <= ? AND expiry_notified_at IS NULL",
+		models.ProductStatusAvailable, tomorrow).Find(&products).Error; err != nil {
+		log.Printf("Failed to load expiring listings: %v", err)
+		return
+	}
+
+	for _, product := range products {
+		// Mark first so a slow delivery is never repeated by the next run
+		result := database.DB.Model(&models.Product{}).
+			Where("id = ? AND expiry_notified_at IS NULL", product.ID).
+			Update("expiry_notified_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue
+		}
+
+		productNp := product.NameNp
+		if productNp == "" {
+			productNp = product.NameEn
+		}
+		notify.Send(product.UserID, notify.TypeProduct, notify.EventProductExpiring, product.ID, map[string]interface{}{
+			"product":    product.NameEn,
+			"product_np": productNp,
+			"date":       product.AvailableTo.String(),
+			"days":       DefaultExtendDays,
+			"link":       ExtendURL(product, DefaultExtendDays, now),
+		})
+	}
+}
+
+// Extend moves the end of a listing to days after its current end, or after
+// today when it already ended, and makes an expired listing available again
+func Extend(product *models.Product, days int, now time.Time) error {
+	today := Day(now)
+	from := product.AvailableTo
+	if from.IsZero() || from.Before(today.Time) {
+		from = today
+	}
+	product.AvailableTo = models.NewDate(from.AddDate(0, 0, days))
+	return SetAvailableTo(product, product.AvailableTo, now)
+}
+
+// ExtendOnce extends a listing as Extend does, but only while it still ends
+// on until, so a repeated extend link or a double submit changes nothing.
+// It reports whether the listing was extended.
+func ExtendOnce(product *models.Product, until models.Date, days int, now time.Time) (bool, error) {
+	if !product.AvailableTo.Equal(until.Time) {
+		return false, nil
+	}
+	extended := *product
+	today := Day(now)
+	from := until
+	if from.IsZero() || from.Before(today.Time) {
+		from = today
+	}
+	extended.AvailableTo = models.NewDate(from.AddDate(0, 0, days))
+	extended.ExpiryNotifiedAt = nil
+	extended.Status = Status(extended, today)
+
+	query := database.DB.Model(&models.Product{}).Where("id = ?", product.ID)
+	if until.IsZero() {
+		query = query.Where("available_to IS NULL")
+	} else {
+		query = query.Where("available_to = ?", until)
+	}
+	result := query.Updates(map[string]interface{}{
+		"available_to":       extended.AvailableTo,
+		"expiry_notified_at": nil,
+		"status":             extended.Status,
+	})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	*product = extended
+	return true, nil
+}
+
+// SetAvailableTo changes the end of a listing, resets its expiry reminder
+// and updates its status
+func SetAvailableTo(product *models.Product, until models.Date, now time.Time) error {
+	product.AvailableTo = until
+	product.ExpiryNotifiedAt = nil
+	product.Status = Status(*product, Day(now))
+	return database.DB.Model(product).Updates(map[string]interface{}{
+		"available_to":       product.AvailableTo,
+		"expiry_notified_at": nil,
+		"status":             product.Status,
+	}).Error
+}
@@ -0,0 +1,37 @@
+package availability
+
+import (
+	"agro-connect/models"
+	"agro-connect/notify"
+	"time"
+)
+
+// Today returns the current date in Nepal
+func Today() models.Date {
+	return Day(time.Now())
+}
+
+// Day returns the date in Nepal at t
+func Day(t time.Time) models.Date {
+	return models.NewDate(t.In(notify.Kathmandu))
+}
+
+// Status returns the status a listing should have on day today given its
+// quantity and availability dates. Only live statuses are derived from the
+// listing; drafts, listings in review, reserved and archived listings keep
+// their status. An empty status counts as available.
+func Status(product models.Product, today models.Date) models.ProductStatus {
+	if product.Status != "" && !product.Status.Live() {
+		return product.Status
+	}
+	switch {
+	case product.Quantity <= 0:
+		return models.ProductStatusSoldOut
+	case !product.AvailableTo.IsZero() && product.AvailableTo.Before(today.Time):
+		return models.ProductStatusExpired
+	case !product.AvailableFrom.IsZero() && product.AvailableFrom.After(today.Time):
+		return models.ProductStatusScheduled
+	default:
+		return models.ProductStatusAvailable
+	}
+}
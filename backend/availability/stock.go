@@ -0,0 +1,99 @@
+package availability
+
+import (
+	"agro-connect/models"
+	"errors"
+	"fmt"
+	"math"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockError is returned when a listing has less left than is asked for
+type StockError struct {
+	Product   string
+	Available float64
+	Unit      string
+}
+
+func (e *StockError) Error() string {
+	return fmt.Sprintf("only %g %s of %s available", e.Available, e.Unit, e.Product)
+}
+
+// LockStock loads a listing, and its variant when variantID is set, locked
+// for the rest of the transaction. The listing is always locked first so
+// concurrent sales of its variants cannot deadlock.
+func LockStock(tx *gorm.DB, productID uint, variantID *uint) (models.Product, *models.ProductVariant, error) {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+		return product, nil, err
+	}
+	if variantID == nil {
+		return product, nil, nil
+	}
+	var variant models.ProductVariant
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND product_id = ?", *variantID, productID).
+		First(&variant).Error; err != nil {
+		return product, nil, err
+	}
+	return product, &variant, nil
+}
+
+// TakeStock removes quantity, in the listing's unit, from a listing and its
+// variant within tx, failing with a StockError when less is left. A live
+// listing with nothing left becomes sold out.
+func TakeStock(tx *gorm.DB, productID uint, variantID *uint, quantity float64, today models.Date) (models.Product, error) {
+	product, variant, err := LockStock(tx, productID, variantID)
+	if err != nil {
+		return product, err
+	}
+	available := product.Quantity
+	if variant != nil {
+		available = variant.Quantity
+	}
+	if quantity > available {
+		return product, &StockError{Product: product.NameEn, Available: available, Unit: product.Unit}
+	}
+	return product, adjustStock(tx, &product, variant, -quantity, today)
+}
+
+// ReturnStock puts quantity back into a listing and its variant within tx,
+// e.g. when a reserved lot does not sell. A sold out listing becomes
+// available again. Deleted listings are left alone.
+func ReturnStock(tx *gorm.DB, productID uint, variantID *uint, quantity float64, today models.Date) error {
+	product, variant, err := LockStock(tx, productID, variantID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return adjustStock(tx, &product, variant, quantity, today)
+}
+
+// adjustStock changes the quantity of a locked listing and its variant by
+// delta and updates the listing's status to match. The listing quantity of
+// a listing with variants is the sum of its variants, so both change.
+func adjustStock(tx *gorm.DB, product *models.Product, variant *models.ProductVariant, delta float64, today models.Date) error {
+	if variant != nil {
+		variant.Quantity = roundQuantity(variant.Quantity + delta)
+		if err := tx.Model(variant).Update("quantity", variant.Quantity).Error; err != nil {
+			return err
+		}
+	}
+	product.Quantity = roundQuantity(product.Quantity + delta)
+	if product.Status.Live() {
+		product.Status = Status(*product, today)
+	}
+	return tx.Model(product).Updates(map[string]interface{}{
+		"quantity": product.Quantity,
+		"status":   product.Status,
+	}).Error
+}
+
+// roundQuantity drops the floating point noise of repeated additions
+func roundQuantity(quantity float64) float64 {
+	return math.Max(math.Round(quantity*1000)/1000, 0)
+}
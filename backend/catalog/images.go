@@ -0,0 +1,156 @@
+package catalog
+
+import (
+	"agro-connect/media"
+	"agro-connect/storage"
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxArchiveFiles limits the entries of an uploaded image archive
+const maxArchiveFiles = 1000
+
+// errPrivateAddress rejects image URLs pointing into a private network
+var errPrivateAddress = errors.New("image URL must point to a public address")
+
+// imageClient downloads images named by URL. It only connects to public
+// addresses, checked after DNS resolution, so a sheet cannot make the
+// server fetch from its own network.
+var imageClient = &http.Client{
+	Timeout: 20 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				ip := net.ParseIP(host)
+				if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+					ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+					return errPrivateAddress
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+// Images loads and validates the images named in a product sheet: URLs of
+// images already uploaded here, other http(s) URLs, or file names in an
+// uploaded zip archive. Each image is loaded once.
+type Images struct {
+	files map[string]*zip.File // by lower-cased base name
+	cache map[string]*media.Upload
+}
+
+// NewImages returns a loader for the images of a sheet. archive may be nil
+// when no zip was uploaded.
+func NewImages(archive *zip.Reader) (*Images, error) {
+	images := &Images{files: map[string]*zip.File{}, cache: map[string]*media.Upload{}}
+	if archive == nil {
+		return images, nil
+	}
+	if len(archive.File) > maxArchiveFiles {
+		return nil, fmt.Errorf("image archive has more than %d files", maxArchiveFiles)
+	}
+	for _, f := range archive.File {
+		name := path.Base(f.Name)
+		if f.FileInfo().IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		images.files[strings.ToLower(name)] = f
+	}
+	return images, nil
+}
+
+// Load returns the validated image named by ref
+func (im *Images) Load(ctx context.Context, ref string) (*media.Upload, error) {
+	if upload, ok := im.cache[ref]; ok {
+		return upload, nil
+	}
+
+	var upload *media.Upload
+	var err error
+	switch {
+	case strings.HasPrefix(ref, storage.URLPrefix):
+		upload, err = im.loadStored(ctx, ref)
+	case strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://"):
+		upload, err = im.download(ctx, ref)
+	default:
+		upload, err = im.loadArchived(ctx, ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+	im.cache[ref] = upload
+	return upload, nil
+}
+
+// loadStored reads a public image uploaded here, e.g. from an export
+func (im *Images) loadStored(ctx context.Context, ref string) (*media.Upload, error) {
+	key, ok := storage.KeyFromURL(ref)
+	if !ok || storage.IsPrivate(key) {
+		return nil, fmt.Errorf("image %s not found", ref)
+	}
+	f, err := storage.Open(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("image %s not found", ref)
+	}
+	defer f.Close()
+	return media.Validate(ctx, path.Base(key), f)
+}
+
+func (im *Images) download(ctx context.Context, ref string) (*media.Upload, error) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid image URL %q", ref)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image URL %q", ref)
+	}
+	resp, err := imageClient.Do(req)
+	if err != nil {
+		if errors.Is(err, errPrivateAddress) {
+			return nil, errPrivateAddress
+		}
+		return nil, fmt.Errorf("failed to download image %s", ref)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download image %s: %s", ref, resp.Status)
+	}
+	return media.Validate(ctx, path.Base(u.Path), resp.Body)
+}
+
+func (im *Images) loadArchived(ctx context.Context, ref string) (*media.Upload, error) {
+	f, ok := im.files[strings.ToLower(path.Base(ref))]
+	if !ok {
+		if len(im.files) == 0 {
+			return nil, fmt.Errorf("image %q not found: upload a zip of images or use an image URL", ref)
+		}
+		return nil, fmt.Errorf("image %q not found in the zip", ref)
+	}
+	if f.UncompressedSize64 > media.MaxUploadSize {
+		return nil, fmt.Errorf("image %q too large. Maximum size is %dMB", ref, media.MaxUploadSize/(1024*1024))
+	}
+	r, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q from the zip: %w", ref, err)
+	}
+	defer r.Close()
+	return media.Validate(ctx, path.Base(f.Name), io.LimitReader(r, media.MaxUploadSize+1))
+}
@@ -0,0 +1,228 @@
+package catalog
+
+import (
+	"agro-connect/models"
+	"agro-connect/search"
+	"agro-connect/spreadsheet"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Column is a column of the product sheet
+type Column struct {
+	Key         string   `json:"key"`
+	Headers     []string `json:"headers"` // further accepted headers, in English or Nepali
+	Description string   `json:"description"`
+	Example     string   `json:"example"`
+}
+
+// Columns documents the product sheet used by import and export. Columns
+// may come in any order and only name_en, quantity and price_per_unit are
+// required for new listings. Headers are matched ignoring case and
+// punctuation, so "Name (EN)" reads as name_en.
+var Columns = []Column{
+	{"id", nil, "ID of one of your listings to update it; leave empty to create a listing", ""},
+	{"name_en", []string{"name", "name english", "english name", "product", "product name"}, "Name in English", "Tomato"},
+	{"name_np", []string{"name nepali", "nepali name", "नाम"}, "Name in Nepali", "गोलभेडा"},
+	{"description_en", []string{"description", "description english"}, "Description in English", "Fresh local tomatoes"},
+	{"description_np", []string{"description nepali", "विवरण"}, "Description in Nepali", "ताजा स्थानीय गोलभेडा"},
+	{"category", []string{"वर्ग", "श्रेणी"}, "Category name or slug, e.g. vegetable", "vegetable"},
+	{"unit", []string{"ईकाई", "इकाई", "ईकाइ", "इकाइ"}, "Unit, e.g. kg, litre, piece or dozen; see GET /units", "kg"},
+	{"quantity", []string{"qty", "परिमाण"}, "Quantity for sale, in the unit", "120"},
+	{"price_per_unit", []string{"price", "rate", "मूल्य", "दर"}, "Price in rupees per unit", "65"},
+	{"available_from", []string{"from", "उपलब्ध देखि"}, "First day of availability, YYYY-MM-DD", "2024-02-01"},
+	{"available_to", []string{"to", "until", "उपलब्ध सम्म"}, "Last day of availability, YYYY-MM-DD; defaults to the category shelf life", ""},
+	{"status", []string{"स्थिति"}, "draft to save without publishing; otherwise the listing is published", "available"},
+	{"image", []string{"image url", "images", "photo", "फोटो", "तस्बिर"}, "Image URL, or the file name of an image in the uploaded zip; required for new listings", "tomato.jpg"},
+}
+
+// RowError reports a row of a product sheet that cannot be imported
+type RowError struct {
+	Row    int    `json:"row"`
+	Column string `json:"column,omitempty"`
+	Error  string `json:"error"`
+}
+
+// Row is a listing read from a product sheet
+type Row struct {
+	Line    int // 1-based row in the sheet, for error messages
+	ID      uint
+	Product models.Product
+	Image   string
+	// Set holds the columns with a value in this row; updates keep the
+	// current value of the others
+	Set map[string]bool
+}
+
+// ReadProducts reads a CSV or XLSX product sheet. Rows above the header are
+// skipped, as are empty rows. Cells that cannot be parsed are returned as
+// errors; rows with errors are left out.
+func ReadProducts(r io.Reader, filename string) ([]Row, []RowError, error) {
+	records, err := spreadsheet.ReadRecords(r, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	headers := map[string][]string{}
+	for _, column := range Columns {
+		headers[column.Key] = append([]string{strings.Join(search.Terms(column.Key), " ")}, column.Headers...)
+	}
+	header, columns := spreadsheet.FindHeader(records, headers, "name_en")
+	if header < 0 {
+		return nil, nil, fmt.Errorf("no header row with a name_en column found")
+	}
+
+	var rows []Row
+	var errs []RowError
+	for i := header + 1; i < len(records); i++ {
+		record := records[i]
+		row := Row{Line: i + 1, Set: map[string]bool{}}
+		cells := map[string]string{}
+		for key, index := range columns {
+			if index < len(record) {
+				if value := strings.TrimSpace(record[index]); value != "" {
+					cells[key] = value
+					row.Set[key] = true
+				}
+			}
+		}
+		if len(cells) == 0 {
+			continue
+		}
+
+		rowErrors := len(errs)
+		fail := func(column string, err error) {
+			errs = append(errs, RowError{Row: row.Line, Column: column, Error: err.Error()})
+		}
+
+		if value, ok := cells["id"]; ok {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || id == 0 {
+				fail("id", fmt.Errorf("invalid id %q", value))
+			}
+			row.ID = uint(id)
+		}
+
+		p := &row.Product
+		p.NameEn = cells["name_en"]
+		p.NameNp = cells["name_np"]
+		p.DescriptionEn = cells["description_en"]
+		p.DescriptionNp = cells["description_np"]
+		p.Category = cells["category"]
+		p.Unit = cells["unit"]
+		p.Status = models.ProductStatus(strings.ToLower(cells["status"]))
+		row.Image = cells["image"]
+
+		for column, target := range map[string]*float64{"quantity": &p.Quantity, "price_per_unit": &p.PricePerUnit} {
+			if value, ok := cells[column]; ok {
+				number, err := spreadsheet.ParseNumber(value)
+				if err != nil {
+					fail(column, err)
+				}
+				*target = number
+			}
+		}
+		for column, target := range map[string]*models.Date{"available_from": &p.AvailableFrom, "available_to": &p.AvailableTo} {
+			if value, ok := cells[column]; ok {
+				date, err := spreadsheet.ParseDate(value)
+				if err != nil {
+					fail(column, err)
+				}
+				*target = models.NewDate(date)
+			}
+		}
+
+		if row.ID == 0 {
+			for _, column := range []string{"name_en", "quantity", "price_per_unit"} {
+				if !row.Set[column] {
+					fail(column, fmt.Errorf("%s is required for new listings", column))
+				}
+			}
+		}
+		if !p.AvailableFrom.IsZero() && !p.AvailableTo.IsZero() && p.AvailableTo.Before(p.AvailableFrom.Time) {
+			fail("available_to", fmt.Errorf("available_to is before available_from"))
+		}
+
+		if len(errs) == rowErrors {
+			rows = append(rows, row)
+		}
+	}
+	return rows, errs, nil
+}
+
+// Header returns the header row of the product sheet
+func Header() []string {
+	header := make([]string, len(Columns))
+	for i, column := range Columns {
+		header[i] = column.Key
+	}
+	return header
+}
+
+// Record returns the row of a listing in the product sheet
+func Record(p models.Product) []string {
+	return []string{
+		strconv.FormatUint(uint64(p.ID), 10),
+		p.NameEn,
+		p.NameNp,
+		p.DescriptionEn,
+		p.DescriptionNp,
+		p.Category,
+		p.Unit,
+		strconv.FormatFloat(p.Quantity, 'f', -1, 64),
+		strconv.FormatFloat(p.PricePerUnit, 'f', -1, 64),
+		p.AvailableFrom.String(),
+		p.AvailableTo.String(),
+		string(p.Status),
+		p.ImageURL,
+	}
+}
+
+// Template returns an empty product sheet with an example row, plus a sheet
+// describing the columns
+func Template() []spreadsheet.Sheet {
+	example := make([]string, len(Columns))
+	documentation := [][]string{{"column", "description", "also accepted as"}}
+	for i, column := range Columns {
+		example[i] = column.Example
+		documentation = append(documentation, []string{column.Key, column.Description, strings.Join(column.Headers, ", ")})
+	}
+	return []spreadsheet.Sheet{
+		{Name: "Products", Rows: [][]string{Header(), example}},
+		{Name: "Columns", Rows: documentation},
+	}
+}
+
+// Apply returns existing with the values set in the row
+func (row Row) Apply(existing models.Product) models.Product {
+	p := existing
+	set := func(column string, dst *string, value string) {
+		if row.Set[column] {
+			*dst = value
+		}
+	}
+	set("name_en", &p.NameEn, row.Product.NameEn)
+	set("name_np", &p.NameNp, row.Product.NameNp)
+	set("description_en", &p.DescriptionEn, row.Product.DescriptionEn)
+	set("description_np", &p.DescriptionNp, row.Product.DescriptionNp)
+	set("category", &p.Category, row.Product.Category)
+	set("unit", &p.Unit, row.Product.Unit)
+	if row.Set["quantity"] {
+		p.Quantity = row.Product.Quantity
+	}
+	if row.Set["price_per_unit"] {
+		p.PricePerUnit = row.Product.PricePerUnit
+	}
+	if row.Set["available_from"] {
+		p.AvailableFrom = row.Product.AvailableFrom
+	}
+	if row.Set["available_to"] {
+		p.AvailableTo = row.Product.AvailableTo
+	}
+	if row.Set["status"] {
+		p.Status = row.Product.Status
+	}
+	return p
+}
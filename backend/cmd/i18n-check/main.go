@@ -0,0 +1,33 @@
+// Command i18n-check reports message catalogue keys that are missing a
+// translation. It exits with status 1 when any key is missing.
+//
+//	go run ./cmd/i18n-check
+package main
+
+import (
+	"agro-connect/i18n"
+	"fmt"
+	"os"
+)
+
+func main() {
+	missing := i18n.MissingKeys()
+
+	total := 0
+	for _, lang := range i18n.Languages {
+		keys := missing[lang]
+		if len(keys) == 0 {
+			continue
+		}
+		fmt.Printf("%s: %d missing\n", lang, len(keys))
+		for _, key := range keys {
+			fmt.Printf("  %s\n", key)
+		}
+		total += len(keys)
+	}
+
+	if total > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("All message catalogues are complete")
+}
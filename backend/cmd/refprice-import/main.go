@@ -0,0 +1,69 @@
+// Command refprice-import imports a daily wholesale market price sheet, such
+// as the one published by the Kalimati market, as reference prices.
+//
+//	go run ./cmd/refprice-import -market kalimati -date 2024-01-31 prices.xlsx
+//
+// Sheets with a date column need no -date flag.
+package main
+
+import (
+	"agro-connect/config"
+	"agro-connect/database"
+	"agro-connect/market"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	marketName := flag.String("market", market.DefaultMarket, "market the prices were published by")
+	date := flag.String("date", "", "date of the prices (YYYY-MM-DD) for sheets without a date column")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: refprice-import [-market name] [-date YYYY-MM-DD] file.csv|file.xlsx")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var day time.Time
+	if *date != "" {
+		var err error
+		if day, err = time.Parse("2006-01-02", *date); err != nil {
+			log.Fatal("Invalid -date, expected YYYY-MM-DD")
+		}
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	config.LoadEnv()
+	database.Connect()
+
+	result, err := market.ImportReferencePrices(f, f.Name(), *marketName, day)
+	if err != nil {
+		log.Fatal("Import failed: ", err)
+	}
+
+	fmt.Printf("Imported %d prices of %s for %s\n", result.Imported, result.Market, strings.Join(result.Dates, ", "))
+	if len(result.Unmapped) > 0 {
+		fmt.Printf("%d commodities match no produce; map them under /admin/reference-prices/mappings:\n", len(result.Unmapped))
+		for _, commodity := range result.Unmapped {
+			fmt.Printf("  %s\n", commodity)
+		}
+	}
+	for _, e := range result.Errors {
+		fmt.Printf("row %d: %s\n", e.Row, e.Error)
+	}
+	if len(result.Errors) > 0 {
+		os.Exit(1)
+	}
+}
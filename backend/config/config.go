@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -15,13 +17,60 @@ type DBConfig struct {
 	DBName   string
 }
 
+type NotifyConfig struct {
+	SMSProvider   string // "file" writes messages to SMSStubFile
+	SMSStubFile   string
+	EmailProvider string // "file" writes messages to EmailStubFile
+	EmailStubFile string
+}
+
+type MediaConfig struct {
+	CWebPPath   string // cwebp executable used for WebP variants, skipped when missing
+	RequireWebP bool   // refuse to start when cwebp is missing instead of skipping WebP
+	WebPQuality string
+	ScanCommand string // malware scanner run on every upload, e.g. clamdscan; empty disables scanning
+}
+
+type StorageConfig struct {
+	Driver     string // "local" or "s3"
+	LocalDir   string // root directory of the local driver
+	SigningKey string // signs local URLs; shared by all instances
+
+	S3Endpoint  string // host[:port], e.g. s3.amazonaws.com or localhost:9000 for MinIO
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+}
+
+type AppConfig struct {
+	PublicURL      string // base URL of this API, used in links sent by SMS and email
+	LinkSigningKey string // signs one-click links such as extending a listing
+}
+
+type ModerationConfig struct {
+	BannedKeywords     []string // words that flag a listing, in English or Nepali
+	PriceOutlierFactor float64  // flag prices more than this factor above or below the market
+}
+
+var App AppConfig
 var DB DBConfig
+var Notify NotifyConfig
+var Media MediaConfig
+var Storage StorageConfig
+var Moderation ModerationConfig
 
 func LoadEnv() {
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using system environment variable")
 	}
 
+	App = AppConfig{
+		PublicURL:      strings.TrimSuffix(getEnv("PUBLIC_URL", "http://localhost:8080"), "/"),
+		LinkSigningKey: getEnv("LINK_SIGNING_KEY", os.Getenv("JWT_SECRET")),
+	}
+
 	DB = DBConfig{
 		Host:     os.Getenv("DB_HOST"),
 		Port:     os.Getenv("DB_PORT"),
@@ -29,4 +78,60 @@ func LoadEnv() {
 		Password: os.Getenv("DB_PASSWORD"),
 		DBName:   os.Getenv("DB_NAME"),
 	}
+
+	Notify = NotifyConfig{
+		SMSProvider:   getEnv("SMS_PROVIDER", "file"),
+		SMSStubFile:   getEnv("SMS_STUB_FILE", "sms_outbox.log"),
+		EmailProvider: getEnv("EMAIL_PROVIDER", "file"),
+		EmailStubFile: getEnv("EMAIL_STUB_FILE", "email_outbox.log"),
+	}
+
+	Media = MediaConfig{
+		CWebPPath:   getEnv("CWEBP_PATH", "cwebp"),
+		RequireWebP: getEnv("REQUIRE_WEBP", "false") == "true",
+		WebPQuality: getEnv("WEBP_QUALITY", "80"),
+		ScanCommand: getEnv("UPLOAD_SCAN_COMMAND", ""),
+	}
+
+	Storage = StorageConfig{
+		Driver:      getEnv("STORAGE_DRIVER", "local"),
+		LocalDir:    getEnv("UPLOADS_DIR", "uploads"),
+		SigningKey:  getEnv("STORAGE_SIGNING_KEY", os.Getenv("JWT_SECRET")),
+		S3Endpoint:  os.Getenv("S3_ENDPOINT"),
+		S3Region:    getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:    getEnv("S3_BUCKET", "agro-connect"),
+		S3AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey: os.Getenv("S3_SECRET_KEY"),
+		S3UseSSL:    getEnv("S3_USE_SSL", "true") == "true",
+	}
+
+	Moderation = ModerationConfig{
+		BannedKeywords:     splitList(getEnv("MODERATION_BANNED_KEYWORDS", defaultBannedKeywords)),
+		PriceOutlierFactor: 3,
+	}
+	if factor, err := strconv.ParseFloat(os.Getenv("MODERATION_PRICE_FACTOR"), 64); err == nil && factor > 1 {
+		Moderation.PriceOutlierFactor = factor
+	}
+}
+
+// defaultBannedKeywords flags produce that cannot be traded legally
+const defaultBannedKeywords = "ganja,gaanja,cannabis,marijuana,charas,hashish,opium,गाँजा,गांजा,चरेस,अफिम"
+
+// splitList reads a comma separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnv returns the environment variable or fallback when it is unset
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
 }
@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"agro-connect/auction"
+	"agro-connect/availability"
+	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/models"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// minAuctionDuration and maxAuctionDuration bound how long an auction runs
+	minAuctionDuration = 10 * time.Minute
+	maxAuctionDuration = 72 * time.Hour
+)
+
+// errAuctionExists is returned for a second open auction of a listing
+var errAuctionExists = errors.New("this listing already has an open auction")
+
+// AuctionInput starts an auction of a lot of a listing. Prices are per unit
+// of the listing. The auction ends at ends_at or after duration_minutes.
+type AuctionInput struct {
+	ProductID       uint       `json:"product_id" binding:"required"`
+	VariantID       *uint      `json:"variant_id"`
+	Quantity        float64    `json:"quantity" binding:"required,gt=0"`
+	StartPrice      float64    `json:"start_price" binding:"required,gt=0"`
+	ReservePrice    float64    `json:"reserve_price" binding:"gte=0"`
+	MinIncrement    float64    `json:"min_increment" binding:"required,gt=0"`
+	StartsAt        *time.Time `json:"starts_at"`
+	EndsAt          *time.Time `json:"ends_at"`
+	DurationMinutes int        `json:"duration_minutes" binding:"gte=0"`
+}
+
+// prepareAuction validates an auction of a farmer's listing. The lot must
+// come from an available listing; its quantity is checked when the lot is
+// reserved.
+func prepareAuction(c *gin.Context, input AuctionInput, farmerID uint, now time.Time) (models.Auction, error) {
+	lot := models.Auction{
+		ProductID:    input.ProductID,
+		VariantID:    input.VariantID,
+		FarmerID:     farmerID,
+		Quantity:     input.Quantity,
+		StartPrice:   input.StartPrice,
+		ReservePrice: input.ReservePrice,
+		MinIncrement: input.MinIncrement,
+		StartsAt:     now,
+		Status:       models.AuctionOpen,
+	}
+
+	var product models.Product
+	if err := database.DB.Where("id = ? AND user_id = ?", input.ProductID, farmerID).First(&product).Error; err != nil {
+		return lot, errors.New(i18n.Tc(c, "auction.not_your_listing", map[string]interface{}{"product_id": input.ProductID}))
+	}
+	if product.Status != models.ProductStatusAvailable || product.AwaitingHarvest() {
+		return lot, errors.New(i18n.Tc(c, "auction.listing_unavailable", nil))
+	}
+	if err := resolveVariant(product.ID, input.VariantID); err != nil {
+		return lot, errors.New(variantMessage(c, err))
+	}
+	lot.Unit = product.Unit
+
+	if input.ReservePrice > 0 && input.ReservePrice < input.StartPrice {
+		return lot, errors.New(i18n.Tc(c, "auction.reserve_below_start", nil))
+	}
+	if input.StartsAt != nil && input.StartsAt.After(now) {
+		lot.StartsAt = *input.StartsAt
+	}
+	switch {
+	case input.EndsAt != nil:
+		lot.EndsAt = *input.EndsAt
+	case input.DurationMinutes > 0:
+		lot.EndsAt = lot.StartsAt.Add(time.Duration(input.DurationMinutes) * time.Minute)
+	default:
+		return lot, errors.New(i18n.Tc(c, "auction.end_required", nil))
+	}
+	if duration := lot.EndsAt.Sub(lot.StartsAt); duration < minAuctionDuration || duration > maxAuctionDuration {
+		return lot, errors.New(i18n.Tc(c, "auction.invalid_duration", map[string]interface{}{
+			"min": minAuctionDuration.String(), "max": maxAuctionDuration.String(),
+		}))
+	}
+	return lot, nil
+}
+
+// CreateAuction starts a timed auction of a lot of the farmer's listing. The
+// lot is taken from the listing's stock until the auction is cancelled or
+// closes unsold.
+// POST /auctions {"product_id": 12, "quantity": 300, "start_price": 40, "reserve_price": 55, "min_increment": 1, "duration_minutes": 120}
+func CreateAuction(c *gin.Context) {
+	var input AuctionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	created, err := prepareAuction(c, input, userID.(uint), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Taking the stock locks the listing, so the check for an open
+		// auction cannot race another one being created
+		if _, err := availability.TakeStock(tx, created.ProductID, created.VariantID, created.Quantity, availability.Today()); err != nil {
+			return err
+		}
+		var open int64
+		if err := tx.Model(&models.Auction{}).Where("product_id = ? AND status = ?", created.ProductID, models.AuctionOpen).Count(&open).Error; err != nil {
+			return err
+		}
+		if open > 0 {
+			return errAuctionExists
+		}
+		return tx.Create(&created).Error
+	})
+	var short *availability.StockError
+	switch {
+	case errors.Is(err, errAuctionExists):
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "auction.exists", nil)})
+		return
+	case errors.As(err, &short):
+		c.JSON(http.StatusBadRequest, gin.H{"error": stockMessage(c, short)})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "auction.create_failed", nil), "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": i18n.Tc(c, "auction.created", nil), "auction": created})
+}
+
+// GetAuctions lists open auctions, ending soonest first. With ?mine=true
+// farmers see their own auctions in any status.
+// GET /auctions?product_id=12&page=1&limit=20
+func GetAuctions(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	query := database.DB.Model(&models.Auction{})
+	mine := c.Query("mine") == "true"
+	if mine {
+		query = query.Where("farmer_id = ?", userID)
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+	} else {
+		query = query.Where("status = ?", models.AuctionOpen)
+	}
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "auction.count_failed", nil)})
+		return
+	}
+
+	page := c.DefaultQuery("page", "1")
+	limit := c.DefaultQuery("limit", "20")
+
+	auctions := []models.Auction{}
+	if err := query.Order("ends_at, id").Scopes(Paginate(page, limit)).Find(&auctions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "auction.retrieve_failed", nil)})
+		return
+	}
+	if !mine {
+		for i := range auctions {
+			auctions[i] = auction.View(auctions[i])
+		}
+	}
+
+	pageInt, limitInt := paginationValues(page, limit)
+	c.JSON(http.StatusOK, gin.H{
+		"auctions": auctions,
+		"now":      time.Now(),
+		"meta": gin.H{
+			"total": total,
+			"page":  pageInt,
+			"limit": limitInt,
+			"pages": (total + int64(limitInt) - 1) / int64(limitInt),
+		},
+	})
+}
+
+// GetAuction returns the current state of an auction with the highest bid
+// and the lowest next bid. Clients poll it or follow auction.updated events
+// on /realtime.
+// GET /auctions/:id
+func GetAuction(c *gin.Context) {
+	var found models.Auction
+	if err := database.DB.First(&found, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "auction.not_found", nil)})
+		return
+	}
+
+	now := time.Now()
+	view := found
+	userID, _ := c.Get("userID")
+	if role, _ := c.Get("role"); role != "admin" && userID.(uint) != found.FarmerID {
+		view = auction.View(found)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"auction":     view,
+		"live":        found.Live(now),
+		"minimum_bid": auction.MinimumBid(found),
+		"now":         now,
+	})
+}
+
+// GetAuctionBids lists the bids of an auction, highest first
+// GET /auctions/:id/bids
+func GetAuctionBids(c *gin.Context) {
+	bids := []models.AuctionBid{}
+	if err := database.DB.Where("auction_id = ?", c.Param("id")).Order("amount DESC, id").Find(&bids).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "auction.bids_retrieve_failed", nil)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"bids": bids})
+}
+
+// PlaceAuctionBid bids per unit on a live auction
+// POST /auctions/:id/bids {"amount": 48}
+func PlaceAuctionBid(c *gin.Context) {
+	var input struct {
+		Amount float64 `json:"amount" binding:"required,gt=0"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var found models.Auction
+	if err := database.DB.Select("id").First(&found, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "auction.not_found", nil)})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	updated, bid, err := auction.PlaceBid(found.ID, userID.(uint), input.Amount, time.Now())
+	if err != nil {
+		auctionError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     i18n.Tc(c, "auction.bid_placed", nil),
+		"bid":         bid,
+		"auction":     auction.View(updated),
+		"minimum_bid": auction.MinimumBid(updated),
+	})
+}
+
+// CancelAuction cancels an open auction nobody has bid on yet
+// POST /auctions/:id/cancel
+func CancelAuction(c *gin.Context) {
+	var found models.Auction
+	if err := database.DB.First(&found, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "auction.not_found", nil)})
+		return
+	}
+	userID, _ := c.Get("userID")
+	if role, _ := c.Get("role"); role != "admin" && found.FarmerID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "auction.cancel_forbidden", nil)})
+		return
+	}
+
+	cancelled, err := auction.Cancel(found.ID, time.Now())
+	if err != nil {
+		auctionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "auction.cancelled", nil), "auction": cancelled})
+}
+
+func auctionError(c *gin.Context, err error) {
+	var tooLow *auction.BidTooLowError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "auction.not_found", nil)})
+	case errors.As(err, &tooLow):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       i18n.Tc(c, "auction.bid_too_low", map[string]interface{}{"minimum": tooLow.Minimum}),
+			"minimum_bid": tooLow.Minimum,
+		})
+	case errors.Is(err, auction.ErrNotLive):
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "auction.not_live", nil)})
+	case errors.Is(err, auction.ErrClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "auction.closed", nil)})
+	case errors.Is(err, auction.ErrHasBids):
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "auction.has_bids", nil)})
+	case errors.Is(err, auction.ErrOwnAuction):
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "auction.own_auction", nil)})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "auction.update_failed", nil), "details": err.Error()})
+	}
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"agro-connect/auction"
+	"agro-connect/database"
+	"agro-connect/database/testdb"
+	"agro-connect/models"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAuction auctions 3 kg of a 10 kg listing and returns the listing and
+// the auction
+func openAuction(t *testing.T) (models.Product, models.Auction) {
+	t.Helper()
+	db := testdb.Open(t, &models.User{}, &models.Product{}, &models.ProductVariant{},
+		&models.Auction{}, &models.AuctionBid{}, &models.Offer{}, &models.Order{}, &models.OrderItem{})
+
+	product := models.Product{UserID: testFarmerID, NameEn: "Garlic", Unit: "kg", PricePerUnit: 300,
+		Quantity: 10, Status: models.ProductStatusAvailable}
+	mustCreate(t, db, &product)
+	input := gin.H{"product_id": product.ID, "quantity": 3, "start_price": 250, "min_increment": 5, "duration_minutes": 60}
+	if code := serve(t, CreateAuction, testFarmerID, "farmer", nil, input); code != http.StatusCreated {
+		t.Fatalf("create auction: got status %d", code)
+	}
+	wantStock(t, "auction created", product.ID, 0, 7, models.ProductStatusAvailable)
+
+	var lot models.Auction
+	if err := db.First(&lot).Error; err != nil {
+		t.Fatal(err)
+	}
+	return product, lot
+}
+
+func TestAuctionLotReturnsWhenItDoesNotSell(t *testing.T) {
+	tests := []struct {
+		name  string
+		close func(lot models.Auction) error
+	}{
+		{"cancel", func(lot models.Auction) error {
+			_, err := auction.Cancel(lot.ID, time.Now())
+			return err
+		}},
+		{"unsold", func(lot models.Auction) error {
+			_, _, err := auction.Close(lot.ID, lot.EndsAt.Add(time.Minute))
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product, lot := openAuction(t)
+			if err := tt.close(lot); err != nil {
+				t.Fatal(err)
+			}
+			wantStock(t, tt.name, product.ID, 0, 10, models.ProductStatusAvailable)
+		})
+	}
+}
+
+func TestAuctionOrderCancelReturnsLot(t *testing.T) {
+	product, lot := openAuction(t)
+	if _, _, err := auction.PlaceBid(lot.ID, testBuyerID, 260, lot.StartsAt.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	_, order, err := auction.Close(lot.ID, lot.EndsAt.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order == nil || order.StockQuantity != 3 {
+		t.Fatalf("sold lot: got order %+v, want one holding 3 kg of stock", order)
+	}
+	wantStock(t, "sold", product.ID, 0, 7, models.ProductStatusAvailable)
+
+	if code := setOrderStatus(t, *order, "canceled"); code != http.StatusOK {
+		t.Fatalf("cancel order: got status %d", code)
+	}
+	wantStock(t, "order canceled", product.ID, 0, 10, models.ProductStatusAvailable)
+	if code := setOrderStatus(t, *order, "processing"); code != http.StatusOK {
+		t.Fatalf("reopen order: got status %d", code)
+	}
+	wantStock(t, "order reopened", product.ID, 0, 7, models.ProductStatusAvailable)
+
+	var saved models.Order
+	if err := database.DB.First(&saved, order.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.StockQuantity != 3 {
+		t.Fatalf("reopened order holds %g kg of stock, want 3", saved.StockQuantity)
+	}
+}
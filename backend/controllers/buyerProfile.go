@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"agro-connect/database"
+	"agro-connect/i18n"
 	"agro-connect/models"
 	"net/http"
 	"strconv"
@@ -21,7 +22,7 @@ func CreateBuyerProfile(c *gin.Context) {
 
 	// Validate required fields
 	if input.UserID == 0 || input.BusinessName == "" || input.ContactPhone == "" || input.District == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required fields"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "profile.missing_fields", nil)})
 		return
 	}
 
@@ -29,7 +30,7 @@ func CreateBuyerProfile(c *gin.Context) {
 	if input.PANNumber != "" {
 		var panCheck models.BuyerProfile
 		if err := database.DB.Where("pan_number = ?", input.PANNumber).First(&panCheck).Error; err == nil {
-			c.JSON(http.StatusConflict, gin.H{"error": "PAN number already in use"})
+			c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "profile.pan_in_use", nil)})
 			return
 		}
 	}
@@ -37,18 +38,20 @@ func CreateBuyerProfile(c *gin.Context) {
 	// Check if user already has a profile
 	var existingProfile models.BuyerProfile
 	if err := database.DB.Where("user_id = ?", input.UserID).First(&existingProfile).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Buyer profile already exists for this user"})
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "profile.buyer_exists", nil)})
 		return
 	}
 
+	input.Latitude, input.Longitude = geocode(input.District, input.Municipality, input.Latitude, input.Longitude, false)
+
 	// Create the new buyer profile
 	if err := database.DB.Create(&input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create buyer profile", "details": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "profile.buyer_create_failed", nil), "details": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Buyer profile created successfully",
+		"message": i18n.Tc(c, "profile.buyer_created", nil),
 		"profile": input,
 	})
 }
@@ -72,7 +75,7 @@ func GetAllBuyerProfiles(c *gin.Context) {
 
 	// Execute query with pagination
 	if err := query.Offset((page - 1) * pageSize).Limit(pageSize).Find(&profiles).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve buyer profiles"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "profile.buyers_retrieve_failed", nil)})
 		return
 	}
 
@@ -91,14 +94,14 @@ func GetBuyerProfileByID(c *gin.Context) {
 	if c.FullPath() == "/buyer-profile/me" {
 		authUserID, exists := c.Get("userID") // Changed to match middleware
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.Tc(c, "error.unauthenticated", nil)})
 			return
 		}
 
 		// Proper type assertion
 		userIDUint, ok := authUserID.(uint)
 		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "error.invalid_user_id", nil)})
 			return
 		}
 		userID = strconv.FormatUint(uint64(userIDUint), 10)
@@ -107,9 +110,9 @@ func GetBuyerProfileByID(c *gin.Context) {
 	var profile models.BuyerProfile
 	if err := database.DB.Where("user_id = ?", userID).First(&profile).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Buyer profile not found"})
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "profile.buyer_not_found", nil)})
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve buyer profile"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "profile.buyer_retrieve_failed", nil)})
 		}
 		return
 	}
@@ -125,14 +128,14 @@ func UpdateBuyerProfile(c *gin.Context) {
 	if c.FullPath() == "/buyer-profile/me" {
 		authUserID, exists := c.Get("userID") // Changed to match middleware
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.Tc(c, "error.unauthenticated", nil)})
 			return
 		}
 
 		// Proper type assertion
 		userIDUint, ok := authUserID.(uint)
 		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "error.invalid_user_id", nil)})
 			return
 		}
 		userID = strconv.FormatUint(uint64(userIDUint), 10)
@@ -146,7 +149,7 @@ func UpdateBuyerProfile(c *gin.Context) {
 
 	var profile models.BuyerProfile
 	if err := database.DB.Where("user_id = ?", userID).First(&profile).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Buyer profile not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "profile.buyer_not_found", nil)})
 		return
 	}
 
@@ -154,7 +157,7 @@ func UpdateBuyerProfile(c *gin.Context) {
 	if input.PANNumber != "" && input.PANNumber != profile.PANNumber {
 		var panCheck models.BuyerProfile
 		if err := database.DB.Where("pan_number = ?", input.PANNumber).Not("id = ?", profile.ID).First(&panCheck).Error; err == nil {
-			c.JSON(http.StatusConflict, gin.H{"error": "PAN number already in use"})
+			c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "profile.pan_in_use", nil)})
 			return
 		}
 	}
@@ -184,12 +187,19 @@ func UpdateBuyerProfile(c *gin.Context) {
 	if input.BusinessAddress != "" {
 		profile.BusinessAddress = input.BusinessAddress
 	}
+	moved := (input.District != "" && input.District != profile.District) ||
+		(input.Municipality != "" && input.Municipality != profile.Municipality)
 	if input.District != "" {
 		profile.District = input.District
 	}
 	if input.Municipality != "" {
 		profile.Municipality = input.Municipality
 	}
+	if input.Latitude != nil && input.Longitude != nil {
+		profile.Latitude, profile.Longitude = input.Latitude, input.Longitude
+		moved = false
+	}
+	profile.Latitude, profile.Longitude = geocode(profile.District, profile.Municipality, profile.Latitude, profile.Longitude, moved)
 	if input.WardNumber != 0 {
 		profile.WardNumber = input.WardNumber
 	}
@@ -201,12 +211,12 @@ func UpdateBuyerProfile(c *gin.Context) {
 	}
 
 	if err := database.DB.Save(&profile).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update buyer profile"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "profile.buyer_update_failed", nil)})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Buyer profile updated successfully",
+		"message": i18n.Tc(c, "profile.buyer_updated", nil),
 		"profile": profile,
 	})
 }
@@ -216,11 +226,11 @@ func DeleteBuyerProfile(c *gin.Context) {
 	userID := c.Param("user_id")
 
 	if err := database.DB.Where("user_id = ?", userID).Delete(&models.BuyerProfile{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete buyer profile"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "profile.buyer_delete_failed", nil)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Buyer profile deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "profile.buyer_deleted", nil)})
 }
 
 // VerifyBuyerProfile marks a buyer profile as verified
@@ -229,18 +239,18 @@ func VerifyBuyerProfile(c *gin.Context) {
 
 	var profile models.BuyerProfile
 	if err := database.DB.Where("user_id = ?", userID).First(&profile).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Buyer profile not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "profile.buyer_not_found", nil)})
 		return
 	}
 
 	profile.Verified = true
 	if err := database.DB.Save(&profile).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify buyer profile"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "profile.buyer_verify_failed", nil)})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Buyer profile verified successfully",
+		"message": i18n.Tc(c, "profile.buyer_verified", nil),
 		"profile": profile,
 	})
 }
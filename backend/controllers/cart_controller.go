@@ -0,0 +1,416 @@
+package controllers
+
+import (
+	"agro-connect/availability"
+	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/models"
+	"agro-connect/notify"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errCartEmpty is returned when checking out an empty cart
+var errCartEmpty = errors.New("your cart is empty")
+
+// cartError is a cart item that cannot be bought as it is
+type cartError string
+
+func (e cartError) Error() string { return string(e) }
+
+// CartItemInput adds a listing to the cart
+type CartItemInput struct {
+	ProductID uint    `json:"product_id" binding:"required"`
+	VariantID *uint   `json:"variant_id"`
+	Quantity  float64 `json:"quantity" binding:"required,gt=0"`
+}
+
+// cartLine is a cart item priced at the current listing price
+type cartLine struct {
+	models.CartItem
+	UnitPrice float64 `json:"unit_price"`
+	Subtotal  float64 `json:"subtotal"`
+	// Problem tells why the item cannot be checked out as it is
+	Problem string `json:"problem,omitempty"`
+}
+
+// cartFarmer sums the cart items of one farmer, which check out as one order
+type cartFarmer struct {
+	FarmerID uint    `json:"farmer_id"`
+	Items    int     `json:"items"`
+	Total    float64 `json:"total"`
+}
+
+// checkCartItem checks that a quantity of a listing can be bought and
+// returns its price per unit. Variants are read through db, so checkout
+// checks them within its transaction.
+func checkCartItem(c *gin.Context, db *gorm.DB, product models.Product, variantID *uint, quantity float64) (float64, error) {
+	if product.Status != models.ProductStatusAvailable || product.AwaitingHarvest() {
+		return 0, cartError(i18n.Tc(c, "cart.not_available", map[string]interface{}{"product": product.NameEn}))
+	}
+
+	price, stock := product.PricePerUnit, product.Quantity
+	if variantID == nil {
+		var variants int64
+		if err := db.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Count(&variants).Error; err != nil {
+			return 0, err
+		}
+		if variants > 0 {
+			return 0, cartError(product.NameEn + ": " + variantMessage(c, errVariantRequired))
+		}
+	} else {
+		var variant models.ProductVariant
+		if err := db.Where("id = ? AND product_id = ?", *variantID, product.ID).First(&variant).Error; err != nil {
+			return 0, cartError(product.NameEn + ": " + variantMessage(c, errVariantNotFound))
+		}
+		price, stock = variant.PricePerUnit, variant.Quantity
+	}
+	if quantity > stock {
+		return 0, cartError(stockMessage(c, &availability.StockError{Product: product.NameEn, Available: stock, Unit: product.Unit}))
+	}
+	return price, nil
+}
+
+// stockKey identifies the stock a cart item is bought from: a listing, or a
+// variant of it
+type stockKey struct {
+	productID uint
+	variantID uint // 0 for listings without variants
+}
+
+// stockMessage translates a StockError
+func stockMessage(c *gin.Context, short *availability.StockError) string {
+	return i18n.Tc(c, "stock.short", map[string]interface{}{
+		"available": short.Available, "unit": short.Unit, "product": short.Product,
+	})
+}
+
+func cartStockKey(item models.CartItem) stockKey {
+	return stockKeyOf(item.ProductID, item.VariantID)
+}
+
+func stockKeyOf(productID uint, variantID *uint) stockKey {
+	key := stockKey{productID: productID}
+	if variantID != nil {
+		key.variantID = *variantID
+	}
+	return key
+}
+
+// sortStockKeys puts stock in the order its rows are locked in, so that
+// concurrent checkouts and cancellations cannot deadlock
+func sortStockKeys(keys []stockKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].productID != keys[j].productID {
+			return keys[i].productID < keys[j].productID
+		}
+		return keys[i].variantID < keys[j].variantID
+	})
+}
+
+func (k stockKey) variant() *uint {
+	if k.variantID == 0 {
+		return nil
+	}
+	id := k.variantID
+	return &id
+}
+
+// subtotal returns the price of a quantity, rounded to paisa
+func subtotal(price, quantity float64) float64 {
+	return math.Round(price*quantity*100) / 100
+}
+
+// GetCart returns the signed in buyer's cart priced at the current listing
+// prices, with a total per farmer as the cart will be split into orders
+// GET /cart
+func GetCart(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var items []models.CartItem
+	if err := database.DB.Where("buyer_id = ?", userID).Preload("Product").Order("id").Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "cart.retrieve_failed", nil)})
+		return
+	}
+
+	lines := make([]cartLine, len(items))
+	farmers := []*cartFarmer{}
+	byFarmer := map[uint]*cartFarmer{}
+	var total float64
+	for i, item := range items {
+		lines[i].CartItem = item
+		if item.Product == nil {
+			lines[i].Problem = "This listing was removed"
+			continue
+		}
+		price, err := checkCartItem(c, database.DB, *item.Product, item.VariantID, item.Quantity)
+		if err != nil {
+			lines[i].Problem = err.Error()
+			continue
+		}
+		lines[i].UnitPrice = price
+		lines[i].Subtotal = subtotal(price, item.Quantity)
+
+		farmer := byFarmer[item.Product.UserID]
+		if farmer == nil {
+			farmer = &cartFarmer{FarmerID: item.Product.UserID}
+			byFarmer[farmer.FarmerID] = farmer
+			farmers = append(farmers, farmer)
+		}
+		farmer.Items++
+		farmer.Total += lines[i].Subtotal
+		total += lines[i].Subtotal
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": lines, "farmers": farmers, "total": math.Round(total*100) / 100})
+}
+
+// AddCartItem adds a listing to the cart, or adds to the quantity of the
+// listing already in the cart
+// POST /cart/items {"product_id": 12, "variant_id": 3, "quantity": 20}
+func AddCartItem(c *gin.Context) {
+	var input CartItemInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var product models.Product
+	if err := database.DB.Where(publicProductsSQL).First(&product, input.ProductID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "product.not_found", nil)})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	item := models.CartItem{BuyerID: userID.(uint), ProductID: product.ID, VariantID: input.VariantID}
+	query := database.DB.Where("buyer_id = ? AND product_id = ?", item.BuyerID, product.ID)
+	if input.VariantID == nil {
+		query = query.Where("variant_id IS NULL")
+	} else {
+		query = query.Where("variant_id = ?", *input.VariantID)
+	}
+	status := http.StatusCreated
+	if err := query.First(&item).Error; err == nil {
+		status = http.StatusOK
+	}
+	item.Quantity += input.Quantity
+
+	if _, err := checkCartItem(c, database.DB, product, item.VariantID, item.Quantity); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.DB.Save(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "cart.add_failed", nil), "details": err.Error()})
+		return
+	}
+	c.JSON(status, gin.H{"message": i18n.Tc(c, "cart.added", nil), "item": item})
+}
+
+// UpdateCartItem changes the quantity of a cart item
+// PUT /cart/items/:id {"quantity": 30}
+func UpdateCartItem(c *gin.Context) {
+	var input struct {
+		Quantity float64 `json:"quantity" binding:"required,gt=0"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	var item models.CartItem
+	if err := database.DB.Where("id = ? AND buyer_id = ?", c.Param("id"), userID).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "cart.item_not_found", nil)})
+		return
+	}
+	var product models.Product
+	if err := database.DB.First(&product, item.ProductID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "product.not_found", nil)})
+		return
+	}
+	if _, err := checkCartItem(c, database.DB, product, item.VariantID, input.Quantity); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item.Quantity = input.Quantity
+	if err := database.DB.Model(&item).Update("quantity", item.Quantity).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "cart.update_failed", nil)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "cart.updated", nil), "item": item})
+}
+
+// RemoveCartItem removes an item from the cart
+// DELETE /cart/items/:id
+func RemoveCartItem(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	result := database.DB.Unscoped().Where("id = ? AND buyer_id = ?", c.Param("id"), userID).Delete(&models.CartItem{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "cart.update_failed", nil)})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "cart.item_not_found", nil)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "cart.removed", nil)})
+}
+
+// ClearCart empties the cart
+// DELETE /cart
+func ClearCart(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	if err := database.DB.Unscoped().Where("buyer_id = ?", userID).Delete(&models.CartItem{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "cart.clear_failed", nil)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "cart.cleared", nil)})
+}
+
+// Checkout turns the cart, or the given items of it, into orders at the
+// current listing prices: one order per farmer with a line per item. The
+// bought quantities are taken from the listings' stock and the checked out
+// items leave the cart.
+// POST /cart/checkout {"item_ids": [4, 5]}
+func Checkout(c *gin.Context) {
+	var input struct {
+		ItemIDs []uint `json:"item_ids"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userID, _ := c.Get("userID")
+	var orders []models.Order
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("buyer_id = ?", userID)
+		if len(input.ItemIDs) > 0 {
+			query = query.Where("id IN ?", input.ItemIDs)
+		}
+		var items []models.CartItem
+		if err := query.Order("id").Find(&items).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return errCartEmpty
+		}
+
+		// Check each listing and variant for the total quantity bought of it,
+		// with its rows locked in a fixed order, before any stock is taken
+		totals := map[stockKey]float64{}
+		var keys []stockKey
+		for _, item := range items {
+			key := cartStockKey(item)
+			if _, ok := totals[key]; !ok {
+				keys = append(keys, key)
+			}
+			totals[key] += item.Quantity
+		}
+		sortStockKeys(keys)
+
+		products := map[uint]models.Product{}
+		prices := map[stockKey]float64{}
+		for _, key := range keys {
+			product, _, err := availability.LockStock(tx, key.productID, key.variant())
+			switch {
+			case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+				return err
+			case product.ID == 0:
+				return cartError(i18n.Tc(c, "cart.listing_gone", map[string]interface{}{"product_id": key.productID}))
+			}
+			// A missing variant is reported by checkCartItem
+			price, err := checkCartItem(c, tx, product, key.variant(), totals[key])
+			if err != nil {
+				return err
+			}
+			products[key.productID] = product
+			prices[key] = price
+		}
+
+		today := availability.Today()
+		for _, key := range keys {
+			if _, err := availability.TakeStock(tx, key.productID, key.variant(), totals[key], today); err != nil {
+				return err
+			}
+		}
+
+		var farmers []uint
+		byFarmer := map[uint]*models.Order{}
+		ids := make([]uint, len(items))
+		for i, item := range items {
+			ids[i] = item.ID
+			product, price := products[item.ProductID], prices[cartStockKey(item)]
+
+			order := byFarmer[product.UserID]
+			if order == nil {
+				order = &models.Order{
+					FarmerID:  product.UserID,
+					BuyerID:   item.BuyerID,
+					ProductID: product.ID,
+					VariantID: item.VariantID,
+					OrderDate: today.String(),
+					Status:    "processing",
+				}
+				byFarmer[product.UserID] = order
+				farmers = append(farmers, product.UserID)
+			}
+			line := models.OrderItem{
+				ProductID: product.ID,
+				VariantID: item.VariantID,
+				Quantity:  item.Quantity,
+				Unit:      product.Unit,
+				UnitPrice: price,
+				Subtotal:  subtotal(price, item.Quantity),
+				Status:    "processing",
+			}
+			order.Items = append(order.Items, line)
+			order.Total += line.Subtotal
+		}
+
+		for _, farmerID := range farmers {
+			order := byFarmer[farmerID]
+			order.Total = math.Round(order.Total*100) / 100
+			if err := tx.Create(order).Error; err != nil {
+				return err
+			}
+			orders = append(orders, *order)
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&models.CartItem{}).Error
+	})
+
+	var invalid cartError
+	var short *availability.StockError
+	switch {
+	case errors.Is(err, errCartEmpty):
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "cart.empty", nil)})
+		return
+	case errors.As(err, &short):
+		c.JSON(http.StatusBadRequest, gin.H{"error": stockMessage(c, short)})
+		return
+	case errors.As(err, &invalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "cart.checkout_failed", nil), "details": err.Error()})
+		return
+	}
+
+	for _, order := range orders {
+		notify.Send(order.FarmerID, notify.TypeOrder, notify.EventOrderPlaced, order.ID, map[string]interface{}{
+			"order_id": order.ID,
+			"count":    len(order.Items),
+			"total":    order.Total,
+		})
+		publishOrderUpdate(order)
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": i18n.Tc(c, "cart.order_placed", nil), "orders": orders})
+}
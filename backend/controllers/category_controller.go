@@ -0,0 +1,383 @@
+package controllers
+
+import (
+	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/models"
+	"agro-connect/units"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCategoryDepth guards walks up the tree against corrupted parent links
+const maxCategoryDepth = 16
+
+// CategoryDefaults are the defaults a category applies to its products,
+// after inheriting unset values from its ancestors
+type CategoryDefaults struct {
+	AllowedUnits  []string `json:"allowed_units"`
+	Perishable    bool     `json:"perishable"`
+	ShelfLifeDays int      `json:"shelf_life_days"`
+	KgPerLitre    float64  `json:"kg_per_litre"`
+}
+
+// allowsUnit reports whether unit may be used, any unit being allowed when
+// no category in the chain restricts them
+func (d CategoryDefaults) allowsUnit(unit string) bool {
+	if len(d.AllowedUnits) == 0 {
+		return true
+	}
+	for _, allowed := range d.AllowedUnits {
+		if strings.EqualFold(allowed, unit) {
+			return true
+		}
+	}
+	return false
+}
+
+// categoryAncestors returns the category followed by its parents up to the root
+func categoryAncestors(category models.Category) ([]models.Category, error) {
+	chain := []models.Category{category}
+	for current := category; current.ParentID != nil; {
+		if len(chain) > maxCategoryDepth {
+			return nil, fmt.Errorf("category %d is nested too deeply", category.ID)
+		}
+		var parent models.Category
+		if err := database.DB.First(&parent, *current.ParentID).Error; err != nil {
+			return nil, fmt.Errorf("parent category %d not found", *current.ParentID)
+		}
+		chain = append(chain, parent)
+		current = parent
+	}
+	return chain, nil
+}
+
+// resolveCategoryDefaults takes each default from the nearest category in
+// the chain that sets it
+func resolveCategoryDefaults(category models.Category) (CategoryDefaults, error) {
+	chain, err := categoryAncestors(category)
+	if err != nil {
+		return CategoryDefaults{}, err
+	}
+
+	var defaults CategoryDefaults
+	perishableSet := false
+	for _, c := range chain {
+		if defaults.AllowedUnits == nil && len(c.AllowedUnits) > 0 {
+			defaults.AllowedUnits = c.AllowedUnits
+		}
+		if !perishableSet && c.Perishable != nil {
+			defaults.Perishable, perishableSet = *c.Perishable, true
+		}
+		if defaults.ShelfLifeDays == 0 && c.ShelfLifeDays > 0 {
+			defaults.ShelfLifeDays = c.ShelfLifeDays
+		}
+		if defaults.KgPerLitre == 0 && c.KgPerLitre > 0 {
+			defaults.KgPerLitre = c.KgPerLitre
+		}
+	}
+	return defaults, nil
+}
+
+// applyCategoryDefaults links the product to its category, looked up by ID or
+// else by the free-text category name, and applies the category defaults:
+// the unit, perishability and the end of availability from the shelf life.
+// Products without a category are left unchanged. The resolved defaults are
+// returned.
+func applyCategoryDefaults(product *models.Product) (CategoryDefaults, error) {
+	var category models.Category
+	switch {
+	case product.CategoryID != nil:
+		if err := database.DB.First(&category, *product.CategoryID).Error; err != nil {
+			return CategoryDefaults{}, fmt.Errorf("category %d not found", *product.CategoryID)
+		}
+	case product.Category != "":
+		found, err := database.FindCategoryByName(product.Category)
+		if err != nil {
+			return CategoryDefaults{}, err
+		}
+		if found == nil {
+			return CategoryDefaults{}, fmt.Errorf("unknown category: %s", product.Category)
+		}
+		category = *found
+	default:
+		return CategoryDefaults{}, nil
+	}
+
+	defaults, err := resolveCategoryDefaults(category)
+	if err != nil {
+		return CategoryDefaults{}, err
+	}
+
+	if product.Unit == "" && len(defaults.AllowedUnits) > 0 {
+		product.Unit = defaults.AllowedUnits[0]
+	} else if !defaults.allowsUnit(product.Unit) {
+		return CategoryDefaults{}, fmt.Errorf("unit %s is not allowed for category %s, use one of: %s",
+			product.Unit, category.NameEn, strings.Join(defaults.AllowedUnits, ", "))
+	}
+
+	if product.AvailableTo.IsZero() && !product.AvailableFrom.IsZero() && defaults.ShelfLifeDays > 0 {
+		product.AvailableTo = models.NewDate(product.AvailableFrom.AddDate(0, 0, defaults.ShelfLifeDays))
+	}
+
+	product.CategoryID = &category.ID
+	product.Category = category.Slug
+	product.Perishable = defaults.Perishable
+	return defaults, nil
+}
+
+// productDensity returns the kg per litre of the product's category, or 0
+func productDensity(product models.Product) float64 {
+	if product.CategoryID == nil {
+		return 0
+	}
+	var category models.Category
+	if err := database.DB.First(&category, *product.CategoryID).Error; err != nil {
+		return 0
+	}
+	defaults, err := resolveCategoryDefaults(category)
+	if err != nil {
+		return 0
+	}
+	return defaults.KgPerLitre
+}
+
+// categorySubtreeSQL selects the IDs of a category and all its descendants
+const categorySubtreeSQL = `WITH RECURSIVE tree AS (
+		SELECT id FROM categories WHERE id = ? AND deleted_at IS NULL
+		UNION ALL
+		SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id
+		WHERE categories.deleted_at IS NULL
+	) SELECT id FROM tree`
+
+// GetCategories returns the category tree, or a flat list with ?flat=true
+// GET /categories
+func GetCategories(c *gin.Context) {
+	var categories []models.Category
+	if err := database.DB.Order("sort_order, name_en").Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "category.retrieve_failed", nil)})
+		return
+	}
+
+	if c.Query("flat") == "true" {
+		c.JSON(http.StatusOK, gin.H{"categories": categories})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"categories": buildCategoryTree(categories, nil)})
+}
+
+// buildCategoryTree nests the children of parentID, keeping the input order
+func buildCategoryTree(categories []models.Category, parentID *uint) []models.Category {
+	tree := []models.Category{}
+	for _, category := range categories {
+		if (parentID == nil && category.ParentID == nil) ||
+			(parentID != nil && category.ParentID != nil && *category.ParentID == *parentID) {
+			category.Children = buildCategoryTree(categories, &category.ID)
+			tree = append(tree, category)
+		}
+	}
+	return tree
+}
+
+// GetCategoryByID returns a category with its path from the root and the
+// defaults it applies to products
+func GetCategoryByID(c *gin.Context) {
+	var category models.Category
+	if err := database.DB.First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "category.not_found", nil)})
+		return
+	}
+
+	chain, err := categoryAncestors(category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defaults, err := resolveCategoryDefaults(category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	path := make([]models.Category, len(chain))
+	for i, ancestor := range chain {
+		path[len(chain)-1-i] = ancestor
+	}
+
+	if err := database.DB.Where("parent_id = ?", category.ID).Order("sort_order, name_en").Find(&category.Children).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "category.subcategories_retrieve_failed", nil)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"category": category,
+		"path":     path,
+		"defaults": defaults,
+	})
+}
+
+// validateCategory normalizes the input and checks the slug is free and the
+// parent exists without creating a cycle
+func validateCategory(c *gin.Context, category *models.Category) error {
+	category.NameEn = strings.TrimSpace(category.NameEn)
+	if category.NameEn == "" {
+		return errors.New(i18n.Tc(c, "category.name_required", nil))
+	}
+	if category.Slug == "" {
+		category.Slug = category.NameEn
+	}
+	category.Slug = strings.Join(strings.Fields(strings.ToLower(category.Slug)), "-")
+	if category.ShelfLifeDays < 0 {
+		return errors.New(i18n.Tc(c, "category.negative_shelf_life", nil))
+	}
+	if category.KgPerLitre < 0 {
+		return errors.New(i18n.Tc(c, "category.negative_density", nil))
+	}
+	for i, unit := range category.AllowedUnits {
+		code, err := units.Normalize(unit)
+		if err != nil {
+			return err
+		}
+		category.AllowedUnits[i] = code
+	}
+
+	var existing models.Category
+	if err := database.DB.Where("slug = ? AND id <> ?", category.Slug, category.ID).First(&existing).Error; err == nil {
+		return errors.New(i18n.Tc(c, "category.slug_in_use", map[string]interface{}{"slug": category.Slug}))
+	}
+
+	if category.ParentID == nil {
+		return nil
+	}
+	var parent models.Category
+	if err := database.DB.First(&parent, *category.ParentID).Error; err != nil {
+		return errors.New(i18n.Tc(c, "category.parent_not_found", map[string]interface{}{"id": *category.ParentID}))
+	}
+	ancestors, err := categoryAncestors(parent)
+	if err != nil {
+		return err
+	}
+	for _, ancestor := range ancestors {
+		if category.ID != 0 && ancestor.ID == category.ID {
+			return errors.New(i18n.Tc(c, "category.cycle", nil))
+		}
+	}
+	return nil
+}
+
+// CreateCategory adds a category to the tree
+// POST /admin/categories
+// {"parent_id": 1, "name_en": "Leafy vegetables", "name_np": "सागपात", "allowed_units": ["kg", "bunch"]}
+func CreateCategory(c *gin.Context) {
+	var category models.Category
+	if err := c.ShouldBindJSON(&category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	category.ID = 0
+	category.Children = nil
+
+	if err := validateCategory(c, &category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Create(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "category.create_failed", nil), "details": err.Error()})
+		return
+	}
+
+	mapProductCategories()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  i18n.Tc(c, "category.created", nil),
+		"category": category,
+	})
+}
+
+// UpdateCategory replaces the fields of a category, including its parent
+// PUT /admin/categories/:id
+func UpdateCategory(c *gin.Context) {
+	var category models.Category
+	if err := database.DB.First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "category.not_found", nil)})
+		return
+	}
+
+	var input models.Category
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category.ParentID = input.ParentID
+	category.Slug = input.Slug
+	category.NameEn = input.NameEn
+	category.NameNp = input.NameNp
+	category.Aliases = input.Aliases
+	category.SortOrder = input.SortOrder
+	category.AllowedUnits = input.AllowedUnits
+	category.Perishable = input.Perishable
+	category.ShelfLifeDays = input.ShelfLifeDays
+	category.KgPerLitre = input.KgPerLitre
+
+	if err := validateCategory(c, &category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Save(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "category.update_failed", nil)})
+		return
+	}
+
+	// Keep the denormalized slug on products in sync
+	database.DB.Model(&models.Product{}).Where("category_id = ?", category.ID).Update("category", category.Slug)
+	mapProductCategories()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  i18n.Tc(c, "category.updated", nil),
+		"category": category,
+	})
+}
+
+// DeleteCategory removes a category without subcategories or products
+// DELETE /admin/categories/:id
+func DeleteCategory(c *gin.Context) {
+	var category models.Category
+	if err := database.DB.First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "category.not_found", nil)})
+		return
+	}
+
+	var children, products int64
+	database.DB.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&children)
+	database.DB.Model(&models.Product{}).Where("category_id = ?", category.ID).Count(&products)
+	if children > 0 || products > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "category.not_empty", map[string]interface{}{
+			"subcategories": children, "products": products,
+		})})
+		return
+	}
+
+	// Hard delete so the slug can be reused
+	if err := database.DB.Unscoped().Delete(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "category.delete_failed", nil)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "category.deleted", nil)})
+}
+
+// mapProductCategories links unmapped products after aliases changed
+func mapProductCategories() {
+	if err := database.MapProductCategories(database.DB); err != nil {
+		log.Printf("Failed to map product categories: %v", err)
+	}
+}
@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"agro-connect/database"
+	"agro-connect/geo"
+	"agro-connect/i18n"
 	"agro-connect/models"
 	"fmt"
 	"net/http"
@@ -17,20 +19,22 @@ func CreateFarmerProfile(c *gin.Context) {
 		return
 	}
 
+	geocodeFarmerProfile(&profile, nil)
+
 	if err := database.DB.Create(&profile).Error; err != nil {
 		fmt.Println("DB Error:", err) // print actual DB error
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create profile", "details": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "profile.farmer_create_failed", nil), "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Profile created successfully", "profile": profile})
+	c.JSON(http.StatusCreated, gin.H{"message": i18n.Tc(c, "profile.farmer_created", nil), "profile": profile})
 }
 
 func GetFarmerProfile(c *gin.Context) {
 	var profile []models.FarmerProfile
 
 	if err := database.DB.Find(&profile).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve profiles"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "profile.farmers_retrieve_failed", nil)})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"profiles": profile})
@@ -41,7 +45,7 @@ func GetFarmerProfileByID(c *gin.Context) {
 	var profile models.FarmerProfile
 
 	if err := database.DB.First(&profile, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "profile.farmer_not_found", nil)})
 		return
 	}
 
@@ -53,21 +57,24 @@ func UpdateFarmerProfile(c *gin.Context) {
 	var profile models.FarmerProfile
 
 	if err := database.DB.First(&profile, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "profile.farmer_not_found", nil)})
 		return
 	}
 
+	previous := profile
 	if err := c.ShouldBindJSON(&profile); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	geocodeFarmerProfile(&profile, &previous)
+
 	if err := database.DB.Save(&profile).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "profile.farmer_update_failed", nil)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully", "profile": profile})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "profile.farmer_updated", nil), "profile": profile})
 }
 
 func DeleteFarmerProfile(c *gin.Context) {
@@ -75,14 +82,54 @@ func DeleteFarmerProfile(c *gin.Context) {
 	var profile models.FarmerProfile
 
 	if err := database.DB.First(&profile, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "profile.farmer_not_found", nil)})
 		return
 	}
 
 	if err := database.DB.Delete(&profile).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete profile"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "profile.farmer_delete_failed", nil)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Profile deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "profile.farmer_deleted", nil)})
+}
+
+// geocodeFarmerProfile fills in the farm coordinates from its district and
+// municipality, falling back to the district on the user account. Explicit
+// coordinates are kept unless the location changed without new coordinates.
+func geocodeFarmerProfile(profile *models.FarmerProfile, previous *models.FarmerProfile) {
+	if profile.District == "" {
+		var user models.User
+		if err := database.DB.Select("district").First(&user, profile.UserID).Error; err == nil {
+			profile.District = user.District
+		}
+	}
+
+	moved := previous != nil &&
+		(previous.District != profile.District || previous.Municipality != profile.Municipality) &&
+		sameCoordinates(previous.Latitude, profile.Latitude) && sameCoordinates(previous.Longitude, profile.Longitude)
+	profile.Latitude, profile.Longitude = geocode(profile.District, profile.Municipality, profile.Latitude, profile.Longitude, moved)
+}
+
+// geocode returns the given coordinates, or the centroid of the municipality
+// when they are missing or stale. Unknown places leave them unchanged.
+func geocode(district, municipality string, latitude, longitude *float64, stale bool) (*float64, *float64) {
+	if latitude != nil && longitude != nil && !stale {
+		return latitude, longitude
+	}
+	point, ok := geo.Geocode(district, municipality)
+	if !ok {
+		if stale {
+			return nil, nil
+		}
+		return latitude, longitude
+	}
+	return &point.Latitude, &point.Longitude
+}
+
+func sameCoordinates(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
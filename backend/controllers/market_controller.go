@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"agro-connect/database"
+	"agro-connect/market"
+	"agro-connect/models"
+	"agro-connect/units"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultPriceRangeDays is the period returned without from/to
+	defaultPriceRangeDays = 30
+	// maxPriceRangeDays limits the period of one request
+	maxPriceRangeDays = 366
+)
+
+// GetMarketPrices returns daily price statistics for charts. Without a
+// district the statistics cover the whole country.
+// GET /market/prices?category=&district=&unit=&from=2024-01-01&to=2024-01-31
+func GetMarketPrices(c *gin.Context) {
+	to := market.Today()
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		to = market.Day(parsed)
+	}
+	from := to.AddDate(0, 0, -(defaultPriceRangeDays - 1))
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		from = market.Day(parsed)
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+	if to.Sub(from) > maxPriceRangeDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date range is limited to " + strconv.Itoa(maxPriceRangeDays) + " days"})
+		return
+	}
+
+	query := database.DB.Model(&models.DailyPriceStat{}).
+		Where("date BETWEEN ? AND ?", from.Format("2006-01-02"), to.Format("2006-01-02"))
+
+	category := c.Query("category")
+	if category != "" {
+		// Statistics are stored per category slug; unmapped free-text
+		// categories are compared by name
+		slug := strings.ToLower(strings.TrimSpace(category))
+		if id, err := strconv.ParseUint(category, 10, 64); err == nil {
+			var found models.Category
+			if err := database.DB.First(&found, id).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+				return
+			}
+			slug = found.Slug
+		} else if found, err := database.FindCategoryByName(category); err == nil && found != nil {
+			slug = found.Slug
+		}
+		category = slug
+		query = query.Where("LOWER(category) = ?", category)
+	}
+
+	district := strings.TrimSpace(c.Query("district"))
+	query = query.Where("LOWER(district) = LOWER(?)", district)
+
+	if unit := c.Query("unit"); unit != "" {
+		if code, err := units.Normalize(unit); err == nil {
+			unit = code
+		}
+		query = query.Where("LOWER(unit) = LOWER(?)", unit)
+	}
+
+	var stats []models.DailyPriceStat
+	if err := query.Order("date, category, unit").Find(&stats).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve market prices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from.Format("2006-01-02"),
+		"to":       to.Format("2006-01-02"),
+		"category": category,
+		"district": district,
+		"stats":    stats,
+	})
+}
+
+// GetProductPriceHistory lists every price a product was listed at, oldest
+// first
+// GET /products/:id/price-history
+func GetProductPriceHistory(c *gin.Context) {
+	var history []models.ProductPriceHistory
+	if err := database.DB.Where("product_id = ?", c.Param("id")).
+		Order("recorded_at, id").Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve price history"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
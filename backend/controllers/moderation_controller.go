@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/models"
+	"agro-connect/moderation"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// publicProductsSQL hides listings that are not published from public
+// listings and search
+const publicProductsSQL = "products.status NOT IN ('draft', 'pending_review', 'archived')"
+
+// moderationRequired reports whether listings of the signed in user go
+// through moderation
+func moderationRequired(c *gin.Context) bool {
+	if role, _ := c.Get("role"); role == "admin" {
+		return false
+	}
+	userID, _ := c.Get("userID")
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return true
+	}
+	return moderation.Required(user)
+}
+
+// moderateListing puts a saved listing into the moderation queue when the
+// save published it or materially changed a published listing, unless the
+// user bypasses moderation. previous is nil for new listings. extra lists
+// material changes the listing itself does not show, e.g. an added image.
+func moderateListing(c *gin.Context, db *gorm.DB, previous *models.Product, product *models.Product, extra []string) error {
+	if product.Status == models.ProductStatusDraft || product.Status == models.ProductStatusArchived {
+		return nil
+	}
+	if !moderationRequired(c) {
+		return nil
+	}
+
+	if previous == nil {
+		_, err := moderation.Submit(db, product, models.ModerationReasonCreated, nil)
+		return err
+	}
+
+	changes := moderation.MaterialChanges(*previous, *product)
+	for _, change := range extra {
+		if !slices.Contains(changes, change) {
+			changes = append(changes, change)
+		}
+	}
+	switch previous.Status {
+	case models.ProductStatusDraft, models.ProductStatusPendingReview:
+		// Publishing a draft, or a listing waiting for review, needs approval
+		if product.Status == models.ProductStatusPendingReview && len(changes) == 0 {
+			return nil
+		}
+		_, err := moderation.Submit(db, product, models.ModerationReasonPublished, changes)
+		return err
+	default:
+		if len(changes) == 0 {
+			return nil
+		}
+		_, err := moderation.Submit(db, product, models.ModerationReasonEdited, changes)
+		return err
+	}
+}
+
+// moderateListingUpdate reloads a listing after an update within db, the
+// transaction of the update, moderates it against its previous version and
+// refreshes product with the result
+func moderateListingUpdate(c *gin.Context, db *gorm.DB, previous models.Product, product *models.Product, extra []string) error {
+	var current models.Product
+	if err := db.First(&current, previous.ID).Error; err != nil {
+		return err
+	}
+	if err := moderateListing(c, db, &previous, &current, extra); err != nil {
+		return fmt.Errorf("Failed to submit listing for review: %w", err)
+	}
+	product.Status = current.Status
+	return nil
+}
+
+// imageChange is the material change of a save that added an image
+func imageChange(added bool) []string {
+	if added {
+		return []string{"image"}
+	}
+	return nil
+}
+
+// GetModerationQueue lists moderation entries, by default the pending ones
+// with flagged listings first and then oldest first
+// GET /admin/moderation?status=pending&flagged=true&page=1&limit=20
+func GetModerationQueue(c *gin.Context) {
+	status := c.DefaultQuery("status", models.ModerationPending)
+	switch status {
+	case models.ModerationPending, models.ModerationApproved, models.ModerationRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "moderation.invalid_status", nil)})
+		return
+	}
+
+	query := database.DB.Model(&models.ProductModeration{}).Where("status = ?", status)
+	if flagged := c.Query("flagged"); flagged != "" {
+		query = query.Where("flagged = ?", flagged == "true")
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "moderation.count_failed", nil)})
+		return
+	}
+
+	order := "flagged DESC, created_at ASC"
+	if status != models.ModerationPending {
+		order = "reviewed_at DESC"
+	}
+	page := c.DefaultQuery("page", "1")
+	limit := c.DefaultQuery("limit", "20")
+
+	var entries []models.ProductModeration
+	if err := query.Preload("Product.Images", orderedImages).
+		Order(order).Scopes(Paginate(page, limit)).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "moderation.retrieve_failed", nil)})
+		return
+	}
+
+	pageInt, limitInt := paginationValues(page, limit)
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"page":    pageInt,
+		"limit":   limitInt,
+		"total":   total,
+	})
+}
+
+// ModerationDecisionInput is the note or reason of a moderation decision
+type ModerationDecisionInput struct {
+	Note   string `json:"note"`
+	Reason string `json:"reason"`
+}
+
+// ApproveListing publishes a listing waiting for review
+// POST /admin/moderation/:id/approve {"note": "optional"}
+func ApproveListing(c *gin.Context) {
+	entry, ok := pendingModeration(c)
+	if !ok {
+		return
+	}
+
+	var input ModerationDecisionInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	adminID, _ := c.Get("userID")
+	if err := moderation.Approve(&entry, adminID.(uint), input.Note); err != nil {
+		moderationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "moderation.approved", nil), "moderation": entry})
+}
+
+// RejectListing returns a listing waiting for review to the farmer as a draft
+// POST /admin/moderation/:id/reject {"reason": "Photo does not show the produce"}
+func RejectListing(c *gin.Context) {
+	entry, ok := pendingModeration(c)
+	if !ok {
+		return
+	}
+
+	var input ModerationDecisionInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "moderation.reason_required", nil)})
+		return
+	}
+
+	adminID, _ := c.Get("userID")
+	if err := moderation.Reject(&entry, adminID.(uint), input.Reason); err != nil {
+		moderationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "moderation.rejected", nil), "moderation": entry})
+}
+
+// GetProductModeration lists the moderation history of one of the farmer's
+// listings, newest first
+// GET /products/:id/moderation
+func GetProductModeration(c *gin.Context) {
+	product, ok := ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	var entries []models.ProductModeration
+	if err := database.DB.Where("product_id = ?", product.ID).Order("created_at DESC").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "moderation.history_failed", nil)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": product.Status, "moderation": entries})
+}
+
+// pendingModeration loads the moderation entry of the request
+func pendingModeration(c *gin.Context) (models.ProductModeration, bool) {
+	var entry models.ProductModeration
+	if err := database.DB.First(&entry, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "moderation.not_found", nil)})
+		return entry, false
+	}
+	if entry.Status != models.ModerationPending {
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "moderation.already_decided", map[string]interface{}{"status": entry.Status})})
+		return entry, false
+	}
+	return entry, true
+}
+
+func moderationError(c *gin.Context, err error) {
+	if errors.Is(err, moderation.ErrReviewed) {
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "moderation.reviewed", nil)})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "moderation.save_failed", nil), "details": err.Error()})
+}
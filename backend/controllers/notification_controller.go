@@ -0,0 +1,345 @@
+package controllers
+
+import (
+	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/models"
+	"agro-connect/notify"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notificationTypes are the notification categories users can set channel
+// preferences for
+var notificationTypes = []string{
+	notify.TypeOffer,
+	notify.TypeOrder,
+	notify.TypeTransport,
+	notify.TypeSystem,
+	notify.TypeProduct,
+}
+
+// notifyOfferCreated tells the farmer who owns the product about a new offer
+func notifyOfferCreated(offer models.Offer) {
+	var product models.Product
+	if err := database.DB.First(&product, offer.ProductID).Error; err != nil {
+		log.Printf("Failed to load product %d for offer notification: %v", offer.ProductID, err)
+		return
+	}
+
+	productEn, productNp := product.NameEn, product.NameNp
+	if productNp == "" {
+		productNp = product.NameEn
+	}
+	// Name the variant the offer is for
+	if offer.VariantID != nil {
+		var variant models.ProductVariant
+		if err := database.DB.Unscoped().First(&variant, *offer.VariantID).Error; err == nil && variant.Label() != "" {
+			productEn += " (" + variant.Label() + ")"
+			productNp += " (" + variant.Label() + ")"
+		}
+	}
+	unit := offer.Unit
+	if unit == "" {
+		unit = product.Unit
+	}
+
+	notify.Send(product.UserID, notify.TypeOffer, notify.EventOfferCreated, offer.ID, map[string]interface{}{
+		"product":    productEn,
+		"product_np": productNp,
+		"quantity":   offer.Quantity,
+		"unit":       unit,
+		"price":      offer.Price,
+	})
+}
+
+// notifyOfferStatusChanged tells the buyer that their offer changed status
+func notifyOfferStatusChanged(offer models.Offer) {
+	notify.Send(offer.BuyerID, notify.TypeOffer, notify.EventOfferStatusChanged, offer.ID, map[string]interface{}{
+		"offer_id": offer.ID,
+		"status":   offer.Status,
+	})
+}
+
+// notifyOrderStatusChanged tells both parties of an order, except the one who
+// made the change, that the order status changed
+func notifyOrderStatusChanged(order models.Order, actorID uint) {
+	params := map[string]interface{}{
+		"order_id": order.ID,
+		"status":   order.Status,
+	}
+	for _, recipient := range []uint{order.BuyerID, order.FarmerID} {
+		if recipient != actorID {
+			notify.Send(recipient, notify.TypeOrder, notify.EventOrderStatusChanged, order.ID, params)
+		}
+	}
+}
+
+// GetNotifications returns the authenticated user's notifications, newest first
+// GET /notifications?page=1&pageSize=10&unread=true
+func GetNotifications(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	page, pageSize := getPaginationParams(c)
+
+	query := database.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("is_read = ?", false)
+	}
+	if notificationType := c.Query("type"); notificationType != "" {
+		query = query.Where("type = ?", notificationType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "notification.count_failed", nil)})
+		return
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "notification.retrieve_failed", nil)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"page":          page,
+		"pageSize":      pageSize,
+		"total":         total,
+	})
+}
+
+// GetUnreadNotificationCount returns the number of unread notifications
+func GetUnreadNotificationCount(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var count int64
+	if err := database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "notification.count_failed", nil)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread": count})
+}
+
+// MarkNotificationRead marks a single notification as read
+func MarkNotificationRead(c *gin.Context) {
+	id := c.Param("id")
+	userID, _ := c.Get("userID")
+
+	var notification models.Notification
+	if err := database.DB.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "notification.not_found", nil)})
+		return
+	}
+
+	if err := database.DB.Model(&notification).Update("is_read", true).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "notification.update_failed", nil)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      i18n.Tc(c, "notification.marked_read", nil),
+		"notification": notification,
+	})
+}
+
+// MarkAllNotificationsRead marks every unread notification of the user as read
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	result := database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Update("is_read", true)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "notification.update_failed", nil)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": i18n.Tc(c, "notification.marked_all_read", map[string]interface{}{"count": result.RowsAffected}),
+		"updated": result.RowsAffected,
+	})
+}
+
+// GetNotificationPreferences returns the user's channel preferences for every
+// notification type, including defaults for types never configured
+func GetNotificationPreferences(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "error.user_not_found", nil)})
+		return
+	}
+
+	preferences := make([]models.NotificationPreference, 0, len(notificationTypes))
+	for _, notificationType := range notificationTypes {
+		preferences = append(preferences, notify.PreferenceFor(user, notificationType))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preferences": preferences})
+}
+
+// UpdateNotificationPreferencesInput is one entry of a preferences update.
+// Type is checked against notificationTypes.
+type UpdateNotificationPreferencesInput struct {
+	Type  string `json:"type" binding:"required"`
+	InApp bool   `json:"in_app"`
+	SMS   bool   `json:"sms"`
+	Email bool   `json:"email"`
+
+	// Frequency of SMS/email delivery: immediate (default), hourly or daily
+	Frequency string `json:"frequency"`
+}
+
+// UpdateNotificationPreferences saves channel preferences per notification type
+// PUT /notifications/preferences
+// [{"type": "offer", "in_app": true, "sms": true, "email": false, "frequency": "daily"}]
+func UpdateNotificationPreferences(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input []UpdateNotificationPreferencesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preferences := make([]models.NotificationPreference, 0, len(input))
+	for _, item := range input {
+		if !validNotificationType(item.Type) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "notification.invalid_type", map[string]interface{}{"type": item.Type})})
+			return
+		}
+
+		if item.Frequency == "" {
+			item.Frequency = notify.FrequencyImmediate
+		}
+		if !notify.ValidFrequency(item.Frequency) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "notification.invalid_frequency", nil)})
+			return
+		}
+
+		var pref models.NotificationPreference
+		database.DB.Where("user_id = ? AND type = ?", userID, item.Type).First(&pref)
+		pref.UserID = userID.(uint)
+		pref.Type = item.Type
+		pref.InApp = item.InApp
+		pref.SMS = item.SMS
+		pref.Email = item.Email
+		pref.Frequency = item.Frequency
+
+		if err := database.DB.Save(&pref).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "notification.preferences_failed", nil)})
+			return
+		}
+		preferences = append(preferences, pref)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     i18n.Tc(c, "notification.preferences_updated", nil),
+		"preferences": preferences,
+	})
+}
+
+// GetNotificationSettings returns the user's quiet hours and digest time
+func GetNotificationSettings(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings": notify.SettingsFor(userID.(uint)),
+		"timezone": notify.Kathmandu.String(),
+	})
+}
+
+// UpdateNotificationSettingsInput defines quiet hours and daily digest time,
+// all as "HH:MM" in Asia/Kathmandu
+type UpdateNotificationSettingsInput struct {
+	QuietHoursEnabled bool   `json:"quiet_hours_enabled"`
+	QuietHoursStart   string `json:"quiet_hours_start"`
+	QuietHoursEnd     string `json:"quiet_hours_end"`
+	DailyDigestTime   string `json:"daily_digest_time"`
+}
+
+// UpdateNotificationSettings saves the user's quiet hours and digest time.
+// Non-urgent SMS is deferred until quiet hours end; email is sent right away.
+// PUT /notifications/settings
+func UpdateNotificationSettings(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var input UpdateNotificationSettingsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings := notify.SettingsFor(userID.(uint))
+	settings.QuietHoursEnabled = input.QuietHoursEnabled
+	if input.QuietHoursStart != "" {
+		settings.QuietHoursStart = input.QuietHoursStart
+	}
+	if input.QuietHoursEnd != "" {
+		settings.QuietHoursEnd = input.QuietHoursEnd
+	}
+	if input.DailyDigestTime != "" {
+		settings.DailyDigestTime = input.DailyDigestTime
+	}
+
+	for _, value := range []string{settings.QuietHoursStart, settings.QuietHoursEnd, settings.DailyDigestTime} {
+		if _, err := notify.ParseClock(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "notification.invalid_settings", map[string]interface{}{"details": err.Error()})})
+			return
+		}
+	}
+
+	if err := database.DB.Save(&settings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "notification.settings_failed", nil)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  i18n.Tc(c, "notification.settings_updated", nil),
+		"settings": settings,
+	})
+}
+
+// GetNotificationDeliveries lists delivery records (in-app, SMS, email) for
+// the authenticated user
+// GET /notifications/deliveries?channel=sms&status=failed
+func GetNotificationDeliveries(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	page, pageSize := getPaginationParams(c)
+
+	query := database.DB.Model(&models.NotificationDelivery{}).Where("user_id = ?", userID)
+	if channel := c.Query("channel"); channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []models.NotificationDelivery
+	if err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "notification.deliveries_failed", nil)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"page":       page,
+		"pageSize":   pageSize,
+	})
+}
+
+func validNotificationType(notificationType string) bool {
+	for _, t := range notificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
@@ -2,8 +2,12 @@ package controllers
 
 import (
 	"agro-connect/database"
+	"agro-connect/i18n"
 	"agro-connect/models"
+	"agro-connect/units"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -13,7 +17,7 @@ func CreateOffer(c *gin.Context) {
 	// Only buyers can create offers
 	role, _ := c.Get("role")
 	if role != "buyer" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only buyers can create offers"})
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "offer.buyers_only", nil)})
 		return
 	}
 
@@ -28,17 +32,25 @@ func CreateOffer(c *gin.Context) {
 	// Set buyer ID from JWT claims
 	offer.BuyerID = userID.(uint)
 
+	if key, params := convertOfferToListing(&offer); key != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, key, params)})
+		return
+	}
+
 	if err := database.DB.Create(&offer).Error; err != nil {
 		fmt.Println("DB Error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create offer",
+			"error":   i18n.Tc(c, "offer.create_failed", nil),
 			"details": err.Error(),
 		})
 		return
 	}
 
+	notifyOfferCreated(offer)
+	publishOfferUpdate(offer)
+
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Offer created successfully",
+		"message": i18n.Tc(c, "offer.created", nil),
 		"offer":   offer,
 	})
 }
@@ -52,13 +64,13 @@ func GetAllOffers(c *gin.Context) {
 		if err := database.DB.Joins("JOIN products ON products.id = offers.product_id").
 			Where("products.farmer_id = ?", userID).
 			Find(&offers).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve offers"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "offer.retrieve_failed", nil)})
 			return
 		}
 	} else {
 		// Admins and buyers see all offers
 		if err := database.DB.Find(&offers).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve offers"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "offer.retrieve_failed", nil)})
 			return
 		}
 	}
@@ -71,7 +83,7 @@ func GetOfferByID(c *gin.Context) {
 	var offer models.Offer
 
 	if err := database.DB.First(&offer, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Offer not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "offer.not_found", nil)})
 		return
 	}
 
@@ -88,7 +100,7 @@ func GetOfferByID(c *gin.Context) {
 		var product models.Product
 		if err := database.DB.First(&product, offer.ProductID).Error; err == nil {
 			if product.UserID != userID.(uint) {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to view this offer"})
+				c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "offer.view_forbidden", nil)})
 				return
 			}
 		}
@@ -102,14 +114,14 @@ func UpdateOffer(c *gin.Context) {
 	var offer models.Offer
 
 	if err := database.DB.First(&offer, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Offer not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "offer.not_found", nil)})
 		return
 	}
 
 	// Only buyer who created the offer can update it
 	userID, _ := c.Get("userID")
 	if offer.BuyerID != userID.(uint) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only the buyer who created the offer can update it"})
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "offer.update_forbidden", nil)})
 		return
 	}
 
@@ -118,13 +130,20 @@ func UpdateOffer(c *gin.Context) {
 		return
 	}
 
+	if key, params := convertOfferToListing(&offer); key != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, key, params)})
+		return
+	}
+
 	if err := database.DB.Save(&offer).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update offer"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "offer.update_failed", nil)})
 		return
 	}
 
+	publishOfferUpdate(offer)
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Offer updated successfully",
+		"message": i18n.Tc(c, "offer.updated", nil),
 		"offer":   offer,
 	})
 }
@@ -134,7 +153,7 @@ func DeleteOffer(c *gin.Context) {
 	var offer models.Offer
 
 	if err := database.DB.First(&offer, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Offer not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "offer.not_found", nil)})
 		return
 	}
 
@@ -142,16 +161,16 @@ func DeleteOffer(c *gin.Context) {
 	userID, _ := c.Get("userID")
 	role, _ := c.Get("role")
 	if offer.BuyerID != userID.(uint) && role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to delete this offer"})
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "offer.delete_forbidden", nil)})
 		return
 	}
 
 	if err := database.DB.Delete(&offer).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete offer"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "offer.delete_failed", nil)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Offer deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "offer.deleted", nil)})
 }
 
 func GetOffersByBuyer(c *gin.Context) {
@@ -163,13 +182,13 @@ func GetOffersByBuyer(c *gin.Context) {
 	// - User is admin
 	// - User is requesting their own offers
 	if role != "admin" && buyerID != fmt.Sprint(userID.(uint)) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to view these offers"})
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "offer.list_forbidden", nil)})
 		return
 	}
 
 	var offers []models.Offer
 	if err := database.DB.Where("buyer_id = ?", buyerID).Find(&offers).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve offers"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "offer.retrieve_failed", nil)})
 		return
 	}
 
@@ -188,14 +207,14 @@ func GetOffersByProduct(c *gin.Context) {
 		var product models.Product
 		if err := database.DB.First(&product, productID).Error; err == nil {
 			if product.UserID != userID.(uint) {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to view offers for this product"})
+				c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "offer.product_forbidden", nil)})
 				return
 			}
 		}
 	}
 
 	if err := database.DB.Where("product_id = ?", productID).Find(&offers).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve offers"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "offer.retrieve_failed", nil)})
 		return
 	}
 
@@ -210,7 +229,7 @@ func UpdateOfferStatus(c *gin.Context) {
 	}
 
 	if err := database.DB.First(&offer, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Offer not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "offer.not_found", nil)})
 		return
 	}
 
@@ -222,7 +241,7 @@ func UpdateOfferStatus(c *gin.Context) {
 		var product models.Product
 		if err := database.DB.First(&product, offer.ProductID).Error; err == nil {
 			if product.UserID != userID.(uint) {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to update this offer's status"})
+				c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "offer.status_forbidden", nil)})
 				return
 			}
 		}
@@ -242,19 +261,77 @@ func UpdateOfferStatus(c *gin.Context) {
 
 	if !validStatuses[statusUpdate.Status] {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid status. Must be PENDING, ACCEPTED, or REJECTED",
+			"error": i18n.Tc(c, "offer.invalid_status", nil),
 		})
 		return
 	}
 
+	previousStatus := offer.Status
 	offer.Status = statusUpdate.Status
 	if err := database.DB.Save(&offer).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update offer status"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "offer.status_update_failed", nil)})
 		return
 	}
 
+	if offer.Status != previousStatus {
+		notifyOfferStatusChanged(offer)
+	}
+	publishOfferUpdate(offer)
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Offer status updated successfully",
+		"message": i18n.Tc(c, "offer.status_updated", nil),
 		"offer":   offer,
 	})
 }
+
+// convertOfferToListing validates the offer's variant and unit, defaulting
+// the unit to the listing's, and expresses the quantity and price in the listing's unit so
+// farmers can compare offers. It returns the message key and parameters of
+// a validation error, or an empty key.
+func convertOfferToListing(offer *models.Offer) (string, map[string]interface{}) {
+	var product models.Product
+	if err := database.DB.First(&product, offer.ProductID).Error; err != nil {
+		return "offer.product_not_found", nil
+	}
+	if err := resolveVariant(product.ID, offer.VariantID); err != nil {
+		if errors.Is(err, errVariantRequired) {
+			return "offer.variant_required", nil
+		}
+		return "offer.variant_not_found", nil
+	}
+
+	if offer.Unit == "" {
+		offer.Unit = product.Unit
+	}
+	offer.ListingQuantity, offer.ListingPrice = offer.Quantity, offer.Price
+	if offer.Unit == "" {
+		return "", nil
+	}
+
+	offerUnit, ok := units.Lookup(offer.Unit)
+	if !ok {
+		// Listings from before the registry may use other units, which
+		// only match exactly
+		if offer.Unit == product.Unit {
+			return "", nil
+		}
+		return "offer.invalid_unit", map[string]interface{}{"unit": offer.Unit}
+	}
+	offer.Unit = offerUnit.Code
+
+	listingUnit, ok := units.Lookup(product.Unit)
+	switch {
+	case !ok && product.Unit != "":
+		return "offer.incompatible_unit", map[string]interface{}{"unit": offer.Unit, "listing_unit": product.Unit}
+	case !ok || listingUnit.Code == offerUnit.Code:
+		return "", nil
+	}
+
+	quantity, err := units.Convert(offer.Quantity, offerUnit, listingUnit, productDensity(product))
+	if err != nil || quantity == 0 {
+		return "offer.incompatible_unit", map[string]interface{}{"unit": offer.Unit, "listing_unit": product.Unit}
+	}
+	offer.ListingQuantity = math.Round(quantity*1000) / 1000
+	offer.ListingPrice = math.Round(offer.Price*offer.Quantity/quantity*100) / 100
+	return "", nil
+}
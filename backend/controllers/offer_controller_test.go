@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"agro-connect/database/testdb"
+	"agro-connect/models"
+	"testing"
+)
+
+func TestConvertOfferToListing(t *testing.T) {
+	db := testdb.Open(t, &models.Category{}, &models.Product{}, &models.ProductVariant{})
+
+	oils := models.Category{Slug: "oils", NameEn: "Oils", KgPerLitre: 0.92}
+	mustCreate(t, db, &oils)
+	mustard := models.Category{Slug: "mustard-oil", NameEn: "Mustard oil", ParentID: &oils.ID}
+	mustCreate(t, db, &mustard)
+	oil := models.Product{UserID: testFarmerID, NameEn: "Mustard oil", Unit: "kg", PricePerUnit: 250,
+		Quantity: 100, CategoryID: &mustard.ID, Status: models.ProductStatusAvailable}
+	mustCreate(t, db, &oil)
+	sacks := models.Product{UserID: testFarmerID, NameEn: "Rice", Unit: "sack", PricePerUnit: 2500,
+		Quantity: 40, Status: models.ProductStatusAvailable}
+	mustCreate(t, db, &sacks)
+
+	tests := []struct {
+		name      string
+		product   models.Product
+		unit      string
+		quantity  float64
+		price     float64
+		key       string
+		wantUnit  string
+		wantQty   float64
+		wantPrice float64
+	}{
+		{"listing unit", oil, "kg", 10, 240, "", "kg", 10, 240},
+		{"no unit", oil, "", 10, 240, "", "kg", 10, 240},
+		{"alias", oil, "Kgs", 10, 240, "", "kg", 10, 240},
+		{"larger unit", oil, "quintal", 2, 24000, "", "quintal", 200, 240},
+		{"volume by density", oil, "litre", 10, 230, "", "litre", 9.2, 250},
+		{"count", oil, "piece", 10, 240, "offer.incompatible_unit", "", 0, 0},
+		{"unknown unit", oil, "drum", 1, 240, "offer.invalid_unit", "", 0, 0},
+		{"legacy unit", sacks, "sack", 2, 2400, "", "sack", 2, 2400},
+		{"legacy listing", sacks, "kg", 50, 50, "offer.incompatible_unit", "", 0, 0},
+	}
+	for _, tt := range tests {
+		offer := models.Offer{ProductID: tt.product.ID, Unit: tt.unit, Quantity: tt.quantity, Price: tt.price}
+		key, _ := convertOfferToListing(&offer)
+		if key != tt.key {
+			t.Errorf("%s: got error %q, want %q", tt.name, key, tt.key)
+			continue
+		}
+		if key != "" {
+			continue
+		}
+		if offer.Unit != tt.wantUnit || offer.ListingQuantity != tt.wantQty || offer.ListingPrice != tt.wantPrice {
+			t.Errorf("%s: got %g %s at %g per listing unit, want %g %s at %g",
+				tt.name, offer.ListingQuantity, offer.Unit, offer.ListingPrice, tt.wantQty, tt.wantUnit, tt.wantPrice)
+		}
+	}
+}
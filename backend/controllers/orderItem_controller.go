@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"agro-connect/availability"
+	"agro-connect/database"
+	"agro-connect/models"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// completionTime returns when something with status completed, keeping the
+// previous time while it stays completed
+func completionTime(status, previousStatus string, previous *time.Time) *time.Time {
+	switch {
+	case status != "completed":
+		return nil
+	case previousStatus == "completed":
+		return previous
+	default:
+		now := time.Now()
+		return &now
+	}
+}
+
+// orderStatusFromItems returns the status of an order from its lines:
+// canceled when every line is canceled, completed when every other line is
+// completed and processing while any line is
+func orderStatusFromItems(items []models.OrderItem) string {
+	status := "canceled"
+	for _, item := range items {
+		switch item.Status {
+		case "processing":
+			return "processing"
+		case "completed":
+			status = "completed"
+		}
+	}
+	return status
+}
+
+// stockChange returns how much of a line's quantity goes back to its listing
+// when the line moves from one status to another: all of it when the line is
+// canceled, and minus all of it when a canceled line is reopened
+func stockChange(quantity float64, from, to string) float64 {
+	switch {
+	case to == "canceled" && from != "canceled":
+		return quantity
+	case from == "canceled" && to != "canceled":
+		return -quantity
+	}
+	return 0
+}
+
+// moveStock returns stock to listings, or takes it from them for negative
+// changes, locking them in the same order as checkout
+func moveStock(tx *gorm.DB, changes map[stockKey]float64) error {
+	var keys []stockKey
+	for key, change := range changes {
+		if change != 0 {
+			keys = append(keys, key)
+		}
+	}
+	sortStockKeys(keys)
+
+	today := availability.Today()
+	for _, key := range keys {
+		var err error
+		if change := changes[key]; change > 0 {
+			err = availability.ReturnStock(tx, key.productID, key.variant(), change, today)
+		} else {
+			_, err = availability.TakeStock(tx, key.productID, key.variant(), -change, today)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stockConflict reports whether err means stock could not be taken again
+// for a reopened line, because too little is left or the listing is gone
+func stockConflict(err error) bool {
+	var short *availability.StockError
+	return errors.As(err, &short) || errors.Is(err, gorm.ErrRecordNotFound)
+}
+
+// applyOrderStatusToItems moves the lines of an order along with a status
+// set on the whole order. Completing an order leaves canceled lines
+// canceled; reopening or canceling it applies to every line. Canceled lines
+// return their stock and reopened lines take it again.
+func applyOrderStatusToItems(tx *gorm.DB, order *models.Order) error {
+	var items []models.OrderItem
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ?", order.ID).Order("id").Find(&items).Error; err != nil {
+		return err
+	}
+	var moved []*models.OrderItem
+	changes := map[stockKey]float64{}
+	for i := range items {
+		item := &items[i]
+		if item.Status == order.Status || (order.Status == "completed" && item.Status == "canceled") {
+			continue
+		}
+		changes[stockKeyOf(item.ProductID, item.VariantID)] += stockChange(item.Quantity, item.Status, order.Status)
+		moved = append(moved, item)
+	}
+	if err := moveStock(tx, changes); err != nil {
+		return err
+	}
+
+	for _, item := range moved {
+		item.CompletedAt = completionTime(order.Status, item.Status, item.CompletedAt)
+		item.Status = order.Status
+		if err := tx.Model(item).Updates(map[string]interface{}{
+			"status":       item.Status,
+			"completed_at": item.CompletedAt,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	order.Items = items
+	return nil
+}
+
+// UpdateOrderItemStatus fulfils or cancels one line of an order. The order
+// completes when its last open line does. Canceling a line returns its
+// quantity to the listing and reopening it takes the quantity again.
+// PATCH /orders/:id/items/:itemId/status {"status": "completed"}
+func UpdateOrderItemStatus(c *gin.Context) {
+	var order models.Order
+	if err := database.DB.First(&order, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Order not found",
+		})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	role, _ := c.Get("role")
+	if role != "admin" && order.FarmerID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Not authorized to update this order's status",
+		})
+		return
+	}
+
+	var item models.OrderItem
+	if err := database.DB.Where("id = ? AND order_id = ?", c.Param("itemId"), order.ID).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Order item not found",
+		})
+		return
+	}
+
+	var statusUpdate struct {
+		Status string `json:"status" binding:"required,oneof=processing completed canceled"`
+	}
+	if err := c.ShouldBindJSON(&statusUpdate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid status update",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	previous := order
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the order before its line, as saveOrderStatus does
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Order{}, order.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, item.ID).Error; err != nil {
+			return err
+		}
+		change := stockChange(item.Quantity, item.Status, statusUpdate.Status)
+		if err := moveStock(tx, map[stockKey]float64{stockKeyOf(item.ProductID, item.VariantID): change}); err != nil {
+			return err
+		}
+
+		item.CompletedAt = completionTime(statusUpdate.Status, item.Status, item.CompletedAt)
+		item.Status = statusUpdate.Status
+		if err := tx.Model(&item).Updates(map[string]interface{}{
+			"status":       item.Status,
+			"completed_at": item.CompletedAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		var items []models.OrderItem
+		if err := tx.Where("order_id = ?", order.ID).Order("id").Find(&items).Error; err != nil {
+			return err
+		}
+		order.Status = orderStatusFromItems(items)
+		trackOrderCompletion(&order, previous)
+		if err := tx.Model(&order).Updates(map[string]interface{}{
+			"status":       order.Status,
+			"completed_at": order.CompletedAt,
+		}).Error; err != nil {
+			return err
+		}
+		order.Items = items
+		return nil
+	})
+	if stockConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Not enough stock left to reopen this order item",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update order item status",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if order.Status != previous.Status {
+		notifyOrderStatusChanged(order, userID.(uint))
+	}
+	publishOrderUpdate(order)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Order item status updated successfully",
+		"data":    order,
+	})
+}
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"agro-connect/database"
+	"agro-connect/database/testdb"
+	"agro-connect/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	testFarmerID uint = 1
+	testBuyerID  uint = 2
+)
+
+// orderStockDB is a database with a plain listing of 10 kg and a listing
+// with a 5 kg variant, returning the plain listing and the variant
+func orderStockDB(t *testing.T) (plain models.Product, variant models.ProductVariant) {
+	t.Helper()
+	db := testdb.Open(t, &models.User{}, &models.Product{}, &models.ProductVariant{},
+		&models.CartItem{}, &models.Order{}, &models.OrderItem{})
+
+	plain = models.Product{UserID: testFarmerID, NameEn: "Tomato", Unit: "kg", PricePerUnit: 80,
+		Quantity: 10, Status: models.ProductStatusAvailable}
+	withVariant := models.Product{UserID: testFarmerID, NameEn: "Potato", Unit: "kg", PricePerUnit: 50,
+		Quantity: 5, Status: models.ProductStatusAvailable}
+	mustCreate(t, db, &plain)
+	mustCreate(t, db, &withVariant)
+	variant = models.ProductVariant{ProductID: withVariant.ID, Grade: "A", PricePerUnit: 60, Quantity: 5}
+	mustCreate(t, db, &variant)
+	return plain, variant
+}
+
+func mustCreate(t *testing.T, db *gorm.DB, value interface{}) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatalf("create %T: %v", value, err)
+	}
+}
+
+// serve runs handler as userID with a JSON body and returns the status code
+func serve(t *testing.T, handler gin.HandlerFunc, userID uint, role string, params gin.Params, body interface{}) int {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	c.Set("userID", userID)
+	c.Set("role", role)
+	handler(c)
+	return w.Code
+}
+
+func checkout(t *testing.T, items ...models.CartItem) models.Order {
+	t.Helper()
+	for i := range items {
+		items[i].BuyerID = testBuyerID
+		mustCreate(t, database.DB, &items[i])
+	}
+	if code := serve(t, Checkout, testBuyerID, "buyer", nil, struct{}{}); code != http.StatusCreated {
+		t.Fatalf("checkout: got status %d", code)
+	}
+	var order models.Order
+	if err := database.DB.Order("id DESC").First(&order).Error; err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+func setOrderStatus(t *testing.T, order models.Order, status string) int {
+	t.Helper()
+	return serve(t, UpdateOrderStatus, testFarmerID, "farmer",
+		gin.Params{{Key: "id", Value: fmt.Sprint(order.ID)}}, gin.H{"status": status})
+}
+
+func setItemStatus(t *testing.T, item models.OrderItem, status string) int {
+	t.Helper()
+	return serve(t, UpdateOrderItemStatus, testFarmerID, "farmer", gin.Params{
+		{Key: "id", Value: fmt.Sprint(item.OrderID)},
+		{Key: "itemId", Value: fmt.Sprint(item.ID)},
+	}, gin.H{"status": status})
+}
+
+// wantStock checks the quantity and status left of a listing and, when
+// variantID is set, the quantity of that variant
+func wantStock(t *testing.T, step string, productID, variantID uint, quantity float64, status models.ProductStatus) {
+	t.Helper()
+	var product models.Product
+	if err := database.DB.First(&product, productID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if product.Quantity != quantity || product.Status != status {
+		t.Errorf("%s: listing %d has %g left and is %s, want %g and %s",
+			step, productID, product.Quantity, product.Status, quantity, status)
+	}
+	if variantID == 0 {
+		return
+	}
+	var variant models.ProductVariant
+	if err := database.DB.First(&variant, variantID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if variant.Quantity != quantity {
+		t.Errorf("%s: variant %d has %g left, want %g", step, variantID, variant.Quantity, quantity)
+	}
+}
+
+func TestOrderCancelReturnsStockAndReopenTakesIt(t *testing.T) {
+	plain, variant := orderStockDB(t)
+	order := checkout(t,
+		models.CartItem{ProductID: plain.ID, Quantity: 3},
+		models.CartItem{ProductID: variant.ProductID, VariantID: &variant.ID, Quantity: 5})
+	wantStock(t, "checkout", plain.ID, 0, 7, models.ProductStatusAvailable)
+	wantStock(t, "checkout", variant.ProductID, variant.ID, 0, models.ProductStatusSoldOut)
+
+	steps := []struct {
+		status       string
+		plain, other float64
+		otherStatus  models.ProductStatus
+	}{
+		{"canceled", 10, 5, models.ProductStatusAvailable},
+		{"canceled", 10, 5, models.ProductStatusAvailable},
+		{"processing", 7, 0, models.ProductStatusSoldOut},
+		{"completed", 7, 0, models.ProductStatusSoldOut},
+		{"canceled", 10, 5, models.ProductStatusAvailable},
+	}
+	for _, step := range steps {
+		if code := setOrderStatus(t, order, step.status); code != http.StatusOK {
+			t.Fatalf("order %s: got status %d", step.status, code)
+		}
+		wantStock(t, "order "+step.status, plain.ID, 0, step.plain, models.ProductStatusAvailable)
+		wantStock(t, "order "+step.status, variant.ProductID, variant.ID, step.other, step.otherStatus)
+	}
+}
+
+func TestOrderItemCancelReturnsStockAndReopenTakesIt(t *testing.T) {
+	plain, variant := orderStockDB(t)
+	order := checkout(t,
+		models.CartItem{ProductID: plain.ID, Quantity: 4},
+		models.CartItem{ProductID: variant.ProductID, VariantID: &variant.ID, Quantity: 2})
+	var items []models.OrderItem
+	if err := database.DB.Where("order_id = ?", order.ID).Order("id").Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		status string
+		plain  float64
+	}{
+		{"canceled", 10},
+		{"completed", 6},
+		{"canceled", 10},
+		{"processing", 6},
+	}
+	for _, step := range steps {
+		if code := setItemStatus(t, items[0], step.status); code != http.StatusOK {
+			t.Fatalf("item %s: got status %d", step.status, code)
+		}
+		wantStock(t, "item "+step.status, plain.ID, 0, step.plain, models.ProductStatusAvailable)
+		wantStock(t, "item "+step.status, variant.ProductID, variant.ID, 3, models.ProductStatusAvailable)
+	}
+}
+
+func TestOrderReopenFailsWhenStockIsGone(t *testing.T) {
+	plain, _ := orderStockDB(t)
+	order := checkout(t, models.CartItem{ProductID: plain.ID, Quantity: 6})
+	if code := setOrderStatus(t, order, "canceled"); code != http.StatusOK {
+		t.Fatalf("cancel: got status %d", code)
+	}
+	checkout(t, models.CartItem{ProductID: plain.ID, Quantity: 8})
+
+	if code := setOrderStatus(t, order, "processing"); code != http.StatusConflict {
+		t.Fatalf("reopen: got status %d, want %d", code, http.StatusConflict)
+	}
+	wantStock(t, "failed reopen", plain.ID, 0, 2, models.ProductStatusAvailable)
+	var item models.OrderItem
+	if err := database.DB.Where("order_id = ?", order.ID).First(&item).Error; err != nil {
+		t.Fatal(err)
+	}
+	if item.Status != "canceled" {
+		t.Fatalf("failed reopen: line is %s, want canceled", item.Status)
+	}
+	if code := setItemStatus(t, item, "processing"); code != http.StatusConflict {
+		t.Fatalf("item reopen: got status %d, want %d", code, http.StatusConflict)
+	}
+}
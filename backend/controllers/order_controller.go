@@ -2,11 +2,14 @@ package controllers
 
 import (
 	"agro-connect/database"
+	"agro-connect/i18n"
 	"agro-connect/models"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GetAllOrders returns all orders with optional filtering
@@ -30,9 +33,9 @@ func GetAllOrders(c *gin.Context) {
 		query = query.Where("product_id = ?", productID)
 	}
 
-	if err := query.Find(&orders).Error; err != nil {
+	if err := query.Preload("Items").Find(&orders).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve orders",
+			"error":   i18n.Tc(c, "order.retrieve_failed", nil),
 			"details": err.Error(),
 		})
 		return
@@ -56,7 +59,7 @@ func CreateOrder(c *gin.Context) {
 	if err := c.ShouldBindJSON(&order); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"error":   "Invalid request data",
+			"error":   i18n.Tc(c, "order.invalid_data", nil),
 			"details": err.Error(),
 		})
 		return
@@ -70,7 +73,18 @@ func CreateOrder(c *gin.Context) {
 	} else {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"error":   "Only buyers or farmers can create orders",
+			"error":   i18n.Tc(c, "order.create_forbidden", nil),
+		})
+		return
+	}
+
+	// Orders with several lines are placed through the cart
+	order.Items, order.Total = nil, 0
+
+	if err := resolveOrderVariant(&order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
 		})
 		return
 	}
@@ -78,15 +92,17 @@ func CreateOrder(c *gin.Context) {
 	if err := database.DB.Create(&order).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"error":   "Failed to create order",
+			"error":   i18n.Tc(c, "order.create_failed", nil),
 			"details": err.Error(),
 		})
 		return
 	}
 
+	publishOrderUpdate(order)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
-		"message": "Order created successfully",
+		"message": i18n.Tc(c, "order.created", nil),
 		"data":    order,
 	})
 }
@@ -96,10 +112,10 @@ func GetOrder(c *gin.Context) {
 	id := c.Param("id")
 	var order models.Order
 
-	if err := database.DB.First(&order, id).Error; err != nil {
+	if err := database.DB.Preload("Items").First(&order, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
-			"error":   "Order not found",
+			"error":   i18n.Tc(c, "order.not_found", nil),
 		})
 		return
 	}
@@ -111,7 +127,7 @@ func GetOrder(c *gin.Context) {
 	if role != "admin" && order.BuyerID != userID.(uint) && order.FarmerID != userID.(uint) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"error":   "Not authorized to view this order",
+			"error":   i18n.Tc(c, "order.view_forbidden", nil),
 		})
 		return
 	}
@@ -130,7 +146,7 @@ func UpdateOrder(c *gin.Context) {
 	if err := database.DB.First(&order, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
-			"error":   "Order not found",
+			"error":   i18n.Tc(c, "order.not_found", nil),
 		})
 		return
 	}
@@ -142,36 +158,105 @@ func UpdateOrder(c *gin.Context) {
 	if role != "admin" && order.BuyerID != userID.(uint) && order.FarmerID != userID.(uint) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"error":   "Not authorized to update this order",
+			"error":   i18n.Tc(c, "order.update_forbidden", nil),
 		})
 		return
 	}
 
+	previous := order
+	previousStatus := order.Status
 	if err := c.ShouldBindJSON(&order); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"error":   "Invalid request data",
+			"error":   i18n.Tc(c, "order.invalid_data", nil),
 			"details": err.Error(),
 		})
 		return
 	}
+	order.Items, order.Total, order.StockQuantity = nil, previous.Total, previous.StockQuantity
+	trackOrderCompletion(&order, previous)
+	// Orders keep the variant they were placed for, even once it is removed
+	if order.ProductID != previous.ProductID || !sameID(order.VariantID, previous.VariantID) {
+		if err := resolveOrderVariant(&order); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
 
-	if err := database.DB.Save(&order).Error; err != nil {
+	err := saveOrderStatus(&order)
+	if stockConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   i18n.Tc(c, "order.reopen_no_stock", nil),
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"error":   "Failed to update order",
+			"error":   i18n.Tc(c, "order.update_failed", nil),
 			"details": err.Error(),
 		})
 		return
 	}
 
+	if order.Status != previousStatus {
+		notifyOrderStatusChanged(order, userID.(uint))
+	}
+	publishOrderUpdate(order)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Order updated successfully",
+		"message": i18n.Tc(c, "order.updated", nil),
 		"data":    order,
 	})
 }
 
+// resolveOrderVariant takes the variant of an order from its offer when
+// not given, and checks that it belongs to the ordered listing
+func resolveOrderVariant(order *models.Order) error {
+	if order.VariantID == nil && order.OfferID != 0 {
+		var offer models.Offer
+		if err := database.DB.First(&offer, order.OfferID).Error; err == nil && offer.ProductID == order.ProductID {
+			order.VariantID = offer.VariantID
+		}
+	}
+	return resolveVariant(order.ProductID, order.VariantID)
+}
+
+// saveOrderStatus saves an order and moves its lines, and their stock, along
+// when its status changed
+func saveOrderStatus(order *models.Order) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").
+			First(&current, order.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(order).Error; err != nil {
+			return err
+		}
+		if order.Status == current.Status {
+			return nil
+		}
+		change := stockChange(order.StockQuantity, current.Status, order.Status)
+		if err := moveStock(tx, map[stockKey]float64{stockKeyOf(order.ProductID, order.VariantID): change}); err != nil {
+			return err
+		}
+		return applyOrderStatusToItems(tx, order)
+	})
+}
+
+// trackOrderCompletion sets CompletedAt when an order becomes completed and
+// clears it when a completed order is reopened or canceled
+func trackOrderCompletion(order *models.Order, previous models.Order) {
+	order.CompletedAt = completionTime(order.Status, previous.Status, previous.CompletedAt)
+}
+
 // UpdateOrderStatus updates only the order status
 func UpdateOrderStatus(c *gin.Context) {
 	id := c.Param("id")
@@ -183,7 +268,7 @@ func UpdateOrderStatus(c *gin.Context) {
 	if err := database.DB.First(&order, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
-			"error":   "Order not found",
+			"error":   i18n.Tc(c, "order.not_found", nil),
 		})
 		return
 	}
@@ -195,7 +280,7 @@ func UpdateOrderStatus(c *gin.Context) {
 	if role != "admin" && order.BuyerID != userID.(uint) && order.FarmerID != userID.(uint) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"error":   "Not authorized to update this order's status",
+			"error":   i18n.Tc(c, "order.status_forbidden", nil),
 		})
 		return
 	}
@@ -203,25 +288,42 @@ func UpdateOrderStatus(c *gin.Context) {
 	if err := c.ShouldBindJSON(&statusUpdate); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"error":   "Invalid status update",
+			"error":   i18n.Tc(c, "order.invalid_status", nil),
 			"details": err.Error(),
 		})
 		return
 	}
 
+	previous := order
+	previousStatus := order.Status
 	order.Status = statusUpdate.Status
-	if err := database.DB.Save(&order).Error; err != nil {
+	trackOrderCompletion(&order, previous)
+	err := saveOrderStatus(&order)
+	if stockConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   i18n.Tc(c, "order.reopen_no_stock", nil),
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"error":   "Failed to update order status",
+			"error":   i18n.Tc(c, "order.status_update_failed", nil),
 			"details": err.Error(),
 		})
 		return
 	}
 
+	if order.Status != previousStatus {
+		notifyOrderStatusChanged(order, userID.(uint))
+	}
+	publishOrderUpdate(order)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Order status updated successfully",
+		"message": i18n.Tc(c, "order.status_updated", nil),
 		"data":    order,
 	})
 }
@@ -234,7 +336,7 @@ func DeleteOrder(c *gin.Context) {
 	if err := database.DB.First(&order, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
-			"error":   "Order not found",
+			"error":   i18n.Tc(c, "order.not_found", nil),
 		})
 		return
 	}
@@ -246,7 +348,7 @@ func DeleteOrder(c *gin.Context) {
 	if role != "admin" && order.BuyerID != userID.(uint) && order.FarmerID != userID.(uint) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"error":   "Not authorized to delete this order",
+			"error":   i18n.Tc(c, "order.delete_forbidden", nil),
 		})
 		return
 	}
@@ -254,7 +356,7 @@ func DeleteOrder(c *gin.Context) {
 	if err := database.DB.Delete(&order).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"error":   "Failed to delete order",
+			"error":   i18n.Tc(c, "order.delete_failed", nil),
 			"details": err.Error(),
 		})
 		return
@@ -262,7 +364,7 @@ func DeleteOrder(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Order deleted successfully",
+		"message": i18n.Tc(c, "order.deleted", nil),
 	})
 }
 
@@ -276,7 +378,7 @@ func GetOrdersByBuyer(c *gin.Context) {
 	if role != "admin" && buyerID != strconv.Itoa(int(userID.(uint))) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"error":   "Not authorized to view these orders",
+			"error":   i18n.Tc(c, "order.list_forbidden", nil),
 		})
 		return
 	}
@@ -289,10 +391,10 @@ func GetOrdersByBuyer(c *gin.Context) {
 		query = query.Where("status = ?", status)
 	}
 
-	if err := query.Find(&orders).Error; err != nil {
+	if err := query.Preload("Items").Find(&orders).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"error":   "Failed to retrieve orders",
+			"error":   i18n.Tc(c, "order.retrieve_failed", nil),
 			"details": err.Error(),
 		})
 		return
@@ -317,7 +419,7 @@ func GetOrdersByFarmer(c *gin.Context) {
 	if role != "admin" && farmerID != strconv.Itoa(int(userID.(uint))) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"error":   "Not authorized to view these orders",
+			"error":   i18n.Tc(c, "order.list_forbidden", nil),
 		})
 		return
 	}
@@ -330,10 +432,10 @@ func GetOrdersByFarmer(c *gin.Context) {
 		query = query.Where("status = ?", status)
 	}
 
-	if err := query.Find(&orders).Error; err != nil {
+	if err := query.Preload("Items").Find(&orders).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"error":   "Failed to retrieve orders",
+			"error":   i18n.Tc(c, "order.retrieve_failed", nil),
 			"details": err.Error(),
 		})
 		return
@@ -0,0 +1,329 @@
+package controllers
+
+import (
+	"agro-connect/availability"
+	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/models"
+	"agro-connect/preorder"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// prepareForwardListing validates the expected harvest of a forward
+// listing. Until the harvest is confirmed the listing offers its highest
+// expected yield from the harvest date on.
+func prepareForwardListing(product *models.Product) error {
+	if !product.Forward() {
+		if product.YieldMin != 0 || product.YieldMax != 0 {
+			return fmt.Errorf("harvest_date is required for forward listings")
+		}
+		if product.ID != 0 {
+			// A listing with pre-orders stays a forward listing
+			capacity, err := preorder.CapacityOf(database.DB, *product)
+			if err != nil {
+				return err
+			}
+			if capacity.Reserved > 0 {
+				return fmt.Errorf("harvest_date is required while the listing has pre-orders")
+			}
+		}
+		return nil
+	}
+	if !product.AwaitingHarvest() {
+		return nil
+	}
+
+	if product.ID == 0 && product.HarvestDate.Before(availability.Today().Time) {
+		return fmt.Errorf("harvest_date cannot be in the past")
+	}
+	if product.YieldMin <= 0 || product.YieldMax < product.YieldMin {
+		return fmt.Errorf("invalid yield range, expected 0 < yield_min <= yield_max")
+	}
+	if len(product.Variants) > 0 {
+		return fmt.Errorf("forward listings cannot have variants")
+	}
+	if product.ID != 0 {
+		capacity, err := preorder.CapacityOf(database.DB, *product)
+		if err != nil {
+			return err
+		}
+		if capacity.Reserved > product.YieldMin {
+			return fmt.Errorf("yield_min cannot be below the %g already pre-ordered", capacity.Reserved)
+		}
+	}
+
+	product.Quantity = product.YieldMax
+	if product.AvailableFrom.Before(product.HarvestDate.Time) {
+		product.AvailableFrom = product.HarvestDate
+	}
+	return nil
+}
+
+// applyPartialHarvestUpdate validates the expected harvest of a partial
+// update of a forward listing
+func applyPartialHarvestUpdate(existing models.Product, updates map[string]interface{}) error {
+	product := existing
+	changed := false
+	if value, ok := updates["harvest_date"]; ok {
+		text, _ := value.(string)
+		date, err := models.ParseDate(text)
+		if err != nil || (value != nil && text == "") {
+			return fmt.Errorf("invalid harvest_date, expected YYYY-MM-DD")
+		}
+		product.HarvestDate = date
+		updates["harvest_date"] = date
+		changed = true
+	}
+	for key, target := range map[string]*float64{"yield_min": &product.YieldMin, "yield_max": &product.YieldMax} {
+		if value, ok := updates[key]; ok {
+			number, ok := value.(float64)
+			if !ok {
+				return fmt.Errorf("invalid %s", key)
+			}
+			*target = number
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if !existing.AwaitingHarvest() && existing.Forward() {
+		return preorder.ErrHarvestConfirmed
+	}
+
+	if err := prepareForwardListing(&product); err != nil {
+		return err
+	}
+	if product.Forward() {
+		updates["quantity"] = product.Quantity
+		updates["available_from"] = product.AvailableFrom.String()
+	}
+	return nil
+}
+
+// PreOrderInput is a buyer's pre-order of a forward listing
+type PreOrderInput struct {
+	ProductID uint    `json:"product_id" binding:"required"`
+	Quantity  float64 `json:"quantity" binding:"required,gt=0"`
+	Deposit   float64 `json:"deposit" binding:"gte=0"`
+	Note      string  `json:"note"`
+}
+
+// PlacePreOrder reserves part of the expected harvest of a forward listing
+// at the current listing price, with an optional deposit paid to the farmer
+// POST /pre-orders {"product_id": 12, "quantity": 200, "deposit": 2000}
+func PlacePreOrder(c *gin.Context) {
+	var input PreOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	preOrder := models.PreOrder{
+		ProductID: input.ProductID,
+		BuyerID:   userID.(uint),
+		Quantity:  input.Quantity,
+		Deposit:   input.Deposit,
+		Note:      input.Note,
+	}
+	if err := preorder.Place(&preOrder); err != nil {
+		preOrderError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": i18n.Tc(c, "preorder.placed", nil), "pre_order": preOrder})
+}
+
+// GetPreOrders lists the pre-orders of the signed in buyer, or those on the
+// signed in farmer's listings; admins see all
+// GET /pre-orders?status=placed&product_id=12
+func GetPreOrders(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	role, _ := c.Get("role")
+
+	query := database.DB.Model(&models.PreOrder{})
+	if role != "admin" {
+		query = query.Where("buyer_id = ? OR farmer_id = ?", userID, userID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+
+	preOrders := []models.PreOrder{}
+	if err := query.Order("created_at DESC").Find(&preOrders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "preorder.retrieve_failed", nil)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pre_orders": preOrders})
+}
+
+// GetPreOrder returns one pre-order to its buyer or farmer
+// GET /pre-orders/:id
+func GetPreOrder(c *gin.Context) {
+	preOrder, ok := participantPreOrder(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pre_order": preOrder})
+}
+
+// CancelPreOrder cancels a pre-order before the harvest, by its buyer or
+// farmer
+// POST /pre-orders/:id/cancel {"reason": "Plans changed"}
+func CancelPreOrder(c *gin.Context) {
+	preOrder, ok := participantPreOrder(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userID, _ := c.Get("userID")
+	if err := preorder.Cancel(&preOrder, userID.(uint), input.Reason); err != nil {
+		preOrderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "preorder.cancelled", nil), "pre_order": preOrder})
+}
+
+// UpdatePreOrderDeposit records that the farmer received a promised
+// deposit, or returned the deposit of a cancelled pre-order
+// PUT /pre-orders/:id/deposit {"status": "received"}
+func UpdatePreOrderDeposit(c *gin.Context) {
+	preOrder, ok := participantPreOrder(c)
+	if !ok {
+		return
+	}
+	userID, _ := c.Get("userID")
+	if role, _ := c.Get("role"); role != "admin" && preOrder.FarmerID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "preorder.deposit_farmer_only", nil)})
+		return
+	}
+
+	var input struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := preorder.SetDeposit(&preOrder, input.Status); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "preorder.deposit_updated", nil), "pre_order": preOrder})
+}
+
+// GetPreOrderCapacity returns how much of a forward listing can still be
+// pre-ordered
+// GET /products/:id/pre-orders/capacity
+func GetPreOrderCapacity(c *gin.Context) {
+	var product models.Product
+	if err := database.DB.Where(publicProductsSQL).First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "product.not_found", nil)})
+		return
+	}
+	if !product.Forward() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "preorder.not_forward", nil)})
+		return
+	}
+
+	capacity, err := preorder.CapacityOf(database.DB, product)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "preorder.capacity_failed", nil)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"harvest_date":         product.HarvestDate,
+		"yield_min":            product.YieldMin,
+		"yield_max":            product.YieldMax,
+		"harvest_confirmed_at": product.HarvestConfirmedAt,
+		"capacity":             capacity,
+	})
+}
+
+// ConfirmHarvest records the actual harvest of a forward listing and turns
+// its pre-orders into orders, first placed first served. What the
+// pre-orders do not take stays on the listing for sale.
+// POST /products/:id/harvest {"quantity": 850}
+func ConfirmHarvest(c *gin.Context) {
+	product, ok := ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Quantity *float64 `json:"quantity" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || *input.Quantity < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "preorder.harvest_quantity_required", nil)})
+		return
+	}
+
+	harvest, err := preorder.ConfirmHarvest(product.ID, *input.Quantity, time.Now())
+	if err != nil {
+		preOrderError(c, err)
+		return
+	}
+	for _, order := range harvest.Orders {
+		publishOrderUpdate(order)
+	}
+	c.JSON(http.StatusOK, harvest)
+}
+
+// participantPreOrder loads the pre-order of the request for its buyer,
+// its farmer or an admin
+func participantPreOrder(c *gin.Context) (models.PreOrder, bool) {
+	var preOrder models.PreOrder
+	if err := database.DB.First(&preOrder, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "preorder.not_found", nil)})
+		return preOrder, false
+	}
+
+	userID, _ := c.Get("userID")
+	role, _ := c.Get("role")
+	if role != "admin" && preOrder.BuyerID != userID.(uint) && preOrder.FarmerID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "preorder.forbidden", nil)})
+		return preOrder, false
+	}
+	return preOrder, true
+}
+
+func preOrderError(c *gin.Context, err error) {
+	var capacityErr *preorder.CapacityError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "product.not_found", nil)})
+	case errors.As(err, &capacityErr):
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "preorder.capacity", map[string]interface{}{"remaining": capacityErr.Remaining})})
+	case errors.Is(err, preorder.ErrHarvestConfirmed):
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "preorder.harvest_confirmed", nil)})
+	case errors.Is(err, preorder.ErrNotPlaced):
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "preorder.not_placed", nil)})
+	case errors.Is(err, preorder.ErrNotForward):
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "preorder.not_forward", nil)})
+	case errors.Is(err, preorder.ErrNotPublished):
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "preorder.not_published", nil)})
+	case errors.Is(err, preorder.ErrDeposit):
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "preorder.deposit_too_high", nil)})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "preorder.save_failed", nil), "details": err.Error()})
+	}
+}
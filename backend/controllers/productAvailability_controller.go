@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"agro-connect/availability"
+	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/models"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+// ExtendProductInput extends a listing by a number of days or to a date
+type ExtendProductInput struct {
+	Days        int         `json:"days"`
+	AvailableTo models.Date `json:"available_to"`
+}
+
+// ExtendProduct extends the availability of a listing. Without a period the
+// listing is extended by its category's shelf life, or a week.
+// POST /products/:id/extend {"days": 7} or {"available_to": "2024-02-15"}
+func ExtendProduct(c *gin.Context) {
+	product, ok := ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	var input ExtendProductInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.extend_invalid_input", nil), "details": err.Error()})
+			return
+		}
+	}
+
+	now := time.Now()
+	var err error
+	if !input.AvailableTo.IsZero() {
+		today := availability.Day(now)
+		if input.AvailableTo.Before(today.Time) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.extend_past", nil)})
+			return
+		}
+		if input.AvailableTo.After(today.AddDate(0, 0, availability.MaxExtendDays)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.extend_too_far", map[string]interface{}{"days": availability.MaxExtendDays})})
+			return
+		}
+		err = availability.SetAvailableTo(&product, input.AvailableTo, now)
+	} else {
+		days := input.Days
+		if days == 0 {
+			days = extendDays(product)
+		}
+		if days < 1 || days > availability.MaxExtendDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.extend_invalid_days", map[string]interface{}{"max": availability.MaxExtendDays})})
+			return
+		}
+		err = availability.Extend(&product, days, now)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.extend_failed", nil), "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+// extendPage is the page shown for a signed extend link. Action is set on
+// the confirmation form; without it the page only shows Message.
+var extendPage = template.Must(template.New("extend").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{if .Action}}<form method="post" action="{{.Action}}"><button type="submit">{{.Button}}</button></form>{{end}}
+</body>
+</html>
+`))
+
+type extendPageData struct {
+	Title, Message, Action, Button string
+}
+
+// renderExtendPage writes the extend link page with a message
+func renderExtendPage(c *gin.Context, status int, data extendPageData) {
+	if data.Title == "" {
+		data.Title = i18n.Tc(c, "product.extend_link_title", nil)
+	}
+	c.Render(status, render.HTML{Template: extendPage, Data: data})
+}
+
+// extendLink reads and verifies a signed extend link and loads its listing
+func extendLink(c *gin.Context) (models.Product, int, models.Date, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		renderExtendPage(c, http.StatusBadRequest, extendPageData{Message: i18n.Tc(c, "product.extend_link_invalid", nil)})
+		return models.Product{}, 0, models.Date{}, false
+	}
+	days, err := strconv.Atoi(c.Query("days"))
+	if err != nil || days < 1 || days > availability.MaxExtendDays {
+		renderExtendPage(c, http.StatusBadRequest, extendPageData{Message: i18n.Tc(c, "product.extend_link_invalid", nil)})
+		return models.Product{}, 0, models.Date{}, false
+	}
+	until, err := models.ParseDate(c.Query("until"))
+	if err != nil {
+		renderExtendPage(c, http.StatusBadRequest, extendPageData{Message: i18n.Tc(c, "product.extend_link_invalid", nil)})
+		return models.Product{}, 0, models.Date{}, false
+	}
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || !availability.VerifyExtendLink(uint(id), days, c.Query("until"), expires, c.Query("signature"), time.Now()) {
+		renderExtendPage(c, http.StatusForbidden, extendPageData{Message: i18n.Tc(c, "product.extend_link_expired", nil)})
+		return models.Product{}, 0, models.Date{}, false
+	}
+
+	var product models.Product
+	if err := database.DB.First(&product, id).Error; err != nil {
+		renderExtendPage(c, http.StatusNotFound, extendPageData{Message: i18n.Tc(c, "product.not_found", nil)})
+		return models.Product{}, 0, models.Date{}, false
+	}
+	return product, days, until, true
+}
+
+// ExtendProductByLink shows the confirmation page of the signed link sent in
+// expiry reminders, so farmers can extend a listing without signing in.
+// Opening the link changes nothing; the page posts to ConfirmExtendByLink.
+// GET /products/:id/extend?days=7&until=2024-02-01&expires=1706700000&signature=...
+func ExtendProductByLink(c *gin.Context) {
+	product, days, until, ok := extendLink(c)
+	if !ok {
+		return
+	}
+	if !product.AvailableTo.Equal(until.Time) {
+		renderExtendPage(c, http.StatusOK, extendPageData{Message: i18n.Tc(c, "product.extend_link_used", gin.H{
+			"product": product.NameEn, "date": product.AvailableTo.String(),
+		})})
+		return
+	}
+
+	renderExtendPage(c, http.StatusOK, extendPageData{
+		Message: i18n.Tc(c, "product.extend_link_confirm", gin.H{"product": product.NameEn, "days": days}),
+		Action:  c.Request.URL.Path + "/confirm?" + c.Request.URL.RawQuery,
+		Button:  i18n.Tc(c, "product.extend_link_button", nil),
+	})
+}
+
+// ConfirmExtendByLink extends a listing from the confirmation page of a
+// signed extend link. The link only applies while the listing still ends
+// on the date it was issued for, so following it again changes nothing.
+// POST /products/:id/extend/confirm?days=7&until=2024-02-01&expires=1706700000&signature=...
+func ConfirmExtendByLink(c *gin.Context) {
+	product, days, until, ok := extendLink(c)
+	if !ok {
+		return
+	}
+	extended, err := availability.ExtendOnce(&product, until, days, time.Now())
+	if err != nil {
+		renderExtendPage(c, http.StatusInternalServerError, extendPageData{Message: i18n.Tc(c, "product.extend_failed", nil)})
+		return
+	}
+	if !extended {
+		database.DB.First(&product, product.ID)
+		renderExtendPage(c, http.StatusOK, extendPageData{Message: i18n.Tc(c, "product.extend_link_used", gin.H{
+			"product": product.NameEn, "date": product.AvailableTo.String(),
+		})})
+		return
+	}
+
+	renderExtendPage(c, http.StatusOK, extendPageData{Message: i18n.Tc(c, "product.extend_link_done", gin.H{
+		"product": product.NameEn, "date": product.AvailableTo.String(),
+	})})
+}
+
+// extendDays is the default extension of a listing: its category's shelf
+// life, or a week
+func extendDays(product models.Product) int {
+	if product.CategoryID != nil {
+		var category models.Category
+		if err := database.DB.First(&category, *product.CategoryID).Error; err == nil {
+			if defaults, err := resolveCategoryDefaults(category); err == nil && defaults.ShelfLifeDays > 0 {
+				return min(defaults.ShelfLifeDays, availability.MaxExtendDays)
+			}
+		}
+	}
+	return availability.DefaultExtendDays
+}
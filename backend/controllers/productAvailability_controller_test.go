@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"agro-connect/availability"
+	"agro-connect/database"
+	"agro-connect/database/testdb"
+	"agro-connect/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExtendLinkExtendsOnce(t *testing.T) {
+	db := testdb.Open(t, &models.Product{})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/products/:id/extend", ExtendProductByLink)
+	router.POST("/products/:id/extend/confirm", ConfirmExtendByLink)
+
+	now := time.Now()
+	until := models.NewDate(availability.Today().AddDate(0, 0, 1))
+	product := models.Product{UserID: testFarmerID, NameEn: "Spinach", Unit: "kg", PricePerUnit: 80,
+		Quantity: 20, AvailableTo: until, Status: models.ProductStatusAvailable}
+	mustCreate(t, db, &product)
+	link := availability.ExtendURL(product, 7, now)
+	link = link[strings.Index(link, "/products/"):]
+	confirm := strings.Replace(link, "/extend?", "/extend/confirm?", 1)
+	extended := models.NewDate(until.AddDate(0, 0, 7))
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		status int
+		want   models.Date
+	}{
+		{"open link", http.MethodGet, link, http.StatusOK, until},
+		{"tampered days", http.MethodPost, strings.Replace(confirm, "?days=7", "?days=30", 1), http.StatusForbidden, until},
+		{"confirm", http.MethodPost, confirm, http.StatusOK, extended},
+		{"confirm again", http.MethodPost, confirm, http.StatusOK, extended},
+		{"open used link", http.MethodGet, link, http.StatusOK, extended},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+		if w.Code != tt.status {
+			t.Fatalf("%s: got status %d, want %d", tt.name, w.Code, tt.status)
+		}
+		var saved models.Product
+		if err := database.DB.First(&saved, product.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if !saved.AvailableTo.Equal(tt.want.Time) {
+			t.Fatalf("%s: listing available until %s, want %s", tt.name, saved.AvailableTo, tt.want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/media"
+	"agro-connect/models"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxProductImages limits the size of a product gallery
+const maxProductImages = 10
+
+// ownedProduct loads the product of the :id parameter, answering the request
+// when it does not exist or belongs to another farmer. Admins may manage any
+// product.
+func ownedProduct(c *gin.Context) (models.Product, bool) {
+	var product models.Product
+	if err := database.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "product.not_found", nil)})
+		return product, false
+	}
+
+	userID, _ := c.Get("userID")
+	role, _ := c.Get("role")
+	if product.UserID != userID.(uint) && role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "image.forbidden", nil)})
+		return product, false
+	}
+	return product, true
+}
+
+// uploadedImages returns the files of the "images" field plus the legacy
+// single "image" field
+func uploadedImages(c *gin.Context) []*multipart.FileHeader {
+	var files []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		files = append(files, form.File["images"]...)
+		files = append(files, form.File["image"]...)
+	}
+	return files
+}
+
+// validateImages runs uploaded files through the upload validation pipeline
+func validateImages(c *gin.Context, files []*multipart.FileHeader) ([]*media.Upload, error) {
+	uploads := make([]*media.Upload, 0, len(files))
+	for _, file := range files {
+		upload, err := media.ValidateImage(c.Request.Context(), file)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads, nil
+}
+
+// uploadErrorStatus answers rejected uploads with 400 and failures to check
+// or store them with 500
+func uploadErrorStatus(err error) int {
+	if media.IsRejected(err) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// errTooManyImages is returned for uploads past maxProductImages
+var errTooManyImages = fmt.Errorf("a product can have at most %d images", maxProductImages)
+
+// addProductImages processes validated uploads and appends them to the
+// product gallery. Stored variants are returned so callers can remove them
+// if the surrounding transaction fails.
+func addProductImages(tx *gorm.DB, productID uint, uploads []*media.Upload, primary bool) ([]models.ProductImage, error) {
+	var count int64
+	if err := tx.Model(&models.ProductImage{}).Where("product_id = ?", productID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if int(count)+len(uploads) > maxProductImages {
+		return nil, errTooManyImages
+	}
+
+	var position int
+	tx.Model(&models.ProductImage{}).Where("product_id = ?", productID).
+		Select("COALESCE(MAX(position) + 1, 0)").Scan(&position)
+
+	var added []models.ProductImage
+	for i, upload := range uploads {
+		image, err := media.SaveProductImage(productID, upload)
+		if err != nil {
+			return added, err
+		}
+		added = append(added, image)
+
+		image.Position = position + i
+		image.IsPrimary = primary && i == 0
+		if err := tx.Create(&image).Error; err != nil {
+			return added, err
+		}
+		added[len(added)-1] = image
+	}
+
+	if primary && len(added) > 0 {
+		if err := tx.Model(&models.ProductImage{}).
+			Where("product_id = ? AND id <> ?", productID, added[0].ID).
+			Update("is_primary", false).Error; err != nil {
+			return added, err
+		}
+	}
+	return added, media.SyncPrimaryImage(tx, productID)
+}
+
+// removeImageFiles deletes the stored variants of images
+func removeImageFiles(images []models.ProductImage) {
+	for _, image := range images {
+		media.RemoveFiles(image.Files()...)
+	}
+}
+
+// deleteProductImages removes the gallery of a product with all variants,
+// plus the single image of products from before galleries existed
+func deleteProductImages(tx *gorm.DB, product models.Product) error {
+	var images []models.ProductImage
+	if err := tx.Where("product_id = ?", product.ID).Find(&images).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("product_id = ?", product.ID).Delete(&models.ProductImage{}).Error; err != nil {
+		return err
+	}
+
+	removeImageFiles(images)
+	if len(images) == 0 && product.ImageURL != "" {
+		media.RemoveFiles(product.ImageURL)
+	}
+	return nil
+}
+
+// GetProductImages lists the gallery of a product in display order
+// GET /products/:id/images
+func GetProductImages(c *gin.Context) {
+	var images []models.ProductImage
+	if err := database.DB.Where("product_id = ?", c.Param("id")).Order("position, id").Find(&images).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "image.retrieve_failed", nil)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"images": images})
+}
+
+// AddProductImages uploads images to the end of a product gallery. With
+// ?primary=true the first uploaded image becomes the primary image.
+// POST /products/:id/images (multipart, field "images")
+func AddProductImages(c *gin.Context) {
+	product, ok := ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	files := uploadedImages(c)
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "image.required", nil)})
+		return
+	}
+	uploads, err := validateImages(c, files)
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var added []models.ProductImage
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		added, err = addProductImages(tx, product.ID, uploads, c.Query("primary") == "true")
+		return err
+	})
+	if errors.Is(err, errTooManyImages) {
+		removeImageFiles(added)
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "image.too_many", map[string]interface{}{"max": maxProductImages})})
+		return
+	}
+	if err != nil {
+		removeImageFiles(added)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "image.add_failed", nil), "details": err.Error()})
+		return
+	}
+	if err := moderateListingUpdate(c, database.DB, product, &product, imageChange(true)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "moderation.submit_failed", nil), "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": i18n.Tc(c, "image.added", nil),
+		"images":  added,
+		"status":  product.Status,
+	})
+}
+
+// ReorderProductImages sets the gallery order
+// PUT /products/:id/images/order
+// {"image_ids": [3, 1, 2]}
+func ReorderProductImages(c *gin.Context) {
+	product, ok := ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		ImageIDs []uint `json:"image_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var images []models.ProductImage
+	if err := database.DB.Where("product_id = ?", product.ID).Find(&images).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "image.retrieve_failed", nil)})
+		return
+	}
+	existing := make(map[uint]bool, len(images))
+	for _, image := range images {
+		existing[image.ID] = true
+	}
+	seen := map[uint]bool{}
+	for _, id := range input.ImageIDs {
+		if !existing[id] || seen[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "image.invalid_id", map[string]interface{}{"id": id})})
+			return
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(images) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "image.order_incomplete", nil)})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for position, id := range input.ImageIDs {
+			if err := tx.Model(&models.ProductImage{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "image.reorder_failed", nil)})
+		return
+	}
+
+	GetProductImages(c)
+}
+
+// SetPrimaryProductImage makes an image the primary image of its product
+// PUT /products/:id/images/:imageId/primary
+func SetPrimaryProductImage(c *gin.Context) {
+	product, ok := ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	var image models.ProductImage
+	if err := database.DB.Where("id = ? AND product_id = ?", c.Param("imageId"), product.ID).First(&image).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "image.not_found", nil)})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ProductImage{}).Where("product_id = ?", product.ID).Update("is_primary", false).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&image).Update("is_primary", true).Error; err != nil {
+			return err
+		}
+		return media.SyncPrimaryImage(tx, product.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "image.primary_failed", nil)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "image.primary_updated", nil), "image": image})
+}
+
+// DeleteProductImage removes an image and all its variants. When the primary
+// image is removed the next image in the gallery becomes primary.
+// DELETE /products/:id/images/:imageId
+func DeleteProductImage(c *gin.Context) {
+	product, ok := ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	var image models.ProductImage
+	if err := database.DB.Where("id = ? AND product_id = ?", c.Param("imageId"), product.ID).First(&image).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "image.not_found", nil)})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&image).Error; err != nil {
+			return err
+		}
+		return media.SyncPrimaryImage(tx, product.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "image.delete_failed", nil)})
+		return
+	}
+	removeImageFiles([]models.ProductImage{image})
+
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "image.deleted", nil)})
+}
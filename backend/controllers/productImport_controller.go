@@ -0,0 +1,330 @@
+package controllers
+
+import (
+	"agro-connect/catalog"
+	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/market"
+	"agro-connect/media"
+	"agro-connect/models"
+	"agro-connect/spreadsheet"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Limits of bulk product imports
+const (
+	maxProductSheetSize  = 5 * 1024 * 1024  // 5MB
+	maxImageArchiveSize  = 50 * 1024 * 1024 // 50MB
+	maxProductImportRows = 500
+)
+
+// importedProduct is a validated row of a product import
+type importedProduct struct {
+	row      catalog.Row
+	previous *models.Product // nil for new listings
+	product  models.Product
+	upload   *media.Upload
+}
+
+// ProductImportReport is the result of a product import
+type ProductImportReport struct {
+	DryRun   bool               `json:"dry_run"`
+	Rows     int                `json:"rows"`
+	Created  int                `json:"created"`
+	Updated  int                `json:"updated"`
+	Errors   []catalog.RowError `json:"errors"`
+	Products []models.Product   `json:"products"`
+}
+
+// ImportProducts creates and updates the farmer's listings from a CSV or
+// XLSX sheet laid out as described by GET /products/import/template. Images
+// are named by URL or by file name in an optional zip. Every row is
+// validated first and nothing is saved unless all rows are valid; with
+// dry_run=true the validated listings are returned without saving them.
+// POST /products/import (multipart: file, images, dry_run)
+func ImportProducts(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "import.file_required", nil)})
+		return
+	}
+	if file.Size > maxProductSheetSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "import.file_too_large", nil)})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "import.read_failed", nil)})
+		return
+	}
+	defer f.Close()
+
+	rows, errs, err := catalog.ReadProducts(f, file.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Rows with parse errors are left out of rows and may have several errors
+	sheetRows := len(rows) + distinctRows(errs)
+	if sheetRows > maxProductImportRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "import.too_many_rows", map[string]interface{}{"max": maxProductImportRows})})
+		return
+	}
+
+	var archive *zip.Reader
+	if images, err := c.FormFile("images"); err == nil {
+		if images.Size > maxImageArchiveSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "import.archive_too_large", nil)})
+			return
+		}
+		zf, err := images.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "import.archive_read_failed", nil)})
+			return
+		}
+		defer zf.Close()
+		if archive, err = zip.NewReader(zf, images.Size); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "import.archive_not_zip", nil)})
+			return
+		}
+	}
+	loader, err := catalog.NewImages(archive)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	imports, rowErrors := validateImportRows(c, userID.(uint), rows, loader)
+	errs = append(errs, rowErrors...)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Row < errs[j].Row })
+
+	report := ProductImportReport{
+		DryRun:   c.PostForm("dry_run") == "true",
+		Rows:     sheetRows,
+		Errors:   errs,
+		Products: []models.Product{},
+	}
+	if report.Errors == nil {
+		report.Errors = []catalog.RowError{}
+	}
+	for _, imported := range imports {
+		if imported.previous == nil {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+	}
+	if len(errs) > 0 {
+		report.Created, report.Updated = 0, 0
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+	if report.DryRun {
+		for _, imported := range imports {
+			report.Products = append(report.Products, imported.product)
+		}
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	// Save every listing together, so a failure leaves no partial import
+	var added []models.ProductImage
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range imports {
+			imported := &imports[i]
+			images, err := saveImportedProduct(c, tx, imported)
+			added = append(added, images...)
+			if err != nil {
+				return fmt.Errorf("Failed to save row %d: %w", imported.row.Line, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		removeImageFiles(added)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "import.save_failed", nil), "details": err.Error()})
+		return
+	}
+
+	for _, imported := range imports {
+		var product models.Product
+		database.DB.Preload("Images", orderedImages).First(&product, imported.product.ID)
+		report.Products = append(report.Products, product)
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// distinctRows counts the sheet rows that have errors
+func distinctRows(errs []catalog.RowError) int {
+	lines := map[int]bool{}
+	for _, e := range errs {
+		lines[e.Row] = true
+	}
+	return len(lines)
+}
+
+// validateImportRows merges each row into the listing it updates, validates
+// it as the product endpoints would and loads its image
+func validateImportRows(c *gin.Context, userID uint, rows []catalog.Row, loader *catalog.Images) ([]importedProduct, []catalog.RowError) {
+	var imports []importedProduct
+	var errs []catalog.RowError
+	seen := map[uint]int{}
+
+	for _, row := range rows {
+		fail := func(column string, err error) {
+			errs = append(errs, catalog.RowError{Row: row.Line, Column: column, Error: err.Error()})
+		}
+		imported := importedProduct{row: row}
+
+		if row.ID != 0 {
+			if line, ok := seen[row.ID]; ok {
+				fail("id", fmt.Errorf("listing %d is already updated by row %d", row.ID, line))
+				continue
+			}
+			seen[row.ID] = row.Line
+
+			var existing models.Product
+			if err := database.DB.Where("id = ? AND user_id = ?", row.ID, userID).First(&existing).Error; err != nil {
+				fail("id", fmt.Errorf("listing %d not found among your listings", row.ID))
+				continue
+			}
+			imported.previous = &existing
+			imported.product = row.Apply(existing)
+			// A new free-text category replaces the linked one
+			if imported.product.Category != existing.Category {
+				imported.product.CategoryID = nil
+			}
+		} else {
+			imported.product = row.Product
+			imported.product.UserID = userID
+			if !row.Set["status"] {
+				imported.product.Status = models.ProductStatusAvailable
+			}
+		}
+
+		product := &imported.product
+		if err := prepareProduct(product); err != nil {
+			fail("", err)
+			continue
+		}
+		current := models.ProductStatusDraft
+		if imported.previous != nil {
+			current = imported.previous.Status
+		}
+		if err := resolveProductStatus(current, product); err != nil {
+			fail("status", err)
+			continue
+		}
+
+		// Exported sheets name the current primary image, which is kept
+		switch {
+		case row.Image == "" && imported.previous == nil:
+			fail("image", fmt.Errorf("image is required for new listings"))
+			continue
+		case row.Image != "" && (imported.previous == nil || row.Image != imported.previous.ImageURL):
+			upload, err := loader.Load(c.Request.Context(), row.Image)
+			if err != nil {
+				fail("image", err)
+				continue
+			}
+			imported.upload = upload
+		}
+
+		imports = append(imports, imported)
+	}
+	return imports, errs
+}
+
+// saveImportedProduct creates or updates a listing of an import, records
+// its price, adds its image and submits it for moderation
+func saveImportedProduct(c *gin.Context, tx *gorm.DB, imported *importedProduct) ([]models.ProductImage, error) {
+	product := &imported.product
+	if imported.previous == nil {
+		if err := tx.Create(product).Error; err != nil {
+			return nil, err
+		}
+		if err := market.RecordPrice(tx, *product); err != nil {
+			return nil, err
+		}
+	} else {
+		existing := *imported.previous
+		product.Images = nil
+		product.ImageURL, product.ThumbnailURL = "", ""
+		if err := saveProductUpdate(tx, &existing, *product); err != nil {
+			return nil, err
+		}
+		if err := tx.First(product, existing.ID).Error; err != nil {
+			return nil, err
+		}
+		if market.PriceChanged(*imported.previous, *product) {
+			if err := market.RecordPrice(tx, *product); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var added []models.ProductImage
+	if imported.upload != nil {
+		var err error
+		if added, err = addProductImages(tx, product.ID, []*media.Upload{imported.upload}, true); err != nil {
+			return added, err
+		}
+	}
+	return added, moderateListing(c, tx, imported.previous, product, imageChange(imported.upload != nil))
+}
+
+// ExportProducts downloads the farmer's listings as a product sheet that
+// can be edited and imported again
+// GET /products/me/export?format=csv|xlsx
+func ExportProducts(c *gin.Context) {
+	format := c.DefaultQuery("format", spreadsheet.FormatCSV)
+	if _, ok := spreadsheet.ContentTypes[format]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "import.invalid_format", nil)})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	var products []models.Product
+	if err := database.DB.Where("user_id = ?", userID).Order("id").Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.retrieve_failed", nil)})
+		return
+	}
+
+	rows := [][]string{catalog.Header()}
+	for _, product := range products {
+		rows = append(rows, catalog.Record(product))
+	}
+	sendSheet(c, format, "products-"+time.Now().Format("2006-01-02"), spreadsheet.Sheet{Name: "Products", Rows: rows})
+}
+
+// GetProductImportTemplate downloads an empty product sheet with an example
+// row; the XLSX template also documents every column
+// GET /products/import/template?format=csv|xlsx
+func GetProductImportTemplate(c *gin.Context) {
+	format := c.DefaultQuery("format", spreadsheet.FormatXLSX)
+	if _, ok := spreadsheet.ContentTypes[format]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "import.invalid_format", nil)})
+		return
+	}
+	sendSheet(c, format, "product-import-template", catalog.Template()...)
+}
+
+// sendSheet sends sheets as a file download named name
+func sendSheet(c *gin.Context, format, name string, sheets ...spreadsheet.Sheet) {
+	var buf bytes.Buffer
+	if err := spreadsheet.Write(&buf, format, sheets...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "import.write_failed", map[string]interface{}{"format": format})})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+format))
+	c.Data(http.StatusOK, spreadsheet.ContentTypes[format], buf.Bytes())
+}
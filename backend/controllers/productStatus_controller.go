@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"agro-connect/availability"
+	"agro-connect/database"
+	"agro-connect/models"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// resolveProductStatus validates the status requested for a product, whose
+// status was current, and replaces it with the status to store. An empty
+// status keeps the current one. Live statuses are derived from the quantity
+// and dates, so asking for available publishes the listing as scheduled,
+// available, sold out or expired. Marking a listing sold out clears its
+// quantity.
+func resolveProductStatus(current models.ProductStatus, product *models.Product) error {
+	requested := product.Status
+	if requested == "" {
+		requested = current
+	}
+	if !requested.Valid() {
+		return fmt.Errorf("invalid status %q, expected one of %v", requested, models.ProductStatuses)
+	}
+	if requested == models.ProductStatusSoldOut && current != models.ProductStatusSoldOut {
+		product.Quantity = 0
+	}
+
+	product.Status = requested
+	next := availability.Status(*product, availability.Today())
+	if !current.CanTransition(next) {
+		return fmt.Errorf("cannot change status from %s to %s", current, next)
+	}
+	product.Status = next
+	return nil
+}
+
+// GetProductStatuses lists the product statuses and the statuses each may
+// change to
+// GET /products/statuses
+func GetProductStatuses(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"statuses":    models.ProductStatuses,
+		"transitions": models.ProductStatusTransitions(),
+	})
+}
+
+// UpdateProductStatus updates the status of a product
+// PUT /products/:id/status {"status": "archived"}
+func UpdateProductStatus(c *gin.Context) {
+	existingProduct, ok := ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	var statusUpdate struct {
+		Status models.ProductStatus `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&statusUpdate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status data: " + err.Error()})
+		return
+	}
+
+	previous := existingProduct
+	product := existingProduct
+	product.Status = statusUpdate.Status
+	if err := resolveProductStatus(existingProduct.Status, &product); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&existingProduct).Updates(map[string]interface{}{
+			"status":   product.Status,
+			"quantity": product.Quantity,
+		}).Error; err != nil {
+			return err
+		}
+		return moderateListingUpdate(c, tx, previous, &existingProduct, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product status: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, existingProduct)
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"agro-connect/availability"
+	"agro-connect/models"
+	"testing"
+)
+
+func TestResolveProductStatusRejectsIllegalMoves(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   models.ProductStatus
+		requested models.ProductStatus
+		quantity  float64
+	}{
+		{"archived to available", models.ProductStatusArchived, models.ProductStatusAvailable, 10},
+		{"archived to sold out", models.ProductStatusArchived, models.ProductStatusSoldOut, 10},
+		{"draft published empty", models.ProductStatusDraft, models.ProductStatusAvailable, 0},
+		{"draft to sold out", models.ProductStatusDraft, models.ProductStatusSoldOut, 10},
+		{"sold out to reserved", models.ProductStatusSoldOut, models.ProductStatusReserved, 10},
+		{"available to review", models.ProductStatusAvailable, models.ProductStatusPendingReview, 10},
+		{"unknown status", models.ProductStatusAvailable, models.ProductStatus("gone"), 10},
+	}
+	for _, tt := range tests {
+		product := models.Product{Status: tt.requested, Quantity: tt.quantity}
+		if err := resolveProductStatus(tt.current, &product); err == nil {
+			t.Errorf("%s: got status %s, want an error", tt.name, product.Status)
+		}
+	}
+}
+
+func TestResolveProductStatusSoldOutNeedsRestock(t *testing.T) {
+	product := models.Product{Status: models.ProductStatusAvailable, Quantity: 0}
+	if err := resolveProductStatus(models.ProductStatusSoldOut, &product); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if product.Status != models.ProductStatusSoldOut {
+		t.Fatalf("without a restock: got %s, want %s", product.Status, models.ProductStatusSoldOut)
+	}
+
+	product = models.Product{Status: models.ProductStatusAvailable, Quantity: 25}
+	if err := resolveProductStatus(models.ProductStatusSoldOut, &product); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if product.Status != models.ProductStatusAvailable {
+		t.Fatalf("after a restock: got %s, want %s", product.Status, models.ProductStatusAvailable)
+	}
+}
+
+func TestResolveProductStatusArchivedRevivesAsDraft(t *testing.T) {
+	product := models.Product{Status: models.ProductStatusDraft, Quantity: 10}
+	if err := resolveProductStatus(models.ProductStatusArchived, &product); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if product.Status != models.ProductStatusDraft {
+		t.Fatalf("got %s, want %s", product.Status, models.ProductStatusDraft)
+	}
+}
+
+func TestResolveProductStatusSoldOutClearsQuantity(t *testing.T) {
+	product := models.Product{Status: models.ProductStatusSoldOut, Quantity: 10, AvailableTo: availability.Today()}
+	if err := resolveProductStatus(models.ProductStatusAvailable, &product); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if product.Status != models.ProductStatusSoldOut || product.Quantity != 0 {
+		t.Fatalf("got %s with %g left, want sold out with nothing left", product.Status, product.Quantity)
+	}
+}
@@ -0,0 +1,329 @@
+package controllers
+
+import (
+	"agro-connect/availability"
+	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/models"
+	"agro-connect/moderation"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Errors of variant references on offers and orders
+var (
+	errVariantRequired = errors.New("choose a variant of this listing")
+	errVariantNotFound = errors.New("variant not found for this listing")
+)
+
+// variantMessage translates an error of resolveVariant
+func variantMessage(c *gin.Context, err error) string {
+	switch {
+	case errors.Is(err, errVariantRequired):
+		return i18n.Tc(c, "variant.required", nil)
+	case errors.Is(err, errVariantNotFound):
+		return i18n.Tc(c, "variant.not_found", nil)
+	default:
+		return err.Error()
+	}
+}
+
+// variantKey identifies a variant within its listing, ignoring case
+func variantKey(v models.ProductVariant) string {
+	return strings.ToLower(v.Grade + "|" + v.Size + "|" + v.Packaging)
+}
+
+// prepareVariant validates a variant and normalizes its price to a price
+// per kilogram in the listing's unit
+func prepareVariant(variant *models.ProductVariant, unit string, kgPerLitre float64) error {
+	variant.Grade = strings.TrimSpace(variant.Grade)
+	variant.Size = strings.TrimSpace(variant.Size)
+	variant.Packaging = strings.TrimSpace(variant.Packaging)
+	if variant.Grade == "" && variant.Size == "" && variant.Packaging == "" {
+		return fmt.Errorf("a variant needs a grade, size or packaging")
+	}
+	if variant.Quantity < 0 {
+		return fmt.Errorf("invalid quantity for variant %s", variant.Label())
+	}
+	if variant.PricePerUnit <= 0 {
+		return fmt.Errorf("invalid price_per_unit for variant %s", variant.Label())
+	}
+	variant.PricePerKg = unitPricePerKg(unit, variant.PricePerUnit, kgPerLitre)
+	return nil
+}
+
+// prepareVariants validates the variants of a new listing and sets the
+// listing's quantity and price from them
+func prepareVariants(product *models.Product, kgPerLitre float64) error {
+	seen := map[string]bool{}
+	for i := range product.Variants {
+		variant := &product.Variants[i]
+		variant.ID = 0
+		if err := prepareVariant(variant, product.Unit, kgPerLitre); err != nil {
+			return err
+		}
+		if seen[variantKey(*variant)] {
+			return fmt.Errorf("duplicate variant %s", variant.Label())
+		}
+		seen[variantKey(*variant)] = true
+		variant.Position = i
+	}
+	product.Quantity, product.PricePerUnit = variantTotals(product.Variants)
+	return nil
+}
+
+// variantTotals returns the total quantity and the lowest price of variants
+func variantTotals(variants []models.ProductVariant) (float64, float64) {
+	var quantity, price float64
+	for i, variant := range variants {
+		quantity += variant.Quantity
+		if i == 0 || variant.PricePerUnit < price {
+			price = variant.PricePerUnit
+		}
+	}
+	return quantity, price
+}
+
+// syncVariantTotals sets the quantity, price and status of a listing with
+// variants from its variants. Listings without variants are left alone.
+func syncVariantTotals(db *gorm.DB, product *models.Product) error {
+	var variants []models.ProductVariant
+	if err := db.Where("product_id = ?", product.ID).Find(&variants).Error; err != nil {
+		return err
+	}
+	if len(variants) == 0 {
+		return nil
+	}
+
+	product.Quantity, product.PricePerUnit = variantTotals(variants)
+	product.PricePerKg = unitPricePerKg(product.Unit, product.PricePerUnit, productDensity(*product))
+	if product.Status.Live() {
+		product.Status = availability.Status(*product, availability.Today())
+	}
+	return db.Model(product).Updates(map[string]interface{}{
+		"quantity":       product.Quantity,
+		"price_per_unit": product.PricePerUnit,
+		"price_per_kg":   product.PricePerKg,
+		"status":         product.Status,
+	}).Error
+}
+
+// resolveVariant checks that an offer or order names a variant of its
+// listing when the listing has variants
+func resolveVariant(productID uint, variantID *uint) error {
+	if variantID == nil {
+		var count int64
+		if err := database.DB.Model(&models.ProductVariant{}).Where("product_id = ?", productID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errVariantRequired
+		}
+		return nil
+	}
+
+	var variant models.ProductVariant
+	if err := database.DB.Where("id = ? AND product_id = ?", *variantID, productID).First(&variant).Error; err != nil {
+		return errVariantNotFound
+	}
+	return nil
+}
+
+// orderedVariants preloads product variants in listing order
+func orderedVariants(db *gorm.DB) *gorm.DB {
+	return db.Order("position, id")
+}
+
+// attachVariants loads the variants of listings returned by raw queries
+func attachVariants(products []*models.Product) {
+	if len(products) == 0 {
+		return
+	}
+	ids := make([]uint, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	var variants []models.ProductVariant
+	if err := database.DB.Where("product_id IN ?", ids).Scopes(orderedVariants).Find(&variants).Error; err != nil {
+		return
+	}
+	byProduct := map[uint][]models.ProductVariant{}
+	for _, variant := range variants {
+		byProduct[variant.ProductID] = append(byProduct[variant.ProductID], variant)
+	}
+	for _, product := range products {
+		product.Variants = byProduct[product.ID]
+	}
+}
+
+// GetProductVariants lists the variants of a listing
+// GET /products/:id/variants
+func GetProductVariants(c *gin.Context) {
+	var product models.Product
+	if err := database.DB.Where(publicProductsSQL).First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "product.not_found", nil)})
+		return
+	}
+
+	variants := []models.ProductVariant{}
+	if err := database.DB.Where("product_id = ?", product.ID).Scopes(orderedVariants).Find(&variants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "variant.retrieve_failed", nil)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"variants": variants})
+}
+
+// AddProductVariant adds a variant to a listing. The first variant replaces
+// the listing's own quantity and price.
+// POST /products/:id/variants {"grade": "A", "quantity": 200, "price_per_unit": 60}
+func AddProductVariant(c *gin.Context) {
+	product, ok := ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	var variant models.ProductVariant
+	if err := c.ShouldBindJSON(&variant); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	variant.ID = 0
+	variant.ProductID = product.ID
+
+	saveProductVariant(c, product, nil, &variant, http.StatusCreated)
+}
+
+// UpdateProductVariant changes the grade, size, packaging, quantity or price
+// of a variant
+// PUT /products/:id/variants/:variantId
+func UpdateProductVariant(c *gin.Context) {
+	product, ok := ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	var variant models.ProductVariant
+	if err := database.DB.Where("id = ? AND product_id = ?", c.Param("variantId"), product.ID).First(&variant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "variant.not_found_by_id", nil)})
+		return
+	}
+	stored := variant
+	if err := c.ShouldBindJSON(&variant); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	variant.ID, variant.ProductID, variant.Position = stored.ID, product.ID, stored.Position
+
+	saveProductVariant(c, product, &stored, &variant, http.StatusOK)
+}
+
+// saveProductVariant validates and saves a variant, then updates the
+// listing's totals, price history and moderation. stored is the variant as
+// loaded before the update, or nil for a new variant. A new variant, or a
+// new name or price of one, needs a new review of a published listing.
+func saveProductVariant(c *gin.Context, product models.Product, stored, variant *models.ProductVariant, status int) {
+	if product.AwaitingHarvest() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "variant.forward_listing", nil)})
+		return
+	}
+	if err := prepareVariant(variant, product.Unit, productDensity(product)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var others []models.ProductVariant
+	database.DB.Where("product_id = ? AND id <> ?", product.ID, variant.ID).Find(&others)
+	for _, other := range others {
+		if variantKey(other) == variantKey(*variant) {
+			c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "variant.exists", map[string]interface{}{"variant": variant.Label()})})
+			return
+		}
+	}
+	if variant.ID == 0 {
+		variant.Position = len(others)
+		for _, other := range others {
+			if other.Position >= variant.Position {
+				variant.Position = other.Position + 1
+			}
+		}
+	}
+
+	previous := product
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the listing and variant as a sale does, so a sale since the
+		// variant was loaded is not overwritten by its old quantity
+		var variantID *uint
+		if stored != nil {
+			variantID = &variant.ID
+		}
+		_, locked, err := availability.LockStock(tx, product.ID, variantID)
+		if err != nil {
+			return err
+		}
+		before := models.ProductVariant{}
+		if locked != nil {
+			if variant.Quantity == stored.Quantity {
+				variant.Quantity = locked.Quantity
+			}
+			before = *locked
+		}
+
+		if err := tx.Save(variant).Error; err != nil {
+			return err
+		}
+		if err := syncVariantTotals(tx, &product); err != nil {
+			return err
+		}
+		return moderateListingUpdate(c, tx, previous, &product, moderation.VariantChanges(before, *variant))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "variant.save_failed", nil), "details": err.Error()})
+		return
+	}
+	recordPriceChange(previous)
+
+	c.JSON(status, gin.H{"variant": variant, "product": product})
+}
+
+// DeleteProductVariant removes a variant from a listing. Offers and orders
+// keep referring to it. Removing the last variant leaves the listing's
+// quantity and price as they were.
+// DELETE /products/:id/variants/:variantId
+func DeleteProductVariant(c *gin.Context) {
+	product, ok := ownedProduct(c)
+	if !ok {
+		return
+	}
+
+	var variant models.ProductVariant
+	if err := database.DB.Where("id = ? AND product_id = ?", c.Param("variantId"), product.ID).First(&variant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "variant.not_found_by_id", nil)})
+		return
+	}
+
+	previous := product
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, _, err := availability.LockStock(tx, product.ID, &variant.ID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&variant).Error; err != nil {
+			return err
+		}
+		if err := syncVariantTotals(tx, &product); err != nil {
+			return err
+		}
+		return moderateListingUpdate(c, tx, previous, &product, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "variant.delete_failed", nil), "details": err.Error()})
+		return
+	}
+	recordPriceChange(previous)
+
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "variant.deleted", nil), "product": product})
+}
@@ -2,91 +2,97 @@ package controllers
 
 import (
 	"agro-connect/database"
+	"agro-connect/geo"
+	"agro-connect/i18n"
+	"agro-connect/market"
+	"agro-connect/media"
 	"agro-connect/models"
+	"agro-connect/search"
+	"agro-connect/units"
 	"encoding/json"
 	"fmt"
-	"mime"
-	"mime/multipart"
+	"log"
+	"math"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-const (
-	maxUploadSize = 2 * 1024 * 1024 // 2MB
-	uploadsDir    = "uploads"
-)
-
 // CreateProduct handles the creation of a new product with image upload
 func CreateProduct(c *gin.Context) {
 	// Get JSON data from "data" form field
 	jsonData := c.PostForm("data")
 	if jsonData == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing product data"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.missing_data", nil)})
 		return
 	}
 
 	var product models.Product
 	if err := json.Unmarshal([]byte(jsonData), &product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product data JSON: " + err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.invalid_data", nil), "details": err.Error()})
 		return
 	}
 
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.Tc(c, "error.unauthenticated", nil)})
 		return
 	}
 	product.UserID = userID.(uint)
+	product.HarvestConfirmedAt = nil
 
-	// Handle image upload
-	file, err := c.FormFile("image")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Image file is required"})
+	if err := prepareProduct(&product); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// Validate file type
-	if err := validateImageFile(file); err != nil {
+	// New listings start as a draft or go live
+	if err := resolveProductStatus(models.ProductStatusDraft, &product); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Create uploads directory if not exists
-	if _, err := os.Stat(uploadsDir); os.IsNotExist(err) {
-		if err := os.Mkdir(uploadsDir, 0755); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create uploads directory"})
-			return
-		}
+	// Images are uploaded in the "images" field, or "image" for older clients
+	files := uploadedImages(c)
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.image_required", nil)})
+		return
 	}
-
-	// Generate unique filename
-	ext := filepath.Ext(file.Filename)
-	filename := fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), strings.TrimSuffix(filepath.Base(file.Filename), ext), ext)
-	savePath := filepath.Join(uploadsDir, filename)
-
-	// Save file
-	if err := c.SaveUploadedFile(file, savePath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image: " + err.Error()})
+	uploads, err := validateImages(c, files)
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	product.ImageURL = "/" + filepath.ToSlash(savePath)
-
-	// Create product
-	if err := database.DB.Create(&product).Error; err != nil {
-		// Clean up uploaded file if database operation fails
-		_ = os.Remove(savePath)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product: " + err.Error()})
+	// Create the product and its gallery together
+	var added []models.ProductImage
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return fmt.Errorf("Failed to create product: %w", err)
+		}
+		if err := market.RecordPrice(tx, product); err != nil {
+			return fmt.Errorf("Failed to record price: %w", err)
+		}
+		var err error
+		added, err = addProductImages(tx, product.ID, uploads, true)
+		if err != nil {
+			return fmt.Errorf("Failed to save images: %w", err)
+		}
+		if err := moderateListing(c, tx, nil, &product, nil); err != nil {
+			return fmt.Errorf("Failed to submit listing for review: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		// Clean up stored variants if the database operation fails
+		removeImageFiles(added)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	database.DB.Preload("Images", orderedImages).Preload("Variants", orderedVariants).First(&product, product.ID)
 	c.JSON(http.StatusCreated, product)
 }
 
@@ -95,43 +101,488 @@ func GetProductByID(c *gin.Context) {
 	productID := c.Param("id")
 	var product models.Product
 
-	if err := database.DB.First(&product, productID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found: " + err.Error()})
+	if err := database.DB.Preload("Images", orderedImages).Preload("Variants", orderedVariants).
+		Where(publicProductsSQL).First(&product, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "product.not_found", nil), "details": err.Error()})
 		return
 	}
 
+	attachReferencePrice(&product)
 	c.JSON(http.StatusOK, product)
 }
 
-// GetAllProducts retrieves all products
+// attachReferencePrice adds the market reference price to one listing
+func attachReferencePrice(product *models.Product) {
+	products := []models.Product{*product}
+	attachReferencePrices(products)
+	product.ReferencePrice = products[0].ReferencePrice
+}
+
+// orderedImages preloads product images in gallery order
+func orderedImages(db *gorm.DB) *gorm.DB {
+	return db.Order("position, id")
+}
+
+// productFilter restricts the product list along one facet dimension
+type productFilter struct {
+	dimension string
+	apply     func(db *gorm.DB) *gorm.DB
+}
+
+// FacetCount is the number of products sharing a facet value
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// productSortOrders maps the "sort" query parameter to an ORDER BY clause
+var productSortOrders = map[string]string{
+	"newest":         "products.created_at DESC",
+	"oldest":         "products.created_at ASC",
+	"price_asc":      "products.price_per_unit ASC",
+	"price_desc":     "products.price_per_unit DESC",
+	"quantity_desc":  "products.quantity DESC",
+	"name_asc":       "products.name_en ASC",
+	"available_from": "products.available_from ASC",
+	"price_per_kg":   "products.price_per_kg ASC NULLS LAST",
+}
+
+const productUsersJoin = "LEFT JOIN users ON users.id = products.user_id"
+
+// parseProductFilters builds the filters selected by the query string
+func parseProductFilters(c *gin.Context) ([]productFilter, error) {
+	var filters []productFilter
+	add := func(dimension, condition string, args ...interface{}) {
+		filters = append(filters, productFilter{dimension, func(db *gorm.DB) *gorm.DB {
+			return db.Where(condition, args...)
+		}})
+	}
+	parseFloat := func(name string) (float64, bool, error) {
+		value := c.Query(name)
+		if value == "" {
+			return 0, false, nil
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid %s: %s", name, value)
+		}
+		return f, true, nil
+	}
+
+	if category := c.Query("category"); category != "" {
+		// A category matches its subcategories too; unmapped free-text
+		// values are compared by name
+		var match *models.Category
+		if id, err := strconv.ParseUint(category, 10, 64); err == nil {
+			var found models.Category
+			if database.DB.First(&found, id).Error == nil {
+				match = &found
+			}
+		} else if found, err := database.FindCategoryByName(category); err == nil {
+			match = found
+		}
+
+		if match != nil {
+			add("category", "products.category_id IN ("+categorySubtreeSQL+")", match.ID)
+		} else {
+			add("category", "LOWER(products.category) = LOWER(?)", category)
+		}
+	}
+	if grade := c.Query("grade"); grade != "" {
+		add("grade", `EXISTS (SELECT 1 FROM product_variants
+			WHERE product_variants.product_id = products.id
+			AND product_variants.deleted_at IS NULL
+			AND LOWER(product_variants.grade) = LOWER(?))`, grade)
+	}
+	if unit := c.Query("unit"); unit != "" {
+		add("unit", "LOWER(products.unit) = LOWER(?)", unit)
+	}
+	if status := c.Query("status"); status != "" {
+		if !models.ProductStatus(status).Valid() {
+			return nil, fmt.Errorf("invalid status: %s", status)
+		}
+		add("status", "products.status = ?", status)
+	}
+	if district := c.Query("district"); district != "" {
+		add("district", "LOWER(users.district) = LOWER(?)", district)
+	}
+	if certification := c.Query("certification"); certification != "" {
+		add("certification", `EXISTS (SELECT 1 FROM farmer_profiles
+			WHERE farmer_profiles.user_id = products.user_id
+			AND farmer_profiles.deleted_at IS NULL
+			AND farmer_profiles.certifications ILIKE ?)`, "%"+certification+"%")
+	}
+
+	if minPrice, ok, err := parseFloat("min_price"); err != nil {
+		return nil, err
+	} else if ok {
+		// The listing price is its lowest variant price, so a dearer
+		// variant matches as well
+		add("price", `(products.price_per_unit >= ? OR EXISTS (SELECT 1 FROM product_variants
+			WHERE product_variants.product_id = products.id
+			AND product_variants.deleted_at IS NULL
+			AND product_variants.price_per_unit >= ?))`, minPrice, minPrice)
+	}
+	if maxPrice, ok, err := parseFloat("max_price"); err != nil {
+		return nil, err
+	} else if ok {
+		add("price", "products.price_per_unit <= ?", maxPrice)
+	}
+	if minPerKg, ok, err := parseFloat("min_price_per_kg"); err != nil {
+		return nil, err
+	} else if ok {
+		add("price_per_kg", "products.price_per_kg >= ?", minPerKg)
+	}
+	if maxPerKg, ok, err := parseFloat("max_price_per_kg"); err != nil {
+		return nil, err
+	} else if ok {
+		add("price_per_kg", "products.price_per_kg <= ?", maxPerKg)
+	}
+	if minQuantity, ok, err := parseFloat("min_quantity"); err != nil {
+		return nil, err
+	} else if ok {
+		add("quantity", "products.quantity >= ?", minQuantity)
+	}
+
+	// Availability window: listings available at some point between the dates.
+	// A listing without an available_from or available_to is open on that end.
+	if value := c.Query("available_from"); value != "" {
+		from, err := models.ParseDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid available_from: %s", value)
+		}
+		add("availability", "(products.available_to IS NULL OR products.available_to >= ?)", from)
+	}
+	if value := c.Query("available_to"); value != "" {
+		to, err := models.ParseDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid available_to: %s", value)
+		}
+		add("availability", "(products.available_from IS NULL OR products.available_from <= ?)", to)
+	}
+
+	return filters, nil
+}
+
+// applyProductFilters applies every filter except those on the skipped dimension
+func applyProductFilters(filters []productFilter, skip string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, f := range filters {
+			if f.dimension != skip {
+				db = f.apply(db)
+			}
+		}
+		return db
+	}
+}
+
+// productFacet counts products per value of column, applying all filters
+// except the facet's own so clients can show alternative choices
+func productFacet(filters []productFilter, dimension, column string) ([]FacetCount, error) {
+	counts := []FacetCount{}
+	err := database.DB.Model(&models.Product{}).
+		Joins(productUsersJoin).
+		Where(publicProductsSQL).
+		Scopes(applyProductFilters(filters, dimension)).
+		Select(column + " AS value, COUNT(*) AS count").
+		Where(column + " IS NOT NULL AND " + column + " <> ''").
+		Group(column).
+		Order("count DESC").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// GetAllProducts lists products with filters, sorting, pagination and facet
+// counts per category and farmer district
+// GET /products?category=vegetables&min_price=20&max_price=80&district=Kaski&sort=price_asc&page=1&limit=20
 func GetAllProducts(c *gin.Context) {
+	filters, err := parseProductFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sortKey := c.DefaultQuery("sort", "newest")
+	order, ok := productSortOrders[sortKey]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.invalid_sort", map[string]interface{}{"sort": sortKey})})
+		return
+	}
+
+	query := database.DB.Model(&models.Product{}).
+		Joins(productUsersJoin).
+		Where(publicProductsSQL).
+		Scopes(applyProductFilters(filters, ""))
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.count_failed", nil), "details": err.Error()})
+		return
+	}
+
+	page := c.DefaultQuery("page", "1")
+	limit := c.DefaultQuery("limit", "20")
+
 	var products []models.Product
+	if err := query.Select("products.*").
+		Preload("Variants", orderedVariants).
+		Order(order).Order("products.id DESC").
+		Scopes(Paginate(page, limit)).
+		Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.retrieve_failed", nil), "details": err.Error()})
+		return
+	}
 
-	if err := database.DB.Find(&products).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve products: " + err.Error()})
+	attachReferencePrices(products)
+
+	categoryFacet, err := productFacet(filters, "category", "products.category")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.facets_failed", nil), "details": err.Error()})
+		return
+	}
+	districtFacet, err := productFacet(filters, "district", "users.district")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.facets_failed", nil), "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, products)
+	pageInt, limitInt := paginationValues(page, limit)
+	c.JSON(http.StatusOK, gin.H{
+		"products": products,
+		"meta": gin.H{
+			"total": total,
+			"page":  pageInt,
+			"limit": limitInt,
+			"pages": (total + int64(limitInt) - 1) / int64(limitInt),
+			"sort":  sortKey,
+		},
+		"facets": gin.H{
+			"category": categoryFacet,
+			"district": districtFacet,
+		},
+	})
 }
 
-// SearchProducts searches for products based on query parameters
+// ProductHighlight holds product fields with matched words wrapped in <mark>
+type ProductHighlight struct {
+	NameEn        string `json:"name_en"`
+	NameNp        string `json:"name_np"`
+	DescriptionEn string `json:"description_en"`
+	DescriptionNp string `json:"description_np"`
+}
+
+// ProductSearchResult is a product matched by SearchProducts
+type ProductSearchResult struct {
+	models.Product
+	Rank       float64          `json:"rank"`
+	Highlight  ProductHighlight `json:"highlight" gorm:"embedded;embeddedPrefix:hl_"`
+	TotalCount int64            `json:"-"`
+}
+
+// fuzzyThreshold is the pg_trgm word similarity above which a name is
+// considered a typo of the query
+const fuzzyThreshold = "0.4"
+
+// productSearchSQL ranks products by full-text relevance across English and
+// Nepali fields plus trigram similarity of the names to any spelling of the
+// query, for typo tolerance
+const productSearchSQL = `
+WITH q AS (
+	SELECT to_tsquery('english', @tsquery) || to_tsquery('simple', @tsquery) AS query
+)
+SELECT products.*,
+	ts_rank(` + search.ProductVector + `, q.query)
+		+ coalesce((SELECT max(greatest(word_similarity(a, coalesce(name_en, '')), word_similarity(a, coalesce(name_np, ''))))
+			FROM unnest(CAST(@alternatives AS text[])) AS a), 0) AS rank,
+	ts_headline('english', coalesce(name_en, ''), q.query, @name_opts) AS hl_name_en,
+	ts_headline('simple', coalesce(name_np, ''), q.query, @name_opts) AS hl_name_np,
+	ts_headline('english', coalesce(description_en, ''), q.query, @description_opts) AS hl_description_en,
+	ts_headline('simple', coalesce(description_np, ''), q.query, @description_opts) AS hl_description_np,
+	COUNT(*) OVER() AS total_count
+FROM products, q
+WHERE products.deleted_at IS NULL
+	AND ` + publicProductsSQL + `
+	AND (` + search.ProductVector + ` @@ q.query
+		OR @plain <% coalesce(name_en, '')
+		OR @plain <% coalesce(name_np, '')
+		OR EXISTS (SELECT 1 FROM unnest(CAST(@alternatives AS text[])) AS a
+			WHERE a <% coalesce(name_en, '') OR a <% coalesce(name_np, '')))
+ORDER BY rank DESC, products.id DESC
+LIMIT @limit OFFSET @offset`
+
+// SearchProducts performs a ranked, typo tolerant full-text search across the
+// English and Nepali names and descriptions. Queries are expanded with produce
+// synonyms and romanized/Devanagari transliterations.
+// GET /products/search?q=tomato&page=1&limit=20
 func SearchProducts(c *gin.Context) {
 	query := c.Query("q")
 	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query parameter 'q' is required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.search_query_required", nil)})
 		return
 	}
 
-	var products []models.Product
-	searchQuery := "%" + strings.ToLower(query) + "%"
+	expanded := search.Build(query)
+	if len(expanded.Terms) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.search_query_empty", nil)})
+		return
+	}
 
-	if err := database.DB.Where("LOWER(name) LIKE ? OR LOWER(description) LIKE ?", searchQuery, searchQuery).Find(&products).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search products: " + err.Error()})
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page <= 0 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	var results []ProductSearchResult
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// SET does not accept bind parameters; set_config(..., true) is SET LOCAL
+		if err := tx.Exec("SELECT set_config('pg_trgm.word_similarity_threshold', ?, true)", fuzzyThreshold).Error; err != nil {
+			return err
+		}
+		return tx.Raw(productSearchSQL, map[string]interface{}{
+			"tsquery":          expanded.TSQuery,
+			"plain":            strings.Join(expanded.Terms, " "),
+			"alternatives":     expanded.Alternatives,
+			"name_opts":        "StartSel=<mark>, StopSel=</mark>, HighlightAll=true",
+			"description_opts": "StartSel=<mark>, StopSel=</mark>, MaxWords=25, MinWords=8, MaxFragments=2",
+			"limit":            limit,
+			"offset":           (page - 1) * limit,
+		}).Scan(&results).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.search_failed", nil), "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, products)
+	var total int64
+	if len(results) > 0 {
+		total = results[0].TotalCount
+	}
+
+	// Variants are listed under their listing
+	listings := make([]*models.Product, len(results))
+	for i := range results {
+		listings[i] = &results[i].Product
+	}
+	attachVariants(listings)
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":   query,
+		"results": results,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+}
+
+// ProductDistance is a product found by GetNearbyProducts
+type ProductDistance struct {
+	models.Product
+	DistanceKm float64 `json:"distance_km"`
+	TotalCount int64   `json:"-"`
+}
+
+const (
+	defaultNearbyRadiusKm = 30.0
+	maxNearbyRadiusKm     = 500.0
+)
+
+// nearbyProductsSQL locates each product by its farmer's most recent
+// geocoded profile. The bounding box lets the planner skip distant farms
+// before computing great-circle distances.
+const nearbyProductsSQL = `
+SELECT *, COUNT(*) OVER() AS total_count FROM (
+	SELECT products.*,
+		6371 * 2 * asin(sqrt(
+			power(sin(radians(fp.latitude - @lat) / 2), 2)
+			+ cos(radians(@lat)) * cos(radians(fp.latitude)) * power(sin(radians(fp.longitude - @lon) / 2), 2)
+		)) AS distance_km
+	FROM products
+	JOIN LATERAL (
+		SELECT latitude, longitude FROM farmer_profiles
+		WHERE farmer_profiles.user_id = products.user_id
+			AND farmer_profiles.deleted_at IS NULL
+			AND farmer_profiles.latitude IS NOT NULL
+			AND farmer_profiles.longitude IS NOT NULL
+		ORDER BY farmer_profiles.id DESC
+		LIMIT 1
+	) fp ON true
+	WHERE products.deleted_at IS NULL
+		AND ` + publicProductsSQL + `
+		AND fp.latitude BETWEEN @min_lat AND @max_lat
+		AND fp.longitude BETWEEN @min_lon AND @max_lon
+) nearby
+WHERE distance_km <= @radius
+ORDER BY distance_km, id DESC
+LIMIT @limit OFFSET @offset`
+
+// GetNearbyProducts lists published products whose farms lie within
+// radius_km of the given point, nearest first. Farms are located from the
+// coordinates on the farmer profile.
+// GET /products/nearby?lat=27.7&lon=85.3&radius_km=30
+func GetNearbyProducts(c *gin.Context) {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lon, errLon := strconv.ParseFloat(c.Query("lon"), 64)
+	if errLat != nil || errLon != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.coordinates_required", nil)})
+		return
+	}
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.coordinates_out_of_range", nil)})
+		return
+	}
+
+	radius := defaultNearbyRadiusKm
+	if value := c.Query("radius_km"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed > maxNearbyRadiusKm {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.invalid_radius", map[string]interface{}{"max": maxNearbyRadiusKm})})
+			return
+		}
+		radius = parsed
+	}
+
+	center := geo.Point{Latitude: lat, Longitude: lon}
+	minLat, maxLat, minLon, maxLon := geo.BoundingBox(center, radius)
+	page, limit := paginationValues(c.DefaultQuery("page", "1"), c.DefaultQuery("limit", "20"))
+
+	var results []ProductDistance
+	if err := database.DB.Raw(nearbyProductsSQL, map[string]interface{}{
+		"lat":     lat,
+		"lon":     lon,
+		"radius":  radius,
+		"min_lat": minLat,
+		"max_lat": maxLat,
+		"min_lon": minLon,
+		"max_lon": maxLon,
+		"limit":   limit,
+		"offset":  (page - 1) * limit,
+	}).Scan(&results).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.nearby_failed", nil), "details": err.Error()})
+		return
+	}
+
+	var total int64
+	if len(results) > 0 {
+		total = results[0].TotalCount
+	}
+
+	listings := make([]*models.Product, len(results))
+	for i := range results {
+		listings[i] = &results[i].Product
+	}
+	attachVariants(listings)
+
+	c.JSON(http.StatusOK, gin.H{
+		"products":  results,
+		"radius_km": radius,
+		"page":      page,
+		"limit":     limit,
+		"total":     total,
+	})
 }
 
 // UpdateProduct updates an existing product with optional image update
@@ -141,69 +592,93 @@ func UpdateProduct(c *gin.Context) {
 
 	// Find existing product
 	if err := database.DB.First(&existingProduct, productID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found: " + err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "product.not_found", nil), "details": err.Error()})
 		return
 	}
 
 	// Initialize product with existing values
+	previousProduct := existingProduct
 	updatedProduct := existingProduct
+	var upload *media.Upload
 
 	// Check if this is a multipart form (for image upload)
 	if c.ContentType() == "multipart/form-data" {
 		// Get JSON data from form field
 		jsonData := c.PostForm("data")
 		if jsonData == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing product data"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.missing_data", nil)})
 			return
 		}
 
 		if err := json.Unmarshal([]byte(jsonData), &updatedProduct); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product data JSON: " + err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.invalid_data", nil), "details": err.Error()})
 			return
 		}
 
-		// Handle image upload if provided
-		file, err := c.FormFile("image")
-		if err == nil {
-			// Validate new image
-			if err := validateImageFile(file); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-				return
-			}
-
-			// Save new image
-			ext := filepath.Ext(file.Filename)
-			filename := fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), strings.TrimSuffix(filepath.Base(file.Filename), ext), ext)
-			savePath := filepath.Join(uploadsDir, filename)
-
-			if err := c.SaveUploadedFile(file, savePath); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image: " + err.Error()})
+		// A new image replaces the primary image once the update is valid
+		if file, err := c.FormFile("image"); err == nil {
+			if upload, err = media.ValidateImage(c.Request.Context(), file); err != nil {
+				c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
 				return
 			}
-
-			// Delete old image if it exists
-			if existingProduct.ImageURL != "" {
-				oldPath := strings.TrimPrefix(existingProduct.ImageURL, "/")
-				if err := os.Remove(oldPath); err != nil && !os.IsNotExist(err) {
-					fmt.Printf("Failed to remove old image: %v\n", err)
-				}
-			}
-
-			updatedProduct.ImageURL = "/" + filepath.ToSlash(savePath)
 		}
 	} else {
 		// Regular JSON request
 		if err := c.ShouldBindJSON(&updatedProduct); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.invalid_input", nil), "details": err.Error()})
 			return
 		}
 	}
 
-	// Update product fields
-	if err := database.DB.Model(&existingProduct).Updates(updatedProduct).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product: " + err.Error()})
+	// Variants are managed by the variants endpoints and the harvest is
+	// confirmed through its own endpoint, after which it no longer changes
+	updatedProduct.Variants = nil
+	updatedProduct.HarvestConfirmedAt = existingProduct.HarvestConfirmedAt
+	if existingProduct.Forward() && !existingProduct.AwaitingHarvest() {
+		updatedProduct.HarvestDate = existingProduct.HarvestDate
+		updatedProduct.YieldMin, updatedProduct.YieldMax = existingProduct.YieldMin, existingProduct.YieldMax
+	}
+
+	// A new free-text category replaces the linked one
+	if updatedProduct.Category != existingProduct.Category && sameID(updatedProduct.CategoryID, existingProduct.CategoryID) {
+		updatedProduct.CategoryID = nil
+	}
+	if err := prepareProduct(&updatedProduct); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := resolveProductStatus(existingProduct.Status, &updatedProduct); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The gallery and primary image URLs are managed by the images endpoints
+	updatedProduct.Images = nil
+	updatedProduct.ImageURL, updatedProduct.ThumbnailURL = "", ""
+
+	// Save, replace the image and moderate together so an edit is never
+	// live without review
+	var added []models.ProductImage
+	removeReplaced := func() {}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := saveProductUpdate(tx, &existingProduct, updatedProduct); err != nil {
+			return err
+		}
+		if upload != nil {
+			var err error
+			if added, removeReplaced, err = replacePrimaryImage(tx, previousProduct, upload); err != nil {
+				return err
+			}
+		}
+		return moderateListingUpdate(c, tx, previousProduct, &existingProduct, imageChange(upload != nil))
+	})
+	if err != nil {
+		removeImageFiles(added)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.update_failed", nil), "details": err.Error()})
 		return
 	}
+	removeReplaced()
+	recordPriceChange(previousProduct)
 
 	c.JSON(http.StatusOK, existingProduct)
 }
@@ -214,53 +689,275 @@ func PartialUpdateProduct(c *gin.Context) {
 	var existingProduct models.Product
 
 	if err := database.DB.First(&existingProduct, productID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found: " + err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "product.not_found", nil), "details": err.Error()})
 		return
 	}
 
 	var updates map[string]interface{}
 	if err := c.ShouldBindJSON(&updates); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "product.invalid_input", nil), "details": err.Error()})
 		return
 	}
 
-	// Handle image upload if provided
-	file, err := c.FormFile("image")
-	if err == nil {
-		// Validate new image
-		if err := validateImageFile(file); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
+	// The gallery, variants and harvest are managed by their own endpoints
+	delete(updates, "images")
+	delete(updates, "variants")
+	delete(updates, "harvest_confirmed_at")
+	delete(updates, "image_url")
+	delete(updates, "thumbnail_url")
+
+	if err := applyPartialListingUpdate(existingProduct, updates); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applyPartialHarvestUpdate(existingProduct, updates); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applyPartialAvailabilityUpdate(existingProduct, updates); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previousProduct := existingProduct
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&existingProduct).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := syncVariantTotals(tx, &existingProduct); err != nil {
+			return err
 		}
+		return moderateListingUpdate(c, tx, previousProduct, &existingProduct, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.update_failed", nil), "details": err.Error()})
+		return
+	}
+	recordPriceChange(previousProduct)
 
-		// Save new image
-		ext := filepath.Ext(file.Filename)
-		filename := fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), strings.TrimSuffix(filepath.Base(file.Filename), ext), ext)
-		savePath := filepath.Join(uploadsDir, filename)
+	c.JSON(http.StatusOK, existingProduct)
+}
 
-		if err := c.SaveUploadedFile(file, savePath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image: " + err.Error()})
-			return
+// saveProductUpdate writes the fields of updated to existing. Updates skips
+// zero values, so perishability, the normalized price, the status and
+// quantity are written separately. Listings with variants keep the quantity
+// and price of their variants.
+func saveProductUpdate(db *gorm.DB, existing *models.Product, updated models.Product) error {
+	availableTo := existing.AvailableTo
+	if err := db.Model(existing).Updates(updated).Error; err != nil {
+		return err
+	}
+	fields := map[string]interface{}{
+		"perishable":   updated.Perishable,
+		"price_per_kg": updated.PricePerKg,
+		"status":       updated.Status,
+		"quantity":     updated.Quantity,
+	}
+	if !updated.AvailableTo.Equal(availableTo.Time) {
+		fields["expiry_notified_at"] = nil
+	}
+	if err := db.Model(existing).Updates(fields).Error; err != nil {
+		return err
+	}
+	return syncVariantTotals(db, existing)
+}
+
+// replacePrimaryImage adds an image as the new primary image within tx and
+// deletes the previous primary image. It returns the added images, whose
+// files are removed if tx rolls back, and a function removing the files of
+// the previous image once tx commits.
+func replacePrimaryImage(tx *gorm.DB, product models.Product, upload *media.Upload) ([]models.ProductImage, func(), error) {
+	var previous []models.ProductImage
+	if err := tx.Where("product_id = ? AND is_primary", product.ID).Find(&previous).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(previous) > 0 {
+		if err := tx.Unscoped().Delete(&previous).Error; err != nil {
+			return nil, nil, err
 		}
+	}
+	added, err := addProductImages(tx, product.ID, []*media.Upload{upload}, true)
+	removePrevious := func() {
+		removeImageFiles(previous)
+		if len(previous) == 0 && product.ImageURL != "" {
+			media.RemoveFiles(product.ImageURL)
+		}
+	}
+	return added, removePrevious, err
+}
 
-		// Delete old image if it exists
-		if existingProduct.ImageURL != "" {
-			oldPath := strings.TrimPrefix(existingProduct.ImageURL, "/")
-			if err := os.Remove(oldPath); err != nil && !os.IsNotExist(err) {
-				// Log but don't fail the operation
-				fmt.Printf("Failed to remove old image: %v\n", err)
-			}
+// recordPriceChange adds the saved price of a product to its price history
+// when an update changed it
+func recordPriceChange(previous models.Product) {
+	var product models.Product
+	if err := database.DB.First(&product, previous.ID).Error; err != nil {
+		log.Printf("Failed to load product %d for price history: %v", previous.ID, err)
+		return
+	}
+	if !market.PriceChanged(previous, product) {
+		return
+	}
+	if err := market.RecordPrice(database.DB, product); err != nil {
+		log.Printf("Failed to record price of product %d: %v", product.ID, err)
+	}
+}
+
+// prepareProduct validates the unit against the registry, applies the
+// category defaults and normalizes the price to a price per kilogram
+func prepareProduct(product *models.Product) error {
+	if product.Unit != "" {
+		code, err := units.Normalize(product.Unit)
+		if err != nil {
+			return err
 		}
+		product.Unit = code
+	}
 
-		updates["image_url"] = "/" + filepath.ToSlash(savePath)
+	defaults, err := applyCategoryDefaults(product)
+	if err != nil {
+		return err
 	}
 
-	if err := database.DB.Model(&existingProduct).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product: " + err.Error()})
-		return
+	if len(product.Variants) > 0 {
+		if err := prepareVariants(product, defaults.KgPerLitre); err != nil {
+			return err
+		}
 	}
+	if err := prepareForwardListing(product); err != nil {
+		return err
+	}
+	product.PricePerKg = unitPricePerKg(product.Unit, product.PricePerUnit, defaults.KgPerLitre)
+	return nil
+}
 
-	c.JSON(http.StatusOK, existingProduct)
+// unitPricePerKg normalizes a price per unit to a price per kilogram, or
+// returns nil when the unit does not convert to a weight
+func unitPricePerKg(unit string, price, kgPerLitre float64) *float64 {
+	if u, ok := units.Lookup(unit); ok {
+		if perKg, ok := units.PricePerKg(price, u, kgPerLitre); ok {
+			perKg = math.Round(perKg*100) / 100
+			return &perKg
+		}
+	}
+	return nil
+}
+
+// applyPartialListingUpdate re-validates the listing when a partial update
+// changes the category, unit or price
+func applyPartialListingUpdate(existing models.Product, updates map[string]interface{}) error {
+	_, categoryChanged := updates["category"]
+	_, categoryIDChanged := updates["category_id"]
+	_, unitChanged := updates["unit"]
+	_, priceChanged := updates["price_per_unit"]
+	if !categoryChanged && !categoryIDChanged && !unitChanged && !priceChanged {
+		return nil
+	}
+
+	product := existing
+	if value, ok := updates["category"].(string); ok {
+		product.Category = value
+		product.CategoryID = nil
+	}
+	if categoryIDChanged {
+		id, ok := updates["category_id"].(float64)
+		if !ok || id <= 0 {
+			return fmt.Errorf("invalid category_id")
+		}
+		categoryID := uint(id)
+		product.CategoryID = &categoryID
+	}
+	if value, ok := updates["unit"].(string); ok {
+		product.Unit = value
+	}
+	if priceChanged {
+		price, ok := updates["price_per_unit"].(float64)
+		if !ok || price < 0 {
+			return fmt.Errorf("invalid price_per_unit")
+		}
+		product.PricePerUnit = price
+	}
+
+	if err := prepareProduct(&product); err != nil {
+		return err
+	}
+
+	updates["category_id"] = product.CategoryID
+	updates["category"] = product.Category
+	updates["unit"] = product.Unit
+	updates["perishable"] = product.Perishable
+	updates["price_per_kg"] = product.PricePerKg
+	return nil
+}
+
+// applyPartialAvailabilityUpdate parses the availability dates of a partial
+// update and validates and recomputes the status when the dates, quantity or
+// status change
+func applyPartialAvailabilityUpdate(existing models.Product, updates map[string]interface{}) error {
+	product := existing
+	changed := false
+	for key, date := range map[string]*models.Date{
+		"available_from": &product.AvailableFrom,
+		"available_to":   &product.AvailableTo,
+	} {
+		value, ok := updates[key]
+		if !ok {
+			continue
+		}
+		text, isString := value.(string)
+		if value != nil && !isString {
+			return fmt.Errorf("invalid %s, expected YYYY-MM-DD", key)
+		}
+		parsed, err := models.ParseDate(text)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		*date = parsed
+		updates[key] = parsed
+		changed = true
+	}
+	if _, ok := updates["available_to"]; ok {
+		updates["expiry_notified_at"] = nil
+	}
+	if value, ok := updates["quantity"]; ok {
+		quantity, ok := value.(float64)
+		if !ok || quantity < 0 {
+			return fmt.Errorf("invalid quantity")
+		}
+		product.Quantity = quantity
+		changed = true
+	}
+	// Keys are column or field names, so "Status" sets the status as well
+	for key, value := range updates {
+		if !strings.EqualFold(key, "status") {
+			continue
+		}
+		status, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("invalid status")
+		}
+		delete(updates, key)
+		product.Status = models.ProductStatus(status)
+		changed = true
+	}
+
+	if changed {
+		if err := resolveProductStatus(existing.Status, &product); err != nil {
+			return err
+		}
+		updates["status"] = product.Status
+		if product.Quantity != existing.Quantity {
+			updates["quantity"] = product.Quantity
+		}
+	}
+	return nil
+}
+
+// sameID reports whether two optional IDs are equal
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
 
 // DeleteProduct deletes a product by its ID
@@ -269,32 +966,29 @@ func DeleteProduct(c *gin.Context) {
 	var product models.Product
 
 	if err := database.DB.First(&product, productID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found: " + err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "product.not_found", nil), "details": err.Error()})
 		return
 	}
 
-	// Delete associated image if it exists
-	if product.ImageURL != "" {
-		imagePath := strings.TrimPrefix(product.ImageURL, "/")
-		if err := os.Remove(imagePath); err != nil && !os.IsNotExist(err) {
-			// Log but don't fail the operation
-			fmt.Printf("Failed to remove product image: %v\n", err)
-		}
+	// Delete the gallery with all image variants
+	if err := deleteProductImages(database.DB, product); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.delete_images_failed", nil), "details": err.Error()})
+		return
 	}
 
 	if err := database.DB.Delete(&product).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.delete_failed", nil), "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "product.deleted", nil)})
 }
 
 // GetProductsByUserID retrieves all products created by a specific user
 func GetProductsByUserID(c *gin.Context) {
 	userIDVal, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.Tc(c, "error.unauthenticated", nil)})
 		return
 	}
 
@@ -306,16 +1000,17 @@ func GetProductsByUserID(c *gin.Context) {
 	case float64:
 		userID = uint(v)
 	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "error.invalid_user_id", nil)})
 		return
 	}
 
 	var products []models.Product
-	if err := database.DB.Where("user_id = ?", userID).Find(&products).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user's products: " + err.Error()})
+	if err := database.DB.Preload("Variants", orderedVariants).Where("user_id = ?", userID).Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.user_retrieve_failed", nil), "details": err.Error()})
 		return
 	}
 
+	attachReferencePrices(products)
 	c.JSON(http.StatusOK, products)
 }
 
@@ -324,7 +1019,7 @@ func GetUserProductByID(c *gin.Context) {
 	productID := c.Param("id")
 	userIDVal, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.Tc(c, "error.unauthenticated", nil)})
 		return
 	}
 
@@ -336,59 +1031,21 @@ func GetUserProductByID(c *gin.Context) {
 	case float64:
 		userID = uint(v)
 	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID format"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "error.invalid_user_id", nil)})
 		return
 	}
 
 	var product models.Product
-	if err := database.DB.Where("id = ? AND user_id = ?", productID, userID).First(&product).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found or not owned by user"})
+	if err := database.DB.Preload("Images", orderedImages).Preload("Variants", orderedVariants).
+		Where("id = ? AND user_id = ?", productID, userID).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "product.not_owned", nil)})
 		return
 	}
 
+	attachReferencePrice(&product)
 	c.JSON(http.StatusOK, product)
 }
 
-// UpdateProductStatus updates the status of a product
-func UpdateProductStatus(c *gin.Context) {
-	productID := c.Param("id")
-	var existingProduct models.Product
-
-	if err := database.DB.First(&existingProduct, productID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found: " + err.Error()})
-		return
-	}
-
-	var statusUpdate struct {
-		Status string `json:"status"`
-	}
-	if err := c.ShouldBindJSON(&statusUpdate); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status data: " + err.Error()})
-		return
-	}
-
-	// Validate status value
-	validStatuses := []string{"active", "inactive", "sold", "pending"}
-	valid := false
-	for _, s := range validStatuses {
-		if statusUpdate.Status == s {
-			valid = true
-			break
-		}
-	}
-	if !valid {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status value"})
-		return
-	}
-
-	if err := database.DB.Model(&existingProduct).Update("status", statusUpdate.Status).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product status: " + err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, existingProduct)
-}
-
 // AdminGetAllProducts retrieves all products (admin only)
 func AdminGetAllProducts(c *gin.Context) {
 	var products []models.Product
@@ -398,7 +1055,7 @@ func AdminGetAllProducts(c *gin.Context) {
 	limit := c.DefaultQuery("limit", "20")
 
 	if err := database.DB.Scopes(Paginate(page, limit)).Find(&products).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve products: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.retrieve_failed", nil), "details": err.Error()})
 		return
 	}
 
@@ -411,85 +1068,47 @@ func AdminDeleteProduct(c *gin.Context) {
 	var product models.Product
 
 	if err := database.DB.First(&product, productID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found: " + err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "product.not_found", nil), "details": err.Error()})
 		return
 	}
 
-	// Delete associated image if it exists
-	if product.ImageURL != "" {
-		imagePath := strings.TrimPrefix(product.ImageURL, "/")
-		if err := os.Remove(imagePath); err != nil && !os.IsNotExist(err) {
-			// Log but don't fail the operation
-			fmt.Printf("Failed to remove product image: %v\n", err)
-		}
+	// Delete the gallery with all image variants
+	if err := deleteProductImages(database.DB, product); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.delete_images_failed", nil), "details": err.Error()})
+		return
 	}
 
 	if err := database.DB.Delete(&product).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete product: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "product.delete_failed", nil), "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully by admin"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "product.deleted_by_admin", nil)})
 }
 
 // Paginate is a scope for pagination
 func Paginate(page, limit string) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
-		pageInt, _ := strconv.Atoi(page)
-		if pageInt <= 0 {
-			pageInt = 1
-		}
-
-		limitInt, _ := strconv.Atoi(limit)
-		switch {
-		case limitInt > 100:
-			limitInt = 100
-		case limitInt <= 0:
-			limitInt = 20
-		}
-
+		pageInt, limitInt := paginationValues(page, limit)
 		offset := (pageInt - 1) * limitInt
 		return db.Offset(offset).Limit(limitInt)
 	}
 }
 
-// validateImageFile checks if the uploaded file is a valid image
-func validateImageFile(file *multipart.FileHeader) error {
-	// Check file size
-	if file.Size > maxUploadSize {
-		return fmt.Errorf("image too large. Maximum size is %dMB", maxUploadSize/(1024*1024))
+// paginationValues parses page and limit, applying defaults and the maximum limit
+func paginationValues(page, limit string) (int, int) {
+	pageInt, _ := strconv.Atoi(page)
+	if pageInt <= 0 {
+		pageInt = 1
 	}
 
-	// Check content type
-	buffer := make([]byte, 512)
-	f, err := file.Open()
-	if err != nil {
-		return fmt.Errorf("failed to open image file")
+	limitInt, _ := strconv.Atoi(limit)
+	switch {
+	case limitInt > 100:
+		limitInt = 100
+	case limitInt <= 0:
+		limitInt = 20
 	}
-	defer f.Close()
 
-	if _, err = f.Read(buffer); err != nil {
-		return fmt.Errorf("failed to read image file")
-	}
-
-	contentType := http.DetectContentType(buffer)
-	if _, err := mime.ExtensionsByType(contentType); err != nil {
-		return fmt.Errorf("invalid image type")
-	}
-
-	// Check file extension
-	ext := filepath.Ext(file.Filename)
-	allowedExts := []string{".jpg", ".jpeg", ".png", ".gif"}
-	valid := false
-	for _, allowedExt := range allowedExts {
-		if strings.EqualFold(ext, allowedExt) {
-			valid = true
-			break
-		}
-	}
-	if !valid {
-		return fmt.Errorf("invalid file extension. Allowed: %v", allowedExts)
-	}
-
-	return nil
+	return pageInt, limitInt
 }
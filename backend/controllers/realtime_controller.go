@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"agro-connect/database"
+	"agro-connect/models"
+	"agro-connect/realtime"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const heartbeatInterval = 25 * time.Second
+
+// StreamEvents pushes notifications, offer and order updates to the
+// authenticated user over Server-Sent Events.
+// GET /realtime?token=<jwt>
+// Reconnecting clients send the Last-Event-ID header (or last_event_id query)
+// to receive events they missed while disconnected.
+func StreamEvents(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Streaming not supported"})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	resumeFrom, _ := strconv.ParseUint(lastEventID, 10, 64)
+
+	events, missed, unsubscribe := realtime.DefaultBroker.Subscribe(userID.(uint), resumeFrom)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	c.Status(http.StatusOK)
+
+	// Tell the browser how long to wait before reconnecting
+	fmt.Fprint(c.Writer, "retry: 5000\n\n")
+	for _, event := range missed {
+		writeEvent(c, event)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			writeEvent(c, event)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent serializes an event in the SSE wire format
+func writeEvent(c *gin.Context, event realtime.Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, payload)
+}
+
+// publishOfferUpdate pushes the current state of an offer to its buyer and
+// the farmer who owns the product
+func publishOfferUpdate(offer models.Offer) {
+	realtime.Publish(offer.BuyerID, realtime.EventOfferUpdated, offer)
+
+	var product models.Product
+	if err := database.DB.Select("user_id").First(&product, offer.ProductID).Error; err == nil {
+		realtime.Publish(product.UserID, realtime.EventOfferUpdated, offer)
+	}
+}
+
+// publishOrderUpdate pushes the current state of an order to both parties
+func publishOrderUpdate(order models.Order) {
+	realtime.Publish(order.BuyerID, realtime.EventOrderUpdated, order)
+	if order.FarmerID != order.BuyerID {
+		realtime.Publish(order.FarmerID, realtime.EventOrderUpdated, order)
+	}
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/market"
+	"agro-connect/models"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxPriceSheetSize limits uploaded price sheets
+const maxPriceSheetSize = 5 * 1024 * 1024 // 5MB
+
+// GetReferencePrices lists wholesale reference prices of one day, the
+// latest imported day by default
+// GET /market/reference-prices?market=kalimati&date=2024-01-31&produce=tomato&category=vegetable
+func GetReferencePrices(c *gin.Context) {
+	query := database.DB.Model(&models.ReferencePrice{})
+	if m := c.Query("market"); m != "" {
+		query = query.Where("market = ?", strings.ToLower(m))
+	}
+	if produce := c.Query("produce"); produce != "" {
+		if canonical := market.ProduceOf(produce); canonical != "" {
+			produce = canonical
+		}
+		query = query.Where("produce = ?", strings.ToLower(produce))
+	}
+	if category := c.Query("category"); category != "" {
+		if found, err := database.FindCategoryByName(category); err == nil && found != nil {
+			query = query.Where("category_id IN ("+categorySubtreeSQL+")", found.ID)
+		} else {
+			query = query.Where("LOWER(category) = LOWER(?)", category)
+		}
+	}
+
+	date := c.Query("date")
+	if date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "price.invalid_date", nil)})
+			return
+		}
+	} else {
+		var latest *time.Time
+		if err := query.Session(&gorm.Session{}).Select("MAX(date)").Scan(&latest).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "price.retrieve_failed", nil)})
+			return
+		}
+		if latest == nil {
+			c.JSON(http.StatusOK, gin.H{"date": nil, "prices": []models.ReferencePrice{}})
+			return
+		}
+		date = latest.Format("2006-01-02")
+	}
+
+	var prices []models.ReferencePrice
+	if err := query.Where("date = ?", date).Order("market, commodity").Find(&prices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "price.retrieve_failed", nil)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"date": date, "prices": prices})
+}
+
+// ImportReferencePrices imports a daily wholesale price sheet
+// POST /admin/reference-prices/import (multipart: file, market, date)
+func ImportReferencePrices(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "price.file_required", nil)})
+		return
+	}
+	if file.Size > maxPriceSheetSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "price.file_too_large", nil)})
+		return
+	}
+
+	var date time.Time
+	if value := c.PostForm("date"); value != "" {
+		if date, err = time.Parse("2006-01-02", value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "price.invalid_date", nil)})
+			return
+		}
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "price.read_failed", nil)})
+		return
+	}
+	defer f.Close()
+
+	result, err := market.ImportReferencePrices(f, file.Filename, c.PostForm("market"), date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetCommodityMappings lists the commodity mappings and the commodities of
+// stored reference prices that match no produce
+// GET /admin/reference-prices/mappings
+func GetCommodityMappings(c *gin.Context) {
+	var mappings []models.CommodityMapping
+	if err := database.DB.Order("commodity").Find(&mappings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "price.mappings_retrieve_failed", nil)})
+		return
+	}
+
+	var unmapped []string
+	if err := database.DB.Model(&models.ReferencePrice{}).Where("produce = ''").
+		Distinct("commodity").Order("commodity").Pluck("commodity", &unmapped).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "price.unmapped_retrieve_failed", nil)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mappings": mappings, "unmapped": unmapped})
+}
+
+// CommodityMappingInput maps a commodity to a category and produce
+type CommodityMappingInput struct {
+	Commodity  string `json:"commodity" binding:"required"`
+	CategoryID *uint  `json:"category_id"`
+	Produce    string `json:"produce"`
+}
+
+// SaveCommodityMapping creates or replaces the mapping of a commodity and
+// applies it to the stored reference prices
+// PUT /admin/reference-prices/mappings
+// {"commodity": "Tomato Big(Nepali)", "category_id": 9, "produce": "tomato"}
+func SaveCommodityMapping(c *gin.Context) {
+	var input CommodityMappingInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.CategoryID != nil {
+		var category models.Category
+		if err := database.DB.First(&category, *input.CategoryID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "category.not_found", nil)})
+			return
+		}
+	}
+
+	produce := strings.ToLower(strings.TrimSpace(input.Produce))
+	if canonical := market.ProduceOf(produce); canonical != "" {
+		produce = canonical
+	}
+
+	commodity := market.NormalizeCommodity(input.Commodity)
+	var mapping models.CommodityMapping
+	database.DB.Where("commodity = ?", commodity).First(&mapping)
+	mapping.Commodity = commodity
+	mapping.CategoryID = input.CategoryID
+	mapping.Produce = produce
+	if err := database.DB.Save(&mapping).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "price.mapping_save_failed", nil)})
+		return
+	}
+
+	remapReferencePrices()
+	c.JSON(http.StatusOK, mapping)
+}
+
+// DeleteCommodityMapping removes a mapping; its commodity falls back to the
+// synonym dictionary
+// DELETE /admin/reference-prices/mappings/:id
+func DeleteCommodityMapping(c *gin.Context) {
+	if err := database.DB.Unscoped().Delete(&models.CommodityMapping{}, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "price.mapping_delete_failed", nil)})
+		return
+	}
+
+	remapReferencePrices()
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "price.mapping_deleted", nil)})
+}
+
+func remapReferencePrices() {
+	if err := market.RemapReferencePrices(); err != nil {
+		log.Println("Failed to remap reference prices:", err)
+	}
+}
+
+// attachReferencePrices adds the market reference price to listings
+func attachReferencePrices(products []models.Product) {
+	if err := market.AttachReferencePrices(products); err != nil {
+		log.Println("Failed to load reference prices:", err)
+	}
+}
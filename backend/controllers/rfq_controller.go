@@ -0,0 +1,797 @@
+package controllers
+
+import (
+	"agro-connect/availability"
+	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/models"
+	"agro-connect/notify"
+	"agro-connect/units"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errRFQNotOpen is returned when a request no longer takes bids or awards
+var errRFQNotOpen = errors.New("this request is no longer open")
+
+// awardError is a problem with the awards a buyer asked for, already
+// translated for the request
+type awardError string
+
+func (e awardError) Error() string { return string(e) }
+
+// RFQInput is a buyer's request for quotation
+type RFQInput struct {
+	Title            string      `json:"title" binding:"required"`
+	Description      string      `json:"description"`
+	CategoryID       *uint       `json:"category_id"`
+	Category         string      `json:"category"`
+	Quantity         float64     `json:"quantity" binding:"required,gt=0"`
+	Unit             string      `json:"unit" binding:"required"`
+	MaxPricePerUnit  *float64    `json:"max_price_per_unit"`
+	DeliveryLocation string      `json:"delivery_location" binding:"required"`
+	District         string      `json:"district"`
+	Deadline         models.Date `json:"deadline"`
+}
+
+// BidInput is a farmer's bid on a request for quotation, in the unit of the
+// request
+type BidInput struct {
+	ProductID    uint        `json:"product_id" binding:"required"`
+	VariantID    *uint       `json:"variant_id"`
+	Quantity     float64     `json:"quantity" binding:"required,gt=0"`
+	PricePerUnit float64     `json:"price_per_unit" binding:"required,gt=0"`
+	DeliveryDate models.Date `json:"delivery_date"`
+	Note         string      `json:"note"`
+}
+
+// RFQAward awards a bid, in full or a part of it
+type RFQAward struct {
+	BidID    uint    `json:"bid_id" binding:"required"`
+	Quantity float64 `json:"quantity"` // defaults to the whole bid
+}
+
+// rfqBidView is a bid as the buyer compares it
+type rfqBidView struct {
+	models.RFQBid
+	FarmerName     string  `json:"farmer_name"`
+	FarmerDistrict string  `json:"farmer_district"`
+	Total          float64 `json:"total"`
+	WithinBudget   bool    `json:"within_budget"`
+}
+
+// prepareRFQ validates a request and links it to its category. The unit must
+// be one the category allows.
+func prepareRFQ(c *gin.Context, rfq *models.RFQ) error {
+	code, err := units.Normalize(rfq.Unit)
+	if err != nil {
+		return err
+	}
+	rfq.Unit = code
+
+	if rfq.Deadline.IsZero() {
+		return errors.New(i18n.Tc(c, "rfq.deadline_required", nil))
+	}
+	if rfq.Deadline.Before(availability.Today().Time) {
+		return errors.New(i18n.Tc(c, "rfq.deadline_past", nil))
+	}
+	if rfq.MaxPricePerUnit != nil && *rfq.MaxPricePerUnit <= 0 {
+		return errors.New(i18n.Tc(c, "rfq.invalid_max_price", nil))
+	}
+
+	listing := models.Product{CategoryID: rfq.CategoryID, Category: rfq.Category, Unit: rfq.Unit}
+	if _, err := applyCategoryDefaults(&listing); err != nil {
+		return err
+	}
+	rfq.CategoryID, rfq.Category = listing.CategoryID, listing.Category
+	return nil
+}
+
+// CreateRFQ posts a buyer's demand for produce and tells farmers with
+// listings in its category
+// POST /rfqs {"title": "Red onions", "category": "onion", "quantity": 2000, "unit": "kg", "delivery_location": "Pokhara", "deadline": "2026-11-15"}
+func CreateRFQ(c *gin.Context) {
+	var input RFQInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	rfq := models.RFQ{
+		BuyerID:          userID.(uint),
+		Title:            strings.TrimSpace(input.Title),
+		Description:      input.Description,
+		CategoryID:       input.CategoryID,
+		Category:         input.Category,
+		Quantity:         input.Quantity,
+		Unit:             input.Unit,
+		MaxPricePerUnit:  input.MaxPricePerUnit,
+		DeliveryLocation: strings.TrimSpace(input.DeliveryLocation),
+		District:         strings.TrimSpace(input.District),
+		Deadline:         input.Deadline,
+		Status:           models.RFQOpen,
+	}
+	if rfq.District == "" {
+		var buyer models.User
+		if err := database.DB.Select("district").First(&buyer, rfq.BuyerID).Error; err == nil {
+			rfq.District = buyer.District
+		}
+	}
+	if err := prepareRFQ(c, &rfq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Create(&rfq).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "rfq.post_failed", nil), "details": err.Error()})
+		return
+	}
+
+	go notifyRFQPosted(rfq)
+
+	c.JSON(http.StatusCreated, gin.H{"message": i18n.Tc(c, "rfq.posted", nil), "rfq": rfq})
+}
+
+// GetRFQs lists open requests that still take bids, soonest deadline first.
+// With ?mine=true buyers see their own requests in any status.
+// GET /rfqs?category=vegetables&district=Kaski&unit=kg&page=1&limit=20
+func GetRFQs(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	query := database.DB.Model(&models.RFQ{})
+	if c.Query("mine") == "true" {
+		query = query.Where("buyer_id = ?", userID)
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+	} else {
+		query = query.Where("status = ? AND deadline >= ?", models.RFQOpen, availability.Today())
+	}
+
+	if category := c.Query("category"); category != "" {
+		found, err := database.FindCategoryByName(category)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "rfq.retrieve_failed", nil)})
+			return
+		}
+		if found == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "rfq.unknown_category", map[string]interface{}{"category": category})})
+			return
+		}
+		query = query.Where("category_id IN ("+categorySubtreeSQL+")", found.ID)
+	}
+	if district := c.Query("district"); district != "" {
+		query = query.Where("LOWER(district) = LOWER(?)", district)
+	}
+	if unit := c.Query("unit"); unit != "" {
+		code, err := units.Normalize(unit)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("unit = ?", code)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "rfq.count_failed", nil)})
+		return
+	}
+
+	page := c.DefaultQuery("page", "1")
+	limit := c.DefaultQuery("limit", "20")
+
+	rfqs := []models.RFQ{}
+	if err := query.Order("deadline, created_at DESC").Scopes(Paginate(page, limit)).Find(&rfqs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "rfq.retrieve_failed", nil)})
+		return
+	}
+	attachBidCounts(rfqs)
+
+	pageInt, limitInt := paginationValues(page, limit)
+	c.JSON(http.StatusOK, gin.H{
+		"rfqs": rfqs,
+		"meta": gin.H{
+			"total": total,
+			"page":  pageInt,
+			"limit": limitInt,
+			"pages": (total + int64(limitInt) - 1) / int64(limitInt),
+		},
+	})
+}
+
+// GetRFQ returns one request. Requests that no longer take bids are only
+// visible to their buyer and the farmers who bid.
+// GET /rfqs/:id
+func GetRFQ(c *gin.Context) {
+	rfq, ok := visibleRFQ(c)
+	if !ok {
+		return
+	}
+	rfqs := []models.RFQ{rfq}
+	attachBidCounts(rfqs)
+	c.JSON(http.StatusOK, gin.H{"rfq": rfqs[0]})
+}
+
+// GetRFQBids lists the bids on a request. The buyer sees all bids, cheapest
+// first, to compare them; a farmer sees only their own.
+// GET /rfqs/:id/bids
+func GetRFQBids(c *gin.Context) {
+	rfq, ok := visibleRFQ(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	role, _ := c.Get("role")
+	query := database.DB.Where("rfq_id = ?", rfq.ID)
+	switch {
+	case role == "admin" || rfq.BuyerID == userID.(uint):
+		query = query.Where("status <> ?", models.BidWithdrawn)
+	case role == "farmer":
+		query = query.Where("farmer_id = ?", userID)
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "rfq.bids_forbidden", nil)})
+		return
+	}
+
+	var bids []models.RFQBid
+	if err := query.Order("price_per_unit, created_at").Find(&bids).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "rfq.bids_retrieve_failed", nil)})
+		return
+	}
+
+	farmerIDs := make([]uint, len(bids))
+	for i, bid := range bids {
+		farmerIDs[i] = bid.FarmerID
+	}
+	var farmers []models.User
+	if len(farmerIDs) > 0 {
+		database.DB.Select("id, full_name, district").Where("id IN ?", farmerIDs).Find(&farmers)
+	}
+	byID := map[uint]models.User{}
+	for _, farmer := range farmers {
+		byID[farmer.ID] = farmer
+	}
+
+	views := make([]rfqBidView, len(bids))
+	for i, bid := range bids {
+		views[i] = rfqBidView{
+			RFQBid:         bid,
+			FarmerName:     byID[bid.FarmerID].FullName,
+			FarmerDistrict: byID[bid.FarmerID].District,
+			Total:          bid.Quantity * bid.PricePerUnit,
+			WithinBudget:   rfq.MaxPricePerUnit == nil || bid.PricePerUnit <= *rfq.MaxPricePerUnit,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"rfq": rfq, "bids": views})
+}
+
+// GetMyBids lists the signed in farmer's bids with their requests
+// GET /rfqs/bids?status=submitted
+func GetMyBids(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	query := database.DB.Where("farmer_id = ?", userID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	bids := []models.RFQBid{}
+	if err := query.Preload("RFQ").Order("created_at DESC").Find(&bids).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "rfq.bids_retrieve_failed", nil)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"bids": bids})
+}
+
+// SubmitBid bids on an open request with produce from one of the farmer's
+// listings. A farmer has one bid per request; change it with UpdateBid.
+// POST /rfqs/:id/bids {"product_id": 12, "quantity": 500, "price_per_unit": 55, "delivery_date": "2026-11-12"}
+func SubmitBid(c *gin.Context) {
+	var rfq models.RFQ
+	if err := database.DB.First(&rfq, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "rfq.not_found", nil)})
+		return
+	}
+	if !takesBids(rfq) {
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "rfq.not_open", nil)})
+		return
+	}
+
+	var input BidInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	var existing int64
+	database.DB.Model(&models.RFQBid{}).
+		Where("rfq_id = ? AND farmer_id = ? AND status IN ?", rfq.ID, userID, []string{models.BidSubmitted, models.BidAwarded}).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "rfq.bid_exists", nil)})
+		return
+	}
+
+	bid := models.RFQBid{RFQID: rfq.ID, FarmerID: userID.(uint), Status: models.BidSubmitted}
+	if err := prepareBid(c, rfq, &bid, input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Create(&bid).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "rfq.bid_submit_failed", nil), "details": err.Error()})
+		return
+	}
+
+	params := rfqParams(rfq)
+	params["quantity"] = bid.Quantity
+	params["price"] = bid.PricePerUnit
+	notify.Send(rfq.BuyerID, notify.TypeOffer, notify.EventRFQBidReceived, rfq.ID, params)
+
+	c.JSON(http.StatusCreated, gin.H{"message": i18n.Tc(c, "rfq.bid_submitted", nil), "bid": bid})
+}
+
+// UpdateBid changes a bid that has not been awarded yet
+// PUT /rfqs/:id/bids/:bidId
+func UpdateBid(c *gin.Context) {
+	rfq, bid, ok := ownBid(c)
+	if !ok {
+		return
+	}
+
+	var input BidInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := prepareBid(c, rfq, &bid, input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Save(&bid).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "rfq.bid_update_failed", nil), "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "rfq.bid_updated", nil), "bid": bid})
+}
+
+// WithdrawBid withdraws a bid that has not been awarded yet
+// DELETE /rfqs/:id/bids/:bidId
+func WithdrawBid(c *gin.Context) {
+	_, bid, ok := ownBid(c)
+	if !ok {
+		return
+	}
+
+	bid.Status = models.BidWithdrawn
+	if err := database.DB.Model(&bid).Update("status", bid.Status).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "rfq.bid_withdraw_failed", nil)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "rfq.bid_withdrawn", nil), "bid": bid})
+}
+
+// AwardRFQ awards bids on a request, whole or in part, and turns each award
+// into an accepted offer and an order. Awarded quantities are taken from the
+// stock of each bid's listing. Once the requested quantity is awarded the
+// request closes and the remaining bids are rejected.
+// POST /rfqs/:id/award {"awards": [{"bid_id": 3, "quantity": 1500}, {"bid_id": 7}]}
+func AwardRFQ(c *gin.Context) {
+	rfq, ok := ownedRFQ(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Awards []RFQAward `json:"awards" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var awarded, rejected []models.RFQBid
+	var offers []models.Offer
+	var orders []models.Order
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rfq, rfq.ID).Error; err != nil {
+			return err
+		}
+		if rfq.Status != models.RFQOpen {
+			return errRFQNotOpen
+		}
+
+		// Lock the bids, and below the stock of their listings, in a fixed
+		// order so concurrent awards cannot deadlock
+		ids := make([]uint, len(input.Awards))
+		seen := map[uint]bool{}
+		for i, award := range input.Awards {
+			if seen[award.BidID] {
+				return awardError(i18n.Tc(c, "rfq.award_duplicate", map[string]interface{}{"bid_id": award.BidID}))
+			}
+			seen[award.BidID] = true
+			ids[i] = award.BidID
+		}
+		var bids []models.RFQBid
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND rfq_id = ? AND status = ?", ids, rfq.ID, models.BidSubmitted).
+			Order("id").Find(&bids).Error; err != nil {
+			return err
+		}
+		bidByID := map[uint]models.RFQBid{}
+		for _, bid := range bids {
+			bidByID[bid.ID] = bid
+		}
+
+		total := rfq.AwardedQuantity
+		stock := map[stockKey]float64{}
+		for _, award := range input.Awards {
+			bid, ok := bidByID[award.BidID]
+			if !ok {
+				return awardError(i18n.Tc(c, "rfq.award_bid_not_open", map[string]interface{}{"bid_id": award.BidID}))
+			}
+			quantity := award.Quantity
+			if quantity == 0 {
+				quantity = bid.Quantity
+			}
+			if quantity < 0 || quantity > bid.Quantity {
+				return awardError(i18n.Tc(c, "rfq.award_too_much", map[string]interface{}{
+					"bid_id": bid.ID, "offered": bid.Quantity, "unit": rfq.Unit, "quantity": quantity,
+				}))
+			}
+			total += quantity
+
+			offer := models.Offer{
+				BuyerID:    rfq.BuyerID,
+				ProductID:  bid.ProductID,
+				VariantID:  bid.VariantID,
+				Quantity:   quantity,
+				Unit:       rfq.Unit,
+				Price:      bid.PricePerUnit,
+				Status:     "ACCEPTED",
+				PickupDate: bid.DeliveryDate.String(),
+			}
+			if key, params := convertOfferToListing(&offer); key != "" {
+				return awardError(i18n.Tc(c, "rfq.award_bid_invalid", map[string]interface{}{
+					"bid_id": bid.ID, "reason": i18n.Tc(c, key, params),
+				}))
+			}
+			stock[stockKeyOf(offer.ProductID, offer.VariantID)] -= offer.ListingQuantity
+
+			bid.AwardedQuantity = quantity
+			awarded = append(awarded, bid)
+			offers = append(offers, offer)
+		}
+
+		var short *availability.StockError
+		err := moveStock(tx, stock)
+		switch {
+		case errors.As(err, &short):
+			return awardError(i18n.Tc(c, "rfq.award_short_stock", map[string]interface{}{
+				"available": short.Available, "unit": short.Unit, "product": short.Product,
+			}))
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return awardError(i18n.Tc(c, "rfq.award_listing_gone", nil))
+		case err != nil:
+			return err
+		}
+
+		today := availability.Today().String()
+		for i := range awarded {
+			bid, offer := &awarded[i], &offers[i]
+			if err := tx.Create(offer).Error; err != nil {
+				return err
+			}
+			order := models.Order{
+				OfferID:       offer.ID,
+				FarmerID:      bid.FarmerID,
+				BuyerID:       rfq.BuyerID,
+				ProductID:     bid.ProductID,
+				VariantID:     bid.VariantID,
+				OrderDate:     today,
+				Status:        "processing",
+				StockQuantity: offer.ListingQuantity,
+			}
+			if err := tx.Create(&order).Error; err != nil {
+				return err
+			}
+
+			bid.Status = models.BidAwarded
+			bid.OrderID = &order.ID
+			if err := tx.Model(bid).Updates(map[string]interface{}{
+				"status":           bid.Status,
+				"awarded_quantity": bid.AwardedQuantity,
+				"order_id":         bid.OrderID,
+			}).Error; err != nil {
+				return err
+			}
+			orders = append(orders, order)
+		}
+
+		// Allow for rounding of split quantities
+		if total > rfq.Quantity*1.0001 {
+			return awardError(i18n.Tc(c, "rfq.award_exceeds", map[string]interface{}{
+				"total": total, "unit": rfq.Unit, "quantity": rfq.Quantity,
+			}))
+		}
+		rfq.AwardedQuantity = total
+		if total >= rfq.Quantity*0.9999 {
+			rfq.Status = models.RFQAwarded
+			var err error
+			if rejected, err = rejectOpenBids(tx, rfq.ID); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&rfq).Updates(map[string]interface{}{
+			"awarded_quantity": rfq.AwardedQuantity,
+			"status":           rfq.Status,
+		}).Error
+	})
+
+	var invalid awardError
+	switch {
+	case errors.Is(err, errRFQNotOpen):
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "rfq.not_open", nil)})
+		return
+	case errors.As(err, &invalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "rfq.award_failed", nil), "details": err.Error()})
+		return
+	}
+
+	for i, bid := range awarded {
+		params := rfqParams(rfq)
+		params["quantity"] = bid.AwardedQuantity
+		params["order_id"] = orders[i].ID
+		notify.Send(bid.FarmerID, notify.TypeOffer, notify.EventRFQBidAwarded, orders[i].ID, params)
+		publishOfferUpdate(offers[i])
+		publishOrderUpdate(orders[i])
+	}
+	notifyBidsRejected(rfq, rejected)
+
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "rfq.awarded", nil), "rfq": rfq, "orders": orders})
+}
+
+// CloseRFQ stops a request from taking bids and rejects the bids not
+// awarded. A request with awards counts as awarded.
+// POST /rfqs/:id/close
+func CloseRFQ(c *gin.Context) {
+	rfq, ok := ownedRFQ(c)
+	if !ok {
+		return
+	}
+
+	var rejected []models.RFQBid
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rfq, rfq.ID).Error; err != nil {
+			return err
+		}
+		if rfq.Status != models.RFQOpen {
+			return errRFQNotOpen
+		}
+
+		var err error
+		if rejected, err = rejectOpenBids(tx, rfq.ID); err != nil {
+			return err
+		}
+		rfq.Status = models.RFQClosed
+		if rfq.AwardedQuantity > 0 {
+			rfq.Status = models.RFQAwarded
+		}
+		return tx.Model(&rfq).Update("status", rfq.Status).Error
+	})
+	switch {
+	case errors.Is(err, errRFQNotOpen):
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "rfq.not_open", nil)})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "rfq.close_failed", nil), "details": err.Error()})
+		return
+	}
+
+	notifyBidsRejected(rfq, rejected)
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "rfq.closed", nil), "rfq": rfq})
+}
+
+// prepareBid applies a farmer's bid input. The bid must come from the
+// farmer's own listing in a unit the listing converts to, and be delivered
+// by the request's deadline.
+func prepareBid(c *gin.Context, rfq models.RFQ, bid *models.RFQBid, input BidInput) error {
+	if input.Quantity > rfq.Quantity {
+		return errors.New(i18n.Tc(c, "rfq.bid_quantity_exceeds", map[string]interface{}{"quantity": rfq.Quantity, "unit": rfq.Unit}))
+	}
+	if input.DeliveryDate.IsZero() {
+		input.DeliveryDate = rfq.Deadline
+	}
+	if input.DeliveryDate.After(rfq.Deadline.Time) {
+		return errors.New(i18n.Tc(c, "rfq.bid_after_deadline", map[string]interface{}{"deadline": rfq.Deadline}))
+	}
+
+	var product models.Product
+	if err := database.DB.Where(publicProductsSQL).Where("id = ? AND user_id = ?", input.ProductID, bid.FarmerID).First(&product).Error; err != nil {
+		return errors.New(i18n.Tc(c, "rfq.bid_not_your_listing", map[string]interface{}{"product_id": input.ProductID}))
+	}
+	offer := models.Offer{
+		ProductID: product.ID,
+		VariantID: input.VariantID,
+		Quantity:  input.Quantity,
+		Unit:      rfq.Unit,
+		Price:     input.PricePerUnit,
+	}
+	if key, params := convertOfferToListing(&offer); key != "" {
+		return errors.New(i18n.Tc(c, key, params))
+	}
+
+	bid.ProductID = product.ID
+	bid.VariantID = input.VariantID
+	bid.Quantity = input.Quantity
+	bid.PricePerUnit = input.PricePerUnit
+	bid.DeliveryDate = input.DeliveryDate
+	bid.Note = input.Note
+	return nil
+}
+
+// takesBids reports whether a request is open and before its deadline
+func takesBids(rfq models.RFQ) bool {
+	return rfq.Status == models.RFQOpen && !rfq.Deadline.Before(availability.Today().Time)
+}
+
+// rejectOpenBids rejects the bids of a request still waiting for an award
+func rejectOpenBids(tx *gorm.DB, rfqID uint) ([]models.RFQBid, error) {
+	var bids []models.RFQBid
+	if err := tx.Where("rfq_id = ? AND status = ?", rfqID, models.BidSubmitted).Find(&bids).Error; err != nil {
+		return nil, err
+	}
+	if len(bids) == 0 {
+		return nil, nil
+	}
+	err := tx.Model(&models.RFQBid{}).
+		Where("rfq_id = ? AND status = ?", rfqID, models.BidSubmitted).
+		Update("status", models.BidRejected).Error
+	return bids, err
+}
+
+// attachBidCounts sets the number of active bids of each request
+func attachBidCounts(rfqs []models.RFQ) {
+	if len(rfqs) == 0 {
+		return
+	}
+	ids := make([]uint, len(rfqs))
+	for i, rfq := range rfqs {
+		ids[i] = rfq.ID
+	}
+	var counts []struct {
+		RFQID uint
+		Count int64
+	}
+	if err := database.DB.Model(&models.RFQBid{}).
+		Select("rfq_id, COUNT(*) AS count").
+		Where("rfq_id IN ? AND status <> ?", ids, models.BidWithdrawn).
+		Group("rfq_id").
+		Scan(&counts).Error; err != nil {
+		return
+	}
+	byID := map[uint]int64{}
+	for _, count := range counts {
+		byID[count.RFQID] = count.Count
+	}
+	for i := range rfqs {
+		rfqs[i].BidCount = byID[rfqs[i].ID]
+	}
+}
+
+// visibleRFQ loads the request of the route for users allowed to see it
+func visibleRFQ(c *gin.Context) (models.RFQ, bool) {
+	var rfq models.RFQ
+	if err := database.DB.First(&rfq, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "rfq.not_found", nil)})
+		return rfq, false
+	}
+
+	userID, _ := c.Get("userID")
+	role, _ := c.Get("role")
+	if takesBids(rfq) || role == "admin" || rfq.BuyerID == userID.(uint) {
+		return rfq, true
+	}
+	var bids int64
+	database.DB.Model(&models.RFQBid{}).Where("rfq_id = ? AND farmer_id = ?", rfq.ID, userID).Count(&bids)
+	if bids == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "rfq.not_found", nil)})
+		return rfq, false
+	}
+	return rfq, true
+}
+
+// ownedRFQ loads the request of the route for its buyer or an admin
+func ownedRFQ(c *gin.Context) (models.RFQ, bool) {
+	var rfq models.RFQ
+	if err := database.DB.First(&rfq, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "rfq.not_found", nil)})
+		return rfq, false
+	}
+
+	userID, _ := c.Get("userID")
+	role, _ := c.Get("role")
+	if role != "admin" && rfq.BuyerID != userID.(uint) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "rfq.manage_forbidden", nil)})
+		return rfq, false
+	}
+	return rfq, true
+}
+
+// ownBid loads the signed in farmer's bid of the route while it can still
+// change
+func ownBid(c *gin.Context) (models.RFQ, models.RFQBid, bool) {
+	var rfq models.RFQ
+	var bid models.RFQBid
+	userID, _ := c.Get("userID")
+	if err := database.DB.Where("id = ? AND rfq_id = ? AND farmer_id = ?", c.Param("bidId"), c.Param("id"), userID).First(&bid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "rfq.bid_not_found", nil)})
+		return rfq, bid, false
+	}
+	if err := database.DB.First(&rfq, bid.RFQID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "rfq.not_found", nil)})
+		return rfq, bid, false
+	}
+	if bid.Status != models.BidSubmitted || !takesBids(rfq) {
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "rfq.bid_locked", nil)})
+		return rfq, bid, false
+	}
+	return rfq, bid, true
+}
+
+// rfqParams are the notification parameters describing a request
+func rfqParams(rfq models.RFQ) map[string]interface{} {
+	location := rfq.DeliveryLocation
+	if location == "" {
+		location = rfq.District
+	}
+	return map[string]interface{}{
+		"rfq_id":   rfq.ID,
+		"title":    rfq.Title,
+		"quantity": rfq.Quantity,
+		"unit":     rfq.Unit,
+		"location": location,
+		"date":     rfq.Deadline.String(),
+	}
+}
+
+// notifyRFQPosted tells farmers with listings in the request's category
+// about a new request
+func notifyRFQPosted(rfq models.RFQ) {
+	if rfq.CategoryID == nil {
+		return
+	}
+	var farmerIDs []uint
+	if err := database.DB.Model(&models.Product{}).
+		Where(publicProductsSQL).
+		Where("products.category_id IN ("+categorySubtreeSQL+")", *rfq.CategoryID).
+		Distinct("user_id").
+		Pluck("user_id", &farmerIDs).Error; err != nil {
+		log.Printf("Failed to find farmers for request %d: %v", rfq.ID, err)
+		return
+	}
+	for _, farmerID := range farmerIDs {
+		notify.Send(farmerID, notify.TypeOffer, notify.EventRFQPosted, rfq.ID, rfqParams(rfq))
+	}
+}
+
+// notifyBidsRejected tells farmers their bids were not awarded
+func notifyBidsRejected(rfq models.RFQ, bids []models.RFQBid) {
+	for _, bid := range bids {
+		notify.Send(bid.FarmerID, notify.TypeOffer, notify.EventRFQBidRejected, rfq.ID, rfqParams(rfq))
+	}
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"agro-connect/availability"
+	"agro-connect/database"
+	"agro-connect/database/testdb"
+	"agro-connect/models"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// awardDB is a database with a 100 kg listing and an open request with a
+// bid from it for each quantity given
+func awardDB(t *testing.T, bidQuantities ...float64) (models.Product, models.RFQ, []models.RFQBid) {
+	t.Helper()
+	db := testdb.Open(t, &models.User{}, &models.Product{}, &models.ProductVariant{}, &models.Category{},
+		&models.RFQ{}, &models.RFQBid{}, &models.Offer{}, &models.Order{}, &models.OrderItem{})
+
+	product := models.Product{UserID: testFarmerID, NameEn: "Onion", Unit: "kg", PricePerUnit: 60,
+		Quantity: 100, Status: models.ProductStatusAvailable}
+	mustCreate(t, db, &product)
+	rfq := models.RFQ{BuyerID: testBuyerID, Title: "Onions", Quantity: 200, Unit: "kg",
+		Deadline: models.NewDate(availability.Today().AddDate(0, 0, 7)), Status: models.RFQOpen}
+	mustCreate(t, db, &rfq)
+
+	var bids []models.RFQBid
+	for _, quantity := range bidQuantities {
+		bid := models.RFQBid{RFQID: rfq.ID, FarmerID: testFarmerID, ProductID: product.ID,
+			Quantity: quantity, PricePerUnit: 55, DeliveryDate: rfq.Deadline, Status: models.BidSubmitted}
+		mustCreate(t, db, &bid)
+		bids = append(bids, bid)
+	}
+	return product, rfq, bids
+}
+
+func award(t *testing.T, rfq models.RFQ, bids ...models.RFQBid) int {
+	t.Helper()
+	awards := make([]gin.H, len(bids))
+	for i, bid := range bids {
+		awards[i] = gin.H{"bid_id": bid.ID}
+	}
+	return serve(t, AwardRFQ, testBuyerID, "buyer",
+		gin.Params{{Key: "id", Value: fmt.Sprint(rfq.ID)}}, gin.H{"awards": awards})
+}
+
+func TestAwardedOrderCancelReturnsStockAndReopenTakesIt(t *testing.T) {
+	product, rfq, bids := awardDB(t, 30)
+	if code := award(t, rfq, bids...); code != http.StatusOK {
+		t.Fatalf("award: got status %d", code)
+	}
+	wantStock(t, "award", product.ID, 0, 70, models.ProductStatusAvailable)
+
+	var order models.Order
+	if err := database.DB.Where("product_id = ?", product.ID).First(&order).Error; err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		status string
+		left   float64
+	}{
+		{"canceled", 100},
+		{"canceled", 100},
+		{"processing", 70},
+		{"completed", 70},
+		{"canceled", 100},
+	}
+	for _, step := range steps {
+		if code := setOrderStatus(t, order, step.status); code != http.StatusOK {
+			t.Fatalf("order %s: got status %d", step.status, code)
+		}
+		wantStock(t, "order "+step.status, product.ID, 0, step.left, models.ProductStatusAvailable)
+	}
+}
+
+func TestAwardTakesNothingWhenStockIsShort(t *testing.T) {
+	product, rfq, bids := awardDB(t, 60, 50)
+	if code := award(t, rfq, bids[1], bids[0]); code != http.StatusBadRequest {
+		t.Fatalf("award: got status %d, want %d", code, http.StatusBadRequest)
+	}
+	wantStock(t, "short award", product.ID, 0, 100, models.ProductStatusAvailable)
+
+	var awarded int64
+	database.DB.Model(&models.RFQBid{}).Where("status <> ?", models.BidSubmitted).Count(&awarded)
+	if awarded != 0 {
+		t.Fatalf("short award: %d bids changed, want none", awarded)
+	}
+
+	if code := award(t, rfq, bids[1]); code != http.StatusOK {
+		t.Fatalf("award: got status %d", code)
+	}
+	wantStock(t, "award", product.ID, 0, 50, models.ProductStatusAvailable)
+}
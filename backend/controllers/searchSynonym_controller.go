@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/models"
+	"agro-connect/search"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSearchSynonyms returns the merged synonym dictionary and the custom
+// entries admins added on top of the built-in one
+func GetSearchSynonyms(c *gin.Context) {
+	var custom []models.SearchSynonym
+	if err := database.DB.Order("canonical, term").Find(&custom).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "synonym.retrieve_failed", nil)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"groups": search.SynonymGroups(),
+		"custom": custom,
+	})
+}
+
+// CreateSearchSynonymInput adds terms to a synonym group
+type CreateSearchSynonymInput struct {
+	Canonical string   `json:"canonical" binding:"required"`
+	Terms     []string `json:"terms" binding:"required,min=1"`
+}
+
+// CreateSearchSynonyms adds terms to the synonym group of a canonical name
+// POST /admin/search/synonyms
+// {"canonical": "tomato", "terms": ["golbheda", "गोलभेडा"]}
+func CreateSearchSynonyms(c *gin.Context) {
+	var input CreateSearchSynonymInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	canonical := strings.ToLower(strings.TrimSpace(input.Canonical))
+	var created []models.SearchSynonym
+	for _, term := range input.Terms {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term == "" {
+			continue
+		}
+
+		var existing models.SearchSynonym
+		if err := database.DB.Where("term = ?", term).First(&existing).Error; err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "synonym.term_taken", map[string]interface{}{"term": term, "group": existing.Canonical})})
+			return
+		}
+
+		synonym := models.SearchSynonym{Canonical: canonical, Term: term}
+		if err := database.DB.Create(&synonym).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "synonym.create_failed", nil), "details": err.Error()})
+			return
+		}
+		created = append(created, synonym)
+	}
+
+	reloadSynonyms()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  i18n.Tc(c, "synonym.created", nil),
+		"synonyms": created,
+	})
+}
+
+// DeleteSearchSynonym removes a custom synonym. Built-in synonyms cannot be deleted.
+func DeleteSearchSynonym(c *gin.Context) {
+	id := c.Param("id")
+
+	var synonym models.SearchSynonym
+	if err := database.DB.First(&synonym, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "synonym.not_found", nil)})
+		return
+	}
+
+	// Hard delete so the term can be added again later
+	if err := database.DB.Unscoped().Delete(&synonym).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "synonym.delete_failed", nil)})
+		return
+	}
+
+	reloadSynonyms()
+
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "synonym.deleted", nil)})
+}
+
+func reloadSynonyms() {
+	if err := search.ReloadSynonyms(database.DB); err != nil {
+		log.Printf("Failed to reload search synonyms: %v", err)
+	}
+}
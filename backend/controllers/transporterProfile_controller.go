@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"agro-connect/database"
+	"agro-connect/i18n"
 	"agro-connect/models"
 	"net/http"
 
@@ -20,23 +21,23 @@ func CreateTransporterProfile(c *gin.Context) {
 
 	// Validate the input
 	if input.UserID == 0 || input.VehicleType == "" || input.LicenseNo == "" || input.Capacity <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "profile.invalid_input", nil)})
 		return
 	}
 
 	var existingProfile models.TransporterProfile
 	if err := database.DB.Where("user_id = ?", input.UserID).First(&existingProfile).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Transporter profile already exists for this user"})
+		c.JSON(http.StatusConflict, gin.H{"error": i18n.Tc(c, "profile.transporter_exists", nil)})
 		return
 	}
 
 	// Create the transporter profile
 
 	if err := database.DB.Create(&input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transporter profile"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "profile.transporter_create_failed", nil)})
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"message": "Transporter profile created successfully", "profile": input})
+	c.JSON(http.StatusCreated, gin.H{"message": i18n.Tc(c, "profile.transporter_created", nil), "profile": input})
 }
 
 //GetTransporterProfile retrieves a transporter profile by user ID
@@ -46,7 +47,7 @@ func GetTransporterProfileID(c *gin.Context) {
 
 	var profile models.TransporterProfile
 	if err := database.DB.Where("user_id = ?", userID).First(&profile).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Transporter profile not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "profile.transporter_not_found", nil)})
 		return
 	}
 
@@ -66,13 +67,13 @@ func UpdateTransporterProfile(c *gin.Context) {
 
 	// Validate the input
 	if input.VehicleType == "" || input.LicenseNo == "" || input.Capacity <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "profile.invalid_input", nil)})
 		return
 	}
 
 	var profile models.TransporterProfile
 	if err := database.DB.Where("user_id = ?", userID).First(&profile).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Transporter profile not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "profile.transporter_not_found", nil)})
 		return
 	}
 
@@ -81,11 +82,11 @@ func UpdateTransporterProfile(c *gin.Context) {
 	profile.Capacity = input.Capacity
 
 	if err := database.DB.Save(&profile).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transporter profile"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "profile.transporter_update_failed", nil)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Transporter profile updated successfully", "profile": profile})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "profile.transporter_updated", nil), "profile": profile})
 }
 
 // DeleteTransporterProfile deletes a transporter profile by user ID
@@ -95,16 +96,16 @@ func DeleteTransporterProfile(c *gin.Context) {
 
 	var profile models.TransporterProfile
 	if err := database.DB.Where("user_id = ?", userID).First(&profile).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Transporter profile not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "profile.transporter_not_found", nil)})
 		return
 	}
 
 	if err := database.DB.Delete(&profile).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transporter profile"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "profile.transporter_delete_failed", nil)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Transporter profile deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "profile.transporter_deleted", nil)})
 }
 
 // GetAllTransporterProfiles retrieves all transporter profiles
@@ -112,12 +113,12 @@ func DeleteTransporterProfile(c *gin.Context) {
 func GetAllTransporterProfiles(c *gin.Context) {
 	var profiles []models.TransporterProfile
 	if err := database.DB.Find(&profiles).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve transporter profiles"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "profile.transporters_retrieve_failed", nil)})
 		return
 	}
 
 	if len(profiles) == 0 {
-		c.JSON(http.StatusOK, gin.H{"message": "No transporter profiles found"})
+		c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "profile.transporters_none", nil)})
 		return
 	}
 
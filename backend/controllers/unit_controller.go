@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"agro-connect/i18n"
+	"agro-connect/units"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUnits lists the units of measure products and offers may use
+// GET /units
+func GetUnits(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"units": units.All()})
+}
+
+// ConvertUnits converts a quantity between two units. Mass and volume
+// convert into each other when kg_per_litre is given.
+// GET /units/convert?quantity=2&from=muri&to=kg&kg_per_litre=0.75
+func ConvertUnits(c *gin.Context) {
+	quantity, err := strconv.ParseFloat(c.Query("quantity"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "unit.invalid_quantity", nil)})
+		return
+	}
+	density, _ := strconv.ParseFloat(c.DefaultQuery("kg_per_litre", "0"), 64)
+
+	from, ok := units.Lookup(c.Query("from"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "unit.unknown", map[string]interface{}{"unit": c.Query("from")})})
+		return
+	}
+	to, ok := units.Lookup(c.Query("to"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "unit.unknown", map[string]interface{}{"unit": c.Query("to")})})
+		return
+	}
+
+	converted, err := units.Convert(quantity, from, to, density)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "unit.incompatible", map[string]interface{}{"from": from.Code, "to": to.Code})})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"quantity": quantity,
+		"from":     from.Code,
+		"to":       to.Code,
+		"result":   math.Round(converted*1000) / 1000,
+	})
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"agro-connect/i18n"
+	"agro-connect/storage"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uploadURLExpiry is how long redirects to remote storage stay valid
+const uploadURLExpiry = time.Hour
+
+// ServeUpload serves a stored file under /uploads/<key>. Local files are
+// sent directly; files in remote storage are redirected to a short-lived
+// signed URL. Private files require a signed URL.
+// GET /uploads/*key
+func ServeUpload(c *gin.Context) {
+	key, ok := storage.CleanKey(c.Param("key"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "upload.not_found", nil)})
+		return
+	}
+
+	if local, ok := storage.Default.(*storage.Local); ok {
+		if storage.IsPrivate(key) && !local.Verify(key, c.Query("expires"), c.Query("signature")) {
+			c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "upload.link_invalid", nil)})
+			return
+		}
+		info, err := os.Stat(local.Path(key))
+		if err != nil || info.IsDir() {
+			c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "upload.not_found", nil)})
+			return
+		}
+		// Keys are never reused, so files can be cached indefinitely
+		if !storage.IsPrivate(key) {
+			c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		}
+		c.File(local.Path(key))
+		return
+	}
+
+	if storage.IsPrivate(key) {
+		c.JSON(http.StatusForbidden, gin.H{"error": i18n.Tc(c, "upload.link_invalid", nil)})
+		return
+	}
+	url, err := storage.Default.SignedURL(c.Request.Context(), key, uploadURLExpiry)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "upload.locate_failed", nil)})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Redirect(http.StatusFound, url)
+}
@@ -2,12 +2,11 @@ package controllers
 
 import (
 	"net/http"
-	"path/filepath"
-	"strconv"
 	"strings"
-	"time"
 
 	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/media"
 	"agro-connect/models"
 	"agro-connect/utils"
 
@@ -39,7 +38,7 @@ func Register(c *gin.Context) {
 	// Check for existing user by email or phone
 	var existingUser models.User
 	if err := database.DB.Where("email = ? OR phone = ?", input.Email, input.Phone).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User with given email or phone already exists"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "user.exists", nil)})
 		return
 	}
 
@@ -50,14 +49,14 @@ func Register(c *gin.Context) {
 	}
 	validRoles := map[string]bool{"farmer": true, "buyer": true, "transporter": true, "admin": true}
 	if !validRoles[role] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "user.invalid_role", nil)})
 		return
 	}
 
 	// Hash the password
 	hashedPassword, err := utils.HashPassword(input.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.hash_failed", nil)})
 		return
 	}
 
@@ -74,12 +73,12 @@ func Register(c *gin.Context) {
 	}
 
 	if err := database.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.create_failed", nil)})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "User created successfully",
+		"message": i18n.Tc(c, "user.created", nil),
 		"user": gin.H{
 			"id":        user.ID,
 			"full_name": user.FullName,
@@ -107,18 +106,18 @@ func Login(c *gin.Context) {
 
 	var user models.User
 	if err := database.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.Tc(c, "user.invalid_credentials", nil)})
 		return
 	}
 
 	if !utils.CheckPasswordHash(input.Password, user.PasswordHash) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.Tc(c, "user.invalid_credentials", nil)})
 		return
 	}
 
 	token, err := utils.GenerateJWT(user.ID, user.Role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.token_failed", nil)})
 		return
 	}
 
@@ -144,13 +143,13 @@ func Login(c *gin.Context) {
 func GetUserProfile(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.Tc(c, "error.unauthorized", nil)})
 		return
 	}
 
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user profile"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.profile_retrieve_failed", nil)})
 		return
 	}
 
@@ -177,7 +176,7 @@ type UpdateUserProfileInput struct {
 func UpdateUserProfile(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.Tc(c, "error.unauthorized", nil)})
 		return
 	}
 
@@ -189,7 +188,7 @@ func UpdateUserProfile(c *gin.Context) {
 
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user profile"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.profile_retrieve_failed", nil)})
 		return
 	}
 
@@ -217,33 +216,33 @@ func UpdateUserProfile(c *gin.Context) {
 	}
 
 	if err := database.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user profile"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.profile_update_failed", nil)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User profile updated successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "user.profile_updated", nil)})
 }
 
 // DeleteUserProfile deletes the profile of the authenticated user
 func DeleteUserProfile(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.Tc(c, "error.unauthorized", nil)})
 		return
 	}
 
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user profile"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.profile_retrieve_failed", nil)})
 		return
 	}
 
 	if err := database.DB.Delete(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user profile"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.profile_delete_failed", nil)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User profile deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "user.profile_deleted", nil)})
 }
 
 // GetAllUsers retrieves all users from the database (admin only)
@@ -252,7 +251,7 @@ func GetAllUsers(c *gin.Context) {
 
 	var users []models.User
 	if err := database.DB.Find(&users).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.retrieve_failed", nil)})
 		return
 	}
 
@@ -277,7 +276,7 @@ func GetUserByID(c *gin.Context) {
 	var user models.User
 
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "error.user_not_found", nil)})
 		return
 	}
 
@@ -299,6 +298,8 @@ func UpdateUserByID(c *gin.Context) {
 		FullName string `json:"full_name"`
 		Email    string `json:"email" binding:"omitempty,email"`
 		Role     string `json:"role"`
+		// Verified farmers bypass listing moderation
+		Verified *bool `json:"verified"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -308,7 +309,7 @@ func UpdateUserByID(c *gin.Context) {
 
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "error.user_not_found", nil)})
 		return
 	}
 
@@ -322,18 +323,21 @@ func UpdateUserByID(c *gin.Context) {
 		role := strings.ToLower(input.Role)
 		validRoles := map[string]bool{"farmer": true, "buyer": true, "transporter": true, "admin": true}
 		if !validRoles[role] {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "user.invalid_role", nil)})
 			return
 		}
 		user.Role = role
 	}
+	if input.Verified != nil {
+		user.Verified = *input.Verified
+	}
 
 	if err := database.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.update_failed", nil)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "user.updated", nil)})
 }
 
 // DeleteUserByID deletes a user by their ID (admin only)
@@ -343,16 +347,16 @@ func DeleteUserByID(c *gin.Context) {
 	userID := c.Param("id")
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "error.user_not_found", nil)})
 		return
 	}
 
 	if err := database.DB.Delete(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.delete_failed", nil)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "user.deleted", nil)})
 }
 
 // UpdatePasswordInput defines the input for password update
@@ -365,7 +369,7 @@ type UpdatePasswordInput struct {
 func UpdatePassword(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.Tc(c, "error.unauthorized", nil)})
 		return
 	}
 
@@ -377,31 +381,31 @@ func UpdatePassword(c *gin.Context) {
 
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User not found"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "error.user_not_found", nil)})
 		return
 	}
 
 	// Check old password
 	if !utils.CheckPasswordHash(input.OldPassword, user.PasswordHash) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Old password is incorrect"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.Tc(c, "user.old_password_incorrect", nil)})
 		return
 	}
 
 	// Hash new password
 	hashedPassword, err := utils.HashPassword(input.NewPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash new password"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.password_hash_failed", nil)})
 		return
 	}
 
 	user.PasswordHash = hashedPassword
 
 	if err := database.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.password_update_failed", nil)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "user.password_updated", nil)})
 }
 
 // UploadProfilePicture handles profile picture upload and updates user record
@@ -409,7 +413,7 @@ func UploadProfilePicture(c *gin.Context) {
 	// Get the user ID from the context (assuming you set it in middleware)
 	userIDInterface, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.Tc(c, "error.unauthenticated", nil)})
 		return
 	}
 
@@ -418,35 +422,36 @@ func UploadProfilePicture(c *gin.Context) {
 	// Get the uploaded file
 	file, err := c.FormFile("profile_picture")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file is received"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": i18n.Tc(c, "user.file_required", nil)})
 		return
 	}
 
-	// Optional: Validate file type/size here
-
-	// Create a unique filename, e.g. userID + timestamp + extension
-	ext := filepath.Ext(file.Filename)
-	fileName := "user_" + strconv.Itoa(int(userID)) + "_" + strconv.FormatInt(time.Now().Unix(), 10) + ext
-
-	// Save the file to the uploads directory
-	savePath := filepath.Join("uploads", fileName)
-	if err := c.SaveUploadedFile(file, savePath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+	upload, err := media.ValidateImage(c.Request.Context(), file)
+	if err != nil {
+		c.JSON(uploadErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	// Update user's ProfilePicture field in database
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": i18n.Tc(c, "error.user_not_found", nil)})
+		return
+	}
+
+	url, err := media.SaveProfilePicture(userID, upload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.file_save_failed", nil)})
 		return
 	}
 
-	user.ProfilePicture = "/" + savePath // relative path accessible via server
+	previous := user.ProfilePicture
+	user.ProfilePicture = url
 	if err := database.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user profile picture"})
+		media.RemoveFiles(url)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": i18n.Tc(c, "user.picture_update_failed", nil)})
 		return
 	}
+	media.RemoveFiles(previous)
 
-	c.JSON(http.StatusOK, gin.H{"message": "Profile picture uploaded successfully", "profile_picture": user.ProfilePicture})
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Tc(c, "user.picture_uploaded", nil), "profile_picture": user.ProfilePicture})
 }
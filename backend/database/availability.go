@@ -0,0 +1,50 @@
+package database
+
+import (
+	"agro-connect/models"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// migrateAvailabilityDates converts the free-text available_from and
+// available_to columns of products into dates before AutoMigrate changes
+// their type. Values that are not a date, such as "", become NULL.
+func migrateAvailabilityDates(db *gorm.DB) {
+	for _, column := range []string{"available_from", "available_to"} {
+		var dataType string
+		db.Raw(`SELECT data_type FROM information_schema.columns
+			WHERE table_schema = CURRENT_SCHEMA() AND table_name = 'products' AND column_name = ?`, column).
+			Scan(&dataType)
+		if dataType != "text" && dataType != "character varying" {
+			continue
+		}
+
+		var rows []struct {
+			ID    uint
+			Value string
+		}
+		if err := db.Table("products").Select("id, " + column + " AS value").
+			Where(column + " IS NOT NULL").Scan(&rows).Error; err != nil {
+			log.Printf("Failed to load %s for date migration: %v", column, err)
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, row := range rows {
+				var value interface{}
+				if date, err := models.ParseDate(strings.ReplaceAll(strings.TrimSpace(row.Value), "/", "-")); err == nil && !date.IsZero() {
+					value = date.String()
+				}
+				if err := tx.Table("products").Where("id = ?", row.ID).Update(column, value).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Exec("ALTER TABLE products ALTER COLUMN " + column + " TYPE date USING " + column + "::date").Error
+		})
+		if err != nil {
+			log.Printf("Failed to migrate %s to a date: %v", column, err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package database
+
+import (
+	"agro-connect/models"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// defaultCategory describes a category seeded on first start
+type defaultCategory struct {
+	slug, nameEn, nameNp string
+	aliases              []string
+	units                []string
+	perishable           *bool
+	shelfLifeDays        int
+	kgPerLitre           float64
+	children             []defaultCategory
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+// defaultCategories mirrors the categories the frontend offered before the
+// taxonomy existed, so existing listings map onto it
+var defaultCategories = []defaultCategory{
+	{slug: "vegetable", nameEn: "Vegetables", nameNp: "तरकारी",
+		aliases: []string{"veg", "veggies", "tarkari", "sabji", "sag sabji"},
+		units:   []string{"kg", "g", "dharni", "piece", "bunch", "dozen"}, perishable: boolPtr(true), shelfLifeDays: 7,
+		children: []defaultCategory{
+			{slug: "leafy-vegetable", nameEn: "Leafy vegetables", nameNp: "सागपात", aliases: []string{"leafy", "saag", "sag"},
+				units: []string{"kg", "g", "bunch"}, shelfLifeDays: 3,
+				children: []defaultCategory{
+					{slug: "spinach", nameEn: "Spinach", nameNp: "पालुङ्गो", aliases: []string{"palungo"}},
+					{slug: "mustard-leaf", nameEn: "Mustard leaf", nameNp: "रायोको साग", aliases: []string{"rayo"}},
+				}},
+			{slug: "root-vegetable", nameEn: "Root vegetables", nameNp: "जरे तरकारी", aliases: []string{"roots", "tubers"},
+				shelfLifeDays: 30,
+				children: []defaultCategory{
+					{slug: "potato", nameEn: "Potato", nameNp: "आलु", aliases: []string{"aalu", "alu"}},
+					{slug: "onion", nameEn: "Onion", nameNp: "प्याज", aliases: []string{"pyaj"}},
+				}},
+			{slug: "fruit-vegetable", nameEn: "Fruit vegetables", nameNp: "फलफूल तरकारी",
+				children: []defaultCategory{
+					{slug: "tomato", nameEn: "Tomato", nameNp: "गोलभेडा", aliases: []string{"golbheda"}},
+				}},
+		}},
+	{slug: "fruit", nameEn: "Fruits", nameNp: "फलफूल", aliases: []string{"fruits", "phalphul"},
+		units: []string{"kg", "g", "piece", "dozen"}, perishable: boolPtr(true), shelfLifeDays: 14},
+	{slug: "grain", nameEn: "Grains and pulses", nameNp: "अन्न तथा दलहन",
+		aliases: []string{"grains", "cereal", "cereals", "pulses", "anna", "dal"},
+		units:   []string{"kg", "quintal", "muri", "pathi", "mana", "dharni"}, perishable: boolPtr(false), kgPerLitre: 0.75},
+	{slug: "spice", nameEn: "Spices", nameNp: "मसला", aliases: []string{"spices", "masala"},
+		units: []string{"kg", "g"}, perishable: boolPtr(false)},
+	{slug: "dairy", nameEn: "Dairy products", nameNp: "दुग्ध पदार्थ", aliases: []string{"dairy product", "milk products"},
+		units: []string{"litre", "ml", "kg", "g", "piece", "packet"}, perishable: boolPtr(true), shelfLifeDays: 2, kgPerLitre: 1.03},
+	{slug: "meat", nameEn: "Meat", nameNp: "मासु", aliases: []string{"masu"},
+		units: []string{"kg", "g", "piece"}, perishable: boolPtr(true), shelfLifeDays: 2},
+	{slug: "other", nameEn: "Other", nameNp: "अन्य", aliases: []string{"others", "misc"},
+		units: []string{"kg", "g", "litre", "ml", "piece", "packet", "bottle", "box"}, perishable: boolPtr(false)},
+}
+
+// seedCategories creates the default category tree when none exists yet
+func seedCategories(db *gorm.DB) {
+	var count int64
+	if err := db.Model(&models.Category{}).Count(&count).Error; err != nil || count > 0 {
+		return
+	}
+
+	var create func(parentID *uint, defaults []defaultCategory) error
+	create = func(parentID *uint, defaults []defaultCategory) error {
+		for i, d := range defaults {
+			category := models.Category{
+				ParentID:      parentID,
+				Slug:          d.slug,
+				NameEn:        d.nameEn,
+				NameNp:        d.nameNp,
+				Aliases:       d.aliases,
+				SortOrder:     i,
+				AllowedUnits:  d.units,
+				Perishable:    d.perishable,
+				ShelfLifeDays: d.shelfLifeDays,
+				KgPerLitre:    d.kgPerLitre,
+			}
+			if err := db.Create(&category).Error; err != nil {
+				return err
+			}
+			if err := create(&category.ID, d.children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error { return create(nil, defaultCategories) }); err != nil {
+		log.Println("Failed to seed categories:", err)
+	}
+}
+
+// MapProductCategories links products that only have a free-text category to
+// the matching category of the tree. It runs at every start, so values left
+// unmapped can be fixed by adding an alias to a category.
+func MapProductCategories(db *gorm.DB) error {
+	var values []string
+	if err := db.Model(&models.Product{}).
+		Where("category_id IS NULL AND category <> ''").
+		Distinct().Pluck("category", &values).Error; err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	var categories []models.Category
+	if err := db.Find(&categories).Error; err != nil {
+		return err
+	}
+
+	var unmapped []string
+	for _, value := range values {
+		category := matchCategory(categories, value)
+		if category == nil {
+			unmapped = append(unmapped, value)
+			continue
+		}
+		if err := db.Model(&models.Product{}).
+			Where("category_id IS NULL AND category = ?", value).
+			Updates(map[string]interface{}{"category_id": category.ID, "category": category.Slug}).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(unmapped) > 0 {
+		log.Printf("Product categories without a matching category, add them as aliases: %s", strings.Join(unmapped, ", "))
+	}
+	return nil
+}
+
+// FindCategoryByName returns the category whose slug, name or alias matches
+// a free-text category name
+func FindCategoryByName(name string) (*models.Category, error) {
+	var categories []models.Category
+	if err := DB.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return matchCategory(categories, name), nil
+}
+
+// matchCategory compares names case-insensitively, ignoring separators and a
+// plural "s", so "Vegetables", "vegetable" and "tarkari" all match
+func matchCategory(categories []models.Category, name string) *models.Category {
+	wanted := normalizeCategoryName(name)
+	if wanted == "" {
+		return nil
+	}
+	for i, category := range categories {
+		names := append([]string{category.Slug, category.NameEn, category.NameNp}, category.Aliases...)
+		for _, n := range names {
+			if normalizeCategoryName(n) == wanted {
+				return &categories[i]
+			}
+		}
+	}
+	return nil
+}
+
+func normalizeCategoryName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.NewReplacer("-", " ", "_", " ").Replace(name)
+	name = strings.Join(strings.Fields(name), " ")
+	if len(name) > 3 && strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") {
+		name = strings.TrimSuffix(name, "s")
+	}
+	return name
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"agro-connect/config"
 	"agro-connect/models"
+	"agro-connect/search"
 	"fmt"
 	"log"
 
@@ -32,6 +33,8 @@ func Connect() {
 
 	// ✅ Create ENUM types before AutoMigrate
 	createEnums(DB)
+	migrateAvailabilityDates(DB)
+	migrateProductStatus(DB)
 
 	// ✅ AutoMigrate after enum creation
 	if err := DB.AutoMigrate(
@@ -42,9 +45,40 @@ func Connect() {
 		&models.Product{},
 		&models.Offer{},
 		&models.Order{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.NotificationDelivery{},
+		&models.NotificationSettings{},
+		&models.QueuedNotification{},
+		&models.SearchSynonym{},
+		&models.Category{},
+		&models.ProductImage{},
+		&models.ProductPriceHistory{},
+		&models.DailyPriceStat{},
+		&models.ReferencePrice{},
+		&models.CommodityMapping{},
+		&models.ProductModeration{},
+		&models.ProductVariant{},
+		&models.PreOrder{},
+		&models.RFQ{},
+		&models.RFQBid{},
+		&models.Auction{},
+		&models.AuctionBid{},
+		&models.CartItem{},
+		&models.OrderItem{},
 	); err != nil {
 		log.Fatal("Migration failed:", err)
 	}
+
+	createSearchIndexes(DB)
+
+	seedCategories(DB)
+	if err := MapProductCategories(DB); err != nil {
+		log.Println("Failed to map product categories:", err)
+	}
+	if err := NormalizeProductUnits(DB); err != nil {
+		log.Println("Failed to normalize product units:", err)
+	}
 }
 
 func createEnums(db *gorm.DB) {
@@ -74,4 +108,23 @@ func createEnums(db *gorm.DB) {
 	END $$;
 `)
 
+	db.Exec(`
+	DO $$ BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'product_status') THEN
+			CREATE TYPE product_status AS ENUM ('draft', 'pending_review', 'scheduled', 'available', 'reserved', 'sold_out', 'expired', 'archived');
+		END IF;
+	END $$;
+`)
+
+}
+
+// createSearchIndexes adds the full-text and trigram indexes used by product search
+func createSearchIndexes(db *gorm.DB) {
+	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`).Error; err != nil {
+		log.Println("Failed to enable pg_trgm, fuzzy search will be unavailable:", err)
+	}
+
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_search ON products USING GIN (` + search.ProductVector + `)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_name_en_trgm ON products USING GIN (name_en gin_trgm_ops)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_products_name_np_trgm ON products USING GIN (name_np gin_trgm_ops)`)
 }
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"agro-connect/models"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// legacyProductStatuses maps statuses accepted before the product_status
+// enum to their replacement
+var legacyProductStatuses = map[string]models.ProductStatus{
+	"active":   models.ProductStatusAvailable,
+	"sold":     models.ProductStatusSoldOut,
+	"inactive": models.ProductStatusArchived,
+	"pending":  models.ProductStatusPendingReview,
+}
+
+// migrateProductStatus converts the free-text status column of products to
+// the product_status enum before AutoMigrate. Legacy statuses are renamed and
+// unknown ones become available.
+func migrateProductStatus(db *gorm.DB) {
+	var dataType string
+	db.Raw(`SELECT data_type FROM information_schema.columns
+		WHERE table_schema = CURRENT_SCHEMA() AND table_name = 'products' AND column_name = 'status'`).
+		Scan(&dataType)
+	if dataType != "text" && dataType != "character varying" {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for legacy, status := range legacyProductStatuses {
+			if err := tx.Exec(`UPDATE products SET status = ? WHERE status = ?`, status, legacy).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec(`UPDATE products SET status = ? WHERE status IS NULL OR status NOT IN ?`,
+			models.ProductStatusAvailable, models.ProductStatuses).Error; err != nil {
+			return err
+		}
+		for _, statement := range []string{
+			`ALTER TABLE products ALTER COLUMN status DROP DEFAULT`,
+			`ALTER TABLE products ALTER COLUMN status TYPE product_status USING status::product_status`,
+			`ALTER TABLE products ALTER COLUMN status SET DEFAULT 'available'`,
+		} {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("Failed to convert product status to an enum:", err)
+	}
+}
@@ -0,0 +1,41 @@
+// Package testdb opens a throwaway SQLite database as database.DB for tests
+// of code that reads and writes through it. Only tests import it, so the
+// SQLite driver never reaches the server binary.
+package testdb
+
+import (
+	"agro-connect/database"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Open migrates tables into a fresh database in the test's temporary
+// directory and installs it as database.DB until the test ends. Queries
+// outside a transaction read its last committed state, as in PostgreSQL.
+func Open(t testing.TB, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_journal_mode=WAL&_busy_timeout=5000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = previous
+		sqlDB.Close()
+	})
+	return db
+}
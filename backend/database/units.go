@@ -0,0 +1,90 @@
+package database
+
+import (
+	"agro-connect/models"
+	"agro-connect/units"
+	"log"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// NormalizeProductUnits rewrites product units to their registry codes and
+// fills in the price per kilogram of listings saved before the registry
+// existed. Units outside the registry are left as they are and logged.
+func NormalizeProductUnits(db *gorm.DB) error {
+	type pair struct {
+		Unit       string
+		CategoryID *uint
+	}
+	var pairs []pair
+	if err := db.Model(&models.Product{}).
+		Where("price_per_kg IS NULL AND unit <> ''").
+		Distinct("unit", "category_id").
+		Scan(&pairs).Error; err != nil {
+		return err
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	var categories []models.Category
+	if err := db.Find(&categories).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]models.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+
+	unknown := map[string]bool{}
+	for _, p := range pairs {
+		unit, ok := units.Lookup(p.Unit)
+		if !ok {
+			unknown[p.Unit] = true
+			continue
+		}
+
+		scope := db.Model(&models.Product{}).Where("price_per_kg IS NULL AND unit = ?", p.Unit)
+		if p.CategoryID == nil {
+			scope = scope.Where("category_id IS NULL")
+		} else {
+			scope = scope.Where("category_id = ?", *p.CategoryID)
+		}
+
+		updates := map[string]interface{}{"unit": unit.Code}
+		if kg, ok := units.KgPer(unit, categoryDensity(byID, p.CategoryID)); ok {
+			updates["price_per_kg"] = gorm.Expr("ROUND(CAST(price_per_unit / ? AS numeric), 2)", kg)
+		}
+		if err := scope.Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(unknown) > 0 {
+		names := make([]string, 0, len(unknown))
+		for name := range unknown {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		log.Printf("Products with units outside the registry: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// categoryDensity returns the kg per litre of the nearest category in the
+// chain that sets it, or 0
+func categoryDensity(categories map[uint]models.Category, id *uint) float64 {
+	for depth := 0; id != nil && depth < 16; depth++ {
+		category, ok := categories[*id]
+		if !ok {
+			return 0
+		}
+		if category.KgPerLitre > 0 {
+			return category.KgPerLitre
+		}
+		id = category.ParentID
+	}
+	return 0
+}
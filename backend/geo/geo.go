@@ -0,0 +1,108 @@
+package geo
+
+import (
+	"embed"
+	"encoding/csv"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// The bundled dataset holds the centroid of every district headquarters and
+// of major municipalities. Addresses are geocoded offline against it; add
+// rows to the CSV to improve precision for other local levels.
+//
+//go:embed data/nepal_municipalities.csv
+var dataFS embed.FS
+
+// Point is a WGS84 coordinate
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+var (
+	municipalities = map[string]Point{} // "district|municipality" -> centroid
+	districts      = map[string]Point{} // district -> headquarters centroid
+)
+
+func init() {
+	f, err := dataFS.Open("data/nepal_municipalities.csv")
+	if err != nil {
+		log.Fatalf("Missing municipality dataset: %v", err)
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		log.Fatalf("Invalid municipality dataset: %v", err)
+	}
+
+	for i, row := range rows {
+		if i == 0 || len(row) < 5 {
+			continue
+		}
+		lat, errLat := strconv.ParseFloat(row[2], 64)
+		lon, errLon := strconv.ParseFloat(row[3], 64)
+		if errLat != nil || errLon != nil {
+			log.Fatalf("Invalid coordinates for %s, %s", row[1], row[0])
+		}
+
+		point := Point{Latitude: lat, Longitude: lon}
+		municipalities[key(row[0], row[1])] = point
+		if row[4] == "1" {
+			districts[normalize(row[0])] = point
+		}
+	}
+}
+
+// Geocode returns the centroid of the municipality in the district, or the
+// district headquarters when the municipality is unknown
+func Geocode(district, municipality string) (Point, bool) {
+	if municipality != "" {
+		if p, ok := municipalities[key(district, municipality)]; ok {
+			return p, true
+		}
+	}
+	p, ok := districts[normalize(district)]
+	return p, ok
+}
+
+// DistanceKm returns the great-circle distance between two points
+func DistanceKm(a, b Point) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(b.Latitude - a.Latitude)
+	dLon := toRad(b.Longitude - a.Longitude)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(a.Latitude))*math.Cos(toRad(b.Latitude))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// BoundingBox returns the latitude/longitude ranges containing every point
+// within radiusKm of center, used to prefilter rows before computing distances
+func BoundingBox(center Point, radiusKm float64) (minLat, maxLat, minLon, maxLon float64) {
+	const kmPerDegree = 111.32
+	dLat := radiusKm / kmPerDegree
+	dLon := radiusKm / (kmPerDegree * math.Cos(center.Latitude*math.Pi/180))
+	return center.Latitude - dLat, center.Latitude + dLat, center.Longitude - dLon, center.Longitude + dLon
+}
+
+// normalize lower-cases a place name and strips common suffixes such as
+// "Municipality", "Metropolitan City" and "Rural Municipality"
+func normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, suffix := range []string{
+		" sub-metropolitan city", " metropolitan city", " rural municipality",
+		" municipality", " district", " nagarpalika", " gaunpalika", " mahanagarpalika",
+	} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func key(district, municipality string) string {
+	return normalize(district) + "|" + normalize(municipality)
+}
@@ -0,0 +1,214 @@
+package i18n
+
+import (
+	"agro-connect/database"
+	"agro-connect/models"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Supported languages
+const (
+	English = "en"
+	Nepali  = "ne"
+)
+
+// Languages lists every language with a catalogue, English first
+var Languages = []string{English, Nepali}
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// message is a catalogue entry. Simple messages only set Other; pluralized
+// messages set One and Other and are selected by the "count" parameter.
+type message struct {
+	One   string
+	Other string
+}
+
+func (m *message) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		m.Other = text
+		return nil
+	}
+
+	var forms struct {
+		One   string `json:"one"`
+		Other string `json:"other"`
+	}
+	if err := json.Unmarshal(data, &forms); err != nil {
+		return err
+	}
+	m.One, m.Other = forms.One, forms.Other
+	return nil
+}
+
+var catalogues = map[string]map[string]message{}
+
+func init() {
+	for _, lang := range Languages {
+		data, err := localeFS.ReadFile(path.Join("locales", lang+".json"))
+		if err != nil {
+			log.Fatalf("Missing message catalogue for %q: %v", lang, err)
+		}
+
+		catalogue := map[string]message{}
+		if err := json.Unmarshal(data, &catalogue); err != nil {
+			log.Fatalf("Invalid message catalogue for %q: %v", lang, err)
+		}
+		catalogues[lang] = catalogue
+	}
+}
+
+// NormalizeLanguage maps a free-text language value (User.Language or an
+// Accept-Language tag) to a supported language, defaulting to English
+func NormalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	switch {
+	case language == "np", language == "nepali", language == "नेपाली",
+		language == Nepali, strings.HasPrefix(language, Nepali+"-"):
+		return Nepali
+	default:
+		return English
+	}
+}
+
+// FromRequest picks the language from the Accept-Language header, honouring
+// quality values, and falls back to English
+func FromRequest(c *gin.Context) string {
+	header := c.GetHeader("Accept-Language")
+	if header == "" {
+		return English
+	}
+
+	type tag struct {
+		lang    string
+		quality float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		quality := 1.0
+		for _, f := range fields[1:] {
+			if q, ok := strings.CutPrefix(strings.TrimSpace(f), "q="); ok {
+				if v, err := strconv.ParseFloat(q, 64); err == nil {
+					quality = v
+				}
+			}
+		}
+		tags = append(tags, tag{lang: fields[0], quality: quality})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].quality > tags[j].quality })
+
+	for _, t := range tags {
+		lang := strings.ToLower(t.lang)
+		if lang == English || strings.HasPrefix(lang, English+"-") {
+			return English
+		}
+		if NormalizeLanguage(lang) == Nepali {
+			return Nepali
+		}
+	}
+	return English
+}
+
+// T returns the message id in the given language with {name} placeholders
+// replaced by params. Missing translations fall back to English and then to
+// the id itself.
+func T(language, id string, params map[string]interface{}) string {
+	msg, ok := catalogues[NormalizeLanguage(language)][id]
+	if !ok {
+		if msg, ok = catalogues[English][id]; !ok {
+			return id
+		}
+	}
+
+	text := msg.Other
+	if msg.One != "" && isOne(params["count"]) {
+		text = msg.One
+	}
+
+	for name, value := range params {
+		text = strings.ReplaceAll(text, "{"+name+"}", fmt.Sprint(value))
+	}
+	return text
+}
+
+// languageKey caches the language of a request in the gin context
+const languageKey = "language"
+
+// FromContext picks the language of the signed in user, falling back to the
+// Accept-Language header for anonymous requests and users without one
+func FromContext(c *gin.Context) string {
+	if language := c.GetString(languageKey); language != "" {
+		return language
+	}
+
+	language := ""
+	if userID, ok := c.Get("userID"); ok && database.DB != nil {
+		var user models.User
+		if err := database.DB.Select("language").First(&user, userID).Error; err == nil && user.Language != "" {
+			language = NormalizeLanguage(user.Language)
+		}
+	}
+	if language == "" {
+		language = FromRequest(c)
+	}
+	c.Set(languageKey, language)
+	return language
+}
+
+// Tc translates id for the language of the current request
+func Tc(c *gin.Context, id string, params map[string]interface{}) string {
+	return T(FromContext(c), id, params)
+}
+
+// MissingKeys reports, per language, the message ids present in any
+// catalogue but missing from that language
+func MissingKeys() map[string][]string {
+	all := map[string]bool{}
+	for _, catalogue := range catalogues {
+		for id := range catalogue {
+			all[id] = true
+		}
+	}
+
+	missing := map[string][]string{}
+	for _, lang := range Languages {
+		for id := range all {
+			msg, ok := catalogues[lang][id]
+			switch {
+			case !ok:
+				missing[lang] = append(missing[lang], id)
+			case msg.One == "" && catalogues[English][id].One != "":
+				missing[lang] = append(missing[lang], id+" (plural form \"one\")")
+			}
+		}
+		sort.Strings(missing[lang])
+	}
+	return missing
+}
+
+func isOne(count interface{}) bool {
+	switch n := count.(type) {
+	case int:
+		return n == 1
+	case int64:
+		return n == 1
+	case uint:
+		return n == 1
+	case float64:
+		return n == 1
+	default:
+		return false
+	}
+}
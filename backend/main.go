@@ -1,8 +1,16 @@
 package main
 
 import (
+	"agro-connect/auction"
+	"agro-connect/availability"
 	"agro-connect/config"
 	"agro-connect/database"
+	"agro-connect/market"
+	"agro-connect/media"
+	"agro-connect/middleware"
+	"agro-connect/notify"
+	"agro-connect/search"
+	"agro-connect/storage"
 	"log"
 	"os"
 	"strings"
@@ -16,12 +24,30 @@ import (
 
 func main() {
 	config.LoadEnv()
+	if err := storage.Setup(); err != nil {
+		log.Fatal("Failed to set up storage: ", err)
+	}
 	database.Connect()
+	notify.Setup()
+	notify.StartScheduler()
+	media.Setup()
+	go func() {
+		media.BackfillProductImages(database.DB)
+		media.BackfillFingerprints(database.DB)
+	}()
+	market.Setup()
+	market.StartScheduler()
+	availability.StartScheduler()
+	auction.StartScheduler()
 
-	router := gin.Default()
-	router.RedirectTrailingSlash = false
+	if err := search.ReloadSynonyms(database.DB); err != nil {
+		log.Println("Failed to load search synonyms:", err)
+	}
 
-	router.Static("/uploads", "./uploads")
+	// gin.Default with a logger that keeps stream tokens out of the log
+	router := gin.New()
+	router.Use(middleware.Logger(), gin.Recovery())
+	router.RedirectTrailingSlash = false
 
 	//Load cors origins form .env
 	allowedOrigins := strings.Split(os.Getenv("CORS_ORIGIN"), ",")
@@ -30,8 +56,8 @@ func main() {
 	// Apply CORS middleware
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     allowedOrigins,
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type", "Last-Event-ID"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -45,6 +71,18 @@ func main() {
 	routes.RegisterProductRoutes(router)
 	routes.RegisterOfferRoutes(router)
 	routes.RegisterOrderRoutes(router)
+	routes.RegisterCartRoutes(router)
+	routes.RegisterPreOrderRoutes(router)
+	routes.RegisterRFQRoutes(router)
+	routes.RegisterAuctionRoutes(router)
+	routes.RegisterNotificationRoutes(router)
+	routes.RegisterRealtimeRoutes(router)
+	routes.RegisterSearchRoutes(router)
+	routes.RegisterCategoryRoutes(router)
+	routes.RegisterUnitRoutes(router)
+	routes.RegisterUploadRoutes(router)
+	routes.RegisterMarketRoutes(router)
+	routes.RegisterModerationRoutes(router)
 
 	port := os.Getenv("PORT")
 	if port == "" {
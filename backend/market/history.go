@@ -0,0 +1,67 @@
+package market
+
+import (
+	"agro-connect/database"
+	"agro-connect/models"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// farmerDistrictSQL selects the district of a farmer from the latest farmer
+// profile, falling back to the user's district
+const farmerDistrictSQL = `COALESCE(
+	(SELECT NULLIF(TRIM(fp.district), '') FROM farmer_profiles fp
+		WHERE fp.user_id = %s AND fp.deleted_at IS NULL
+		ORDER BY fp.id DESC LIMIT 1),
+	(SELECT TRIM(u.district) FROM users u WHERE u.id = %s),
+	'')`
+
+// RecordPrice adds the current price of a product to its price history. db
+// may be a transaction.
+func RecordPrice(db *gorm.DB, product models.Product) error {
+	var district string
+	if err := db.Raw("SELECT "+strings.ReplaceAll(farmerDistrictSQL, "%s", "?"), product.UserID, product.UserID).
+		Scan(&district).Error; err != nil {
+		return err
+	}
+
+	return db.Create(&models.ProductPriceHistory{
+		ProductID:    product.ID,
+		RecordedAt:   time.Now(),
+		CategoryID:   product.CategoryID,
+		Category:     product.Category,
+		District:     district,
+		Unit:         product.Unit,
+		PricePerUnit: product.PricePerUnit,
+		PricePerKg:   product.PricePerKg,
+	}).Error
+}
+
+// PriceChanged reports whether an edit changed the price of a listing, so
+// it needs a new price history record
+func PriceChanged(previous, current models.Product) bool {
+	return previous.PricePerUnit != current.PricePerUnit || previous.Unit != current.Unit
+}
+
+// Setup starts the price history of products listed before it existed and
+// dates the orders completed before CompletedAt was tracked
+func Setup() {
+	if err := database.DB.Exec(`
+		INSERT INTO product_price_histories
+			(product_id, recorded_at, category_id, category, district, unit, price_per_unit, price_per_kg)
+		SELECT p.id, p.created_at, p.category_id, p.category, ` +
+		strings.ReplaceAll(farmerDistrictSQL, "%s", "p.user_id") + `,
+			p.unit, p.price_per_unit, p.price_per_kg
+		FROM products p
+		WHERE NOT EXISTS (SELECT 1 FROM product_price_histories h WHERE h.product_id = p.id)`).Error; err != nil {
+		log.Println("Failed to seed price history:", err)
+	}
+
+	if err := database.DB.Exec(`UPDATE orders SET completed_at = updated_at
+		WHERE status = 'completed' AND completed_at IS NULL`).Error; err != nil {
+		log.Println("Failed to backfill order completion dates:", err)
+	}
+}
@@ -0,0 +1,394 @@
+package market
+
+import (
+	"agro-connect/database"
+	"agro-connect/models"
+	"agro-connect/search"
+	"agro-connect/units"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultMarket is the market of imported sheets that do not name one
+const DefaultMarket = "kalimati"
+
+// maxReferenceAgeDays is how old a reference price may be to still be shown
+// next to listings
+const maxReferenceAgeDays = 14
+
+// produceCategories places produce without a category of its own in the
+// default category tree
+var produceCategories = map[string]string{
+	"cauliflower":  "vegetable",
+	"cabbage":      "leafy-vegetable",
+	"coriander":    "leafy-vegetable",
+	"radish":       "root-vegetable",
+	"carrot":       "root-vegetable",
+	"chilli":       "fruit-vegetable",
+	"pumpkin":      "fruit-vegetable",
+	"cucumber":     "fruit-vegetable",
+	"eggplant":     "fruit-vegetable",
+	"okra":         "fruit-vegetable",
+	"beans":        "fruit-vegetable",
+	"peas":         "fruit-vegetable",
+	"bitter gourd": "fruit-vegetable",
+	"mushroom":     "vegetable",
+	"garlic":       "spice",
+	"ginger":       "spice",
+	"cardamom":     "spice",
+	"rice":         "grain",
+	"paddy":        "grain",
+	"maize":        "grain",
+	"wheat":        "grain",
+	"lentil":       "grain",
+	"soybean":      "grain",
+	"milk":         "dairy",
+	"ghee":         "dairy",
+	"apple":        "fruit",
+	"orange":       "fruit",
+	"banana":       "fruit",
+	"mango":        "fruit",
+	"lemon":        "fruit",
+	"honey":        "other",
+}
+
+// NormalizeCommodity is the form of a commodity name used as mapping key
+func NormalizeCommodity(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+var qualifierPattern = regexp.MustCompile(`\([^)]*\)`)
+
+// ProduceOf returns the canonical produce named in a product or commodity
+// name, e.g. "tomato" for "Tomato Big(Nepali)" or "Fresh golbheda", or ""
+// when it names no known produce. Two-word produce such as "bitter gourd"
+// is checked before single words.
+func ProduceOf(name string) string {
+	terms := search.Terms(qualifierPattern.ReplaceAllString(name, " "))
+	var candidates []string
+	for i := 0; i+1 < len(terms); i++ {
+		candidates = append(candidates, terms[i]+" "+terms[i+1])
+	}
+	candidates = append(candidates, terms...)
+
+	index := produceIndex()
+	for _, candidate := range candidates {
+		if canonicals := index[candidate]; len(canonicals) > 0 {
+			sort.Strings(canonicals)
+			return canonicals[0]
+		}
+	}
+	return ""
+}
+
+// produceIndex maps every term of the synonym dictionary to the canonical
+// names of the groups containing it
+func produceIndex() map[string][]string {
+	index := map[string][]string{}
+	for canonical, terms := range search.SynonymGroups() {
+		index[canonical] = append(index[canonical], canonical)
+		for _, term := range terms {
+			if term != canonical {
+				index[term] = append(index[term], canonical)
+			}
+		}
+	}
+	return index
+}
+
+// sheetUnitAliases are spellings of units in price sheets that the unit
+// registry does not know
+var sheetUnitAliases = map[string]string{
+	"के.जी": "kg",
+}
+
+// sheetUnit returns the registry code of a unit as written in a price sheet,
+// e.g. "Kg", "1 Pc", "Per Doz" or "गोटा", or the name as given when it is
+// unknown
+func sheetUnit(unit string) string {
+	name := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(unit)), "per "), "1 ")
+	if code, ok := sheetUnitAliases[name]; ok {
+		return code
+	}
+	if code, err := units.Normalize(name); err == nil {
+		return code
+	}
+	for _, u := range units.All() {
+		if u.NameNp == name {
+			return u.Code
+		}
+	}
+	return unit
+}
+
+// resolver maps commodity names to categories and produce using the
+// commodity mappings, falling back to the synonym dictionary
+type resolver struct {
+	mappings   map[string]models.CommodityMapping
+	categories map[uint]models.Category
+	bySlug     map[string]models.Category
+}
+
+func newResolver(db *gorm.DB) (*resolver, error) {
+	var mappings []models.CommodityMapping
+	if err := db.Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	var categories []models.Category
+	if err := db.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	r := &resolver{
+		mappings:   map[string]models.CommodityMapping{},
+		categories: map[uint]models.Category{},
+		bySlug:     map[string]models.Category{},
+	}
+	for _, m := range mappings {
+		r.mappings[m.Commodity] = m
+	}
+	for _, c := range categories {
+		r.categories[c.ID] = c
+		r.bySlug[c.Slug] = c
+	}
+	return r, nil
+}
+
+// resolve fills the category and produce of a reference price
+func (r *resolver) resolve(price *models.ReferencePrice) {
+	price.CategoryID, price.Category, price.Produce = nil, "", ""
+
+	if m, ok := r.mappings[NormalizeCommodity(price.Commodity)]; ok {
+		price.Produce = m.Produce
+		if m.CategoryID != nil {
+			if category, ok := r.categories[*m.CategoryID]; ok {
+				price.CategoryID, price.Category = &category.ID, category.Slug
+			}
+		}
+	} else {
+		price.Produce = ProduceOf(price.Commodity)
+		if price.Produce != "" {
+			slug := strings.ReplaceAll(price.Produce, " ", "-")
+			category, ok := r.bySlug[slug]
+			if !ok {
+				category, ok = r.bySlug[produceCategories[price.Produce]]
+			}
+			if ok {
+				price.CategoryID, price.Category = &category.ID, category.Slug
+			}
+		}
+	}
+
+	price.AvgPricePerKg = nil
+	if unit, ok := units.Lookup(price.Unit); ok {
+		if perKg, ok := units.PricePerKg(price.AvgPrice, unit, r.density(price.CategoryID)); ok {
+			perKg = math.Round(perKg*100) / 100
+			price.AvgPricePerKg = &perKg
+		}
+	}
+}
+
+// density returns the kg per litre of a category, inherited from its
+// closest ancestor that sets one
+func (r *resolver) density(categoryID *uint) float64 {
+	for depth := 0; categoryID != nil && depth < 10; depth++ {
+		category, ok := r.categories[*categoryID]
+		if !ok {
+			break
+		}
+		if category.KgPerLitre > 0 {
+			return category.KgPerLitre
+		}
+		categoryID = category.ParentID
+	}
+	return 0
+}
+
+// ImportResult summarizes an imported price sheet
+type ImportResult struct {
+	Market   string     `json:"market"`
+	Dates    []string   `json:"dates"`
+	Imported int        `json:"imported"`
+	Unmapped []string   `json:"unmapped"` // commodities matching no produce
+	Errors   []RowError `json:"errors"`
+}
+
+// ImportReferencePrices parses a CSV or XLSX price sheet and stores its rows
+// as reference prices of market. date is used for sheets without a date
+// column. Prices already imported for the same market, day and commodity are
+// replaced, so a corrected sheet can be imported again.
+func ImportReferencePrices(r io.Reader, filename, market string, date time.Time) (*ImportResult, error) {
+	market = strings.ToLower(strings.TrimSpace(market))
+	if market == "" {
+		market = DefaultMarket
+	}
+
+	rows, rowErrors, err := ParsePriceSheet(r, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := newResolver(database.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Market: market, Dates: []string{}, Unmapped: []string{}, Errors: rowErrors}
+	dates := map[string]bool{}
+	unmapped := map[string]bool{}
+	seen := map[string]int{} // a repeated commodity replaces the earlier row
+	var prices []models.ReferencePrice
+	for _, row := range rows {
+		day := row.Date
+		if day.IsZero() {
+			day = date
+		}
+		if day.IsZero() {
+			result.Errors = append(result.Errors, RowError{Row: row.Line, Error: row.Commodity + ": no date given"})
+			continue
+		}
+
+		price := models.ReferencePrice{
+			Market:    market,
+			Date:      Day(day),
+			Commodity: row.Commodity,
+			Unit:      sheetUnit(row.Unit),
+			MinPrice:  row.Min,
+			MaxPrice:  row.Max,
+			AvgPrice:  row.Avg,
+		}
+		res.resolve(&price)
+		if price.Produce == "" && !unmapped[price.Commodity] {
+			unmapped[price.Commodity] = true
+			result.Unmapped = append(result.Unmapped, price.Commodity)
+		}
+		dates[price.Date.Format("2006-01-02")] = true
+
+		key := price.Date.Format("2006-01-02") + "\n" + price.Commodity
+		if i, ok := seen[key]; ok {
+			prices[i] = price
+			continue
+		}
+		seen[key] = len(prices)
+		prices = append(prices, price)
+	}
+
+	if len(prices) > 0 {
+		if err := database.DB.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "market"}, {Name: "date"}, {Name: "commodity"}},
+			DoUpdates: clause.AssignmentColumns([]string{"category_id", "category", "produce", "unit",
+				"min_price", "max_price", "avg_price", "avg_price_per_kg", "updated_at"}),
+		}).CreateInBatches(&prices, 200).Error; err != nil {
+			return nil, fmt.Errorf("failed to save reference prices: %w", err)
+		}
+	}
+
+	for d := range dates {
+		result.Dates = append(result.Dates, d)
+	}
+	sort.Strings(result.Dates)
+	result.Imported = len(prices)
+	return result, nil
+}
+
+// RemapReferencePrices applies the current commodity mappings to every
+// stored reference price. Call it after mappings change.
+func RemapReferencePrices() error {
+	res, err := newResolver(database.DB)
+	if err != nil {
+		return err
+	}
+
+	var prices []models.ReferencePrice
+	return database.DB.FindInBatches(&prices, 500, func(tx *gorm.DB, batch int) error {
+		for i := range prices {
+			res.resolve(&prices[i])
+			if err := database.DB.Model(&prices[i]).Select("category_id", "category", "produce", "avg_price_per_kg").
+				Updates(&prices[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// AttachReferencePrices sets the ReferencePrice of every product naming a
+// known produce to the latest price of that produce. Among the commodities
+// of that day, one in the listing's unit whose name shares most words with
+// the listing is preferred.
+func AttachReferencePrices(products []models.Product) error {
+	produceOf := make([]string, len(products))
+	wanted := map[string]bool{}
+	for i, p := range products {
+		produce := ProduceOf(p.NameEn)
+		if produce == "" {
+			produce = ProduceOf(p.NameNp)
+		}
+		if produce == "" {
+			produce = ProduceOf(strings.ReplaceAll(p.Category, "-", " "))
+		}
+		produceOf[i] = produce
+		if produce != "" {
+			wanted[produce] = true
+		}
+	}
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	var names []string
+	for produce := range wanted {
+		names = append(names, produce)
+	}
+	var prices []models.ReferencePrice
+	if err := database.DB.Raw(`SELECT r.* FROM reference_prices r
+		WHERE r.produce IN ? AND r.date >= ?
+			AND r.date = (SELECT MAX(date) FROM reference_prices l WHERE l.produce = r.produce)
+		ORDER BY r.id`, names, Today().AddDate(0, 0, -maxReferenceAgeDays).Format("2006-01-02")).
+		Scan(&prices).Error; err != nil {
+		return err
+	}
+
+	byProduce := map[string][]models.ReferencePrice{}
+	for _, price := range prices {
+		byProduce[price.Produce] = append(byProduce[price.Produce], price)
+	}
+
+	for i := range products {
+		candidates := byProduce[produceOf[i]]
+		if len(candidates) == 0 {
+			continue
+		}
+		words := map[string]bool{}
+		for _, term := range search.Terms(products[i].NameEn + " " + products[i].NameNp) {
+			words[term] = true
+		}
+
+		best, bestScore := -1, -1
+		for j, candidate := range candidates {
+			score := 0
+			if candidate.Unit == products[i].Unit {
+				score += 100
+			}
+			for _, term := range search.Terms(candidate.Commodity) {
+				if words[term] {
+					score++
+				}
+			}
+			if score > bestScore {
+				best, bestScore = j, score
+			}
+		}
+		price := candidates[best]
+		products[i].ReferencePrice = &price
+	}
+	return nil
+}
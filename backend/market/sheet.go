@@ -0,0 +1,90 @@
+package market
+
+import (
+	"agro-connect/spreadsheet"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// SheetRow is one commodity of a daily price sheet
+type SheetRow struct {
+	Line      int // 1-based row in the sheet, for error messages
+	Commodity string
+	Unit      string
+	Min       float64
+	Max       float64
+	Avg       float64
+	Date      time.Time // zero when the sheet has no date column
+}
+
+// RowError reports a row of a price sheet that could not be imported
+type RowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// sheetColumns lists the accepted headers of each column, in English and
+// Nepali, compared after lower-casing and dropping punctuation. A header
+// also matches when it starts with an accepted name, e.g. "Minimum (Rs.)".
+var sheetColumns = map[string][]string{
+	"commodity": {"commodity", "commodities", "item", "name", "produce", "कृषि उपज", "वस्तु"},
+	"unit":      {"unit", "ईकाई", "इकाई", "ईकाइ", "इकाइ"},
+	"min":       {"minimum", "min", "न्यूनतम"},
+	"max":       {"maximum", "max", "अधिकतम"},
+	"avg":       {"average", "avg", "mean", "औसत"},
+	"date":      {"date", "मिति"},
+}
+
+// ParsePriceSheet reads a CSV or XLSX price sheet, chosen by the file
+// extension. Rows above the header, such as a title, are skipped. Rows that
+// cannot be parsed are returned as errors instead of failing the sheet.
+func ParsePriceSheet(r io.Reader, filename string) ([]SheetRow, []RowError, error) {
+	records, err := spreadsheet.ReadRecords(r, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header, columns := spreadsheet.FindHeader(records, sheetColumns, "commodity", "min", "max", "avg")
+	if header < 0 {
+		return nil, nil, fmt.Errorf("no header row with commodity, minimum, maximum and average columns found")
+	}
+
+	var rows []SheetRow
+	var errs []RowError
+	for i := header + 1; i < len(records); i++ {
+		record := records[i]
+		cell := func(column string) string {
+			index, ok := columns[column]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		row := SheetRow{Line: i + 1, Commodity: strings.Join(strings.Fields(cell("commodity")), " "), Unit: cell("unit")}
+		if row.Commodity == "" {
+			continue
+		}
+
+		var err error
+		if row.Min, err = spreadsheet.ParseNumber(cell("min")); err == nil {
+			if row.Max, err = spreadsheet.ParseNumber(cell("max")); err == nil {
+				row.Avg, err = spreadsheet.ParseNumber(cell("avg"))
+			}
+		}
+		if err == nil && (row.Min > row.Max || row.Avg < row.Min || row.Avg > row.Max) {
+			err = fmt.Errorf("prices must satisfy minimum <= average <= maximum")
+		}
+		if err == nil && cell("date") != "" {
+			row.Date, err = spreadsheet.ParseDate(cell("date"))
+		}
+		if err != nil {
+			errs = append(errs, RowError{Row: row.Line, Error: row.Commodity + ": " + err.Error()})
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, errs, nil
+}
@@ -0,0 +1,171 @@
+package market
+
+import (
+	"agro-connect/database"
+	"agro-connect/models"
+	"database/sql"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// statsInterval is how often the statistics of the current day are
+	// refreshed
+	statsInterval = time.Hour
+	// maxBackfillDays limits how far back missing statistics are computed on
+	// startup
+	maxBackfillDays = 366
+)
+
+// Nepal has no daylight saving time, so a fixed zone matches the database
+// session time zone without depending on tzdata
+var nepalTime = time.FixedZone("Asia/Kathmandu", (5*60+45)*60)
+
+// Today returns the current date in Nepal
+func Today() time.Time {
+	return Day(time.Now())
+}
+
+// Day returns the date of t in Nepal, at midnight
+func Day(t time.Time) time.Time {
+	y, m, d := t.In(nepalTime).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, nepalTime)
+}
+
+// dailyStatsSQL aggregates the listing prices in effect at the end of @day,
+// i.e. the latest history record of every product, and the orders and order
+// lines completed on @day. Only the current status of a listing is known, so
+// it filters today's listings only; past days count every listing that was
+// not deleted by the end of the day. Each group is computed per district and
+// for the whole country, the latter stored with an empty district.
+const dailyStatsSQL = `
+WITH prices AS (
+	SELECT DISTINCT ON (h.product_id) h.category, h.unit,
+		NULLIF(INITCAP(h.district), '') AS district, h.price_per_unit, h.price_per_kg
+	FROM product_price_histories h
+	JOIN products p ON p.id = h.product_id
+	WHERE h.recorded_at < @day::date + 1
+		AND (p.deleted_at IS NULL OR p.deleted_at >= @day::date + 1)
+		AND (@day::date < @today::date OR p.status = 'available')
+	ORDER BY h.product_id, h.recorded_at DESC, h.id DESC
+),
+listing_stats AS (
+	SELECT category, unit, CASE WHEN GROUPING(district) = 1 THEN '' ELSE district END AS district,
+		COUNT(*) AS listings,
+		MIN(price_per_unit) AS min_price,
+		MAX(price_per_unit) AS max_price,
+		ROUND(percentile_cont(0.5) WITHIN GROUP (ORDER BY price_per_unit)::numeric, 2) AS median_price,
+		ROUND(percentile_cont(0.5) WITHIN GROUP (ORDER BY price_per_kg)::numeric, 2) AS median_price_per_kg
+	FROM prices
+	GROUP BY GROUPING SETS ((category, unit, district), (category, unit))
+	HAVING GROUPING(district) = 1 OR district IS NOT NULL
+),
+sales AS (
+	SELECT l.category, l.unit, NULLIF(INITCAP(l.district), '') AS district,
+		CASE WHEN f.listing_quantity > 0 THEN f.listing_quantity ELSE f.quantity END AS quantity
+	FROM orders o
+	JOIN offers f ON f.id = o.offer_id
+	-- the listing as it was when the order was completed
+	JOIN LATERAL (
+		SELECT h.category, h.unit, h.district
+		FROM product_price_histories h
+		WHERE h.product_id = o.product_id
+		ORDER BY h.recorded_at <= o.completed_at DESC, h.recorded_at DESC, h.id DESC
+		LIMIT 1
+	) l ON true
+	WHERE o.status = 'completed' AND o.deleted_at IS NULL
+		AND o.completed_at >= @day::date AND o.completed_at < @day::date + 1
+	UNION ALL
+	-- lines of orders placed from the cart, counted as each line completes
+	SELECT l.category, l.unit, NULLIF(INITCAP(l.district), '') AS district, i.quantity
+	FROM order_items i
+	JOIN orders o ON o.id = i.order_id
+	JOIN LATERAL (
+		SELECT h.category, h.unit, h.district
+		FROM product_price_histories h
+		WHERE h.product_id = i.product_id
+		ORDER BY h.recorded_at <= i.completed_at DESC, h.recorded_at DESC, h.id DESC
+		LIMIT 1
+	) l ON true
+	WHERE i.status = 'completed' AND i.deleted_at IS NULL AND o.deleted_at IS NULL
+		AND i.completed_at >= @day::date AND i.completed_at < @day::date + 1
+),
+sale_stats AS (
+	SELECT category, unit, CASE WHEN GROUPING(district) = 1 THEN '' ELSE district END AS district,
+		SUM(quantity) AS volume, COUNT(*) AS orders
+	FROM sales
+	GROUP BY GROUPING SETS ((category, unit, district), (category, unit))
+	HAVING GROUPING(district) = 1 OR district IS NOT NULL
+)
+INSERT INTO daily_price_stats (date, category, unit, district, listings, min_price, max_price,
+	median_price, median_price_per_kg, volume, orders, updated_at)
+SELECT @day::date, COALESCE(l.category, s.category), COALESCE(l.unit, s.unit), COALESCE(l.district, s.district),
+	COALESCE(l.listings, 0), COALESCE(l.min_price, 0), COALESCE(l.max_price, 0), COALESCE(l.median_price, 0),
+	l.median_price_per_kg, COALESCE(s.volume, 0), COALESCE(s.orders, 0), NOW()
+FROM listing_stats l
+FULL JOIN sale_stats s ON s.category = l.category AND s.unit = l.unit AND s.district = l.district`
+
+// RefreshDailyStats recomputes the statistics of the day of t
+func RefreshDailyStats(t time.Time) error {
+	day := Day(t).Format("2006-01-02")
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("date = ?", day).Delete(&models.DailyPriceStat{}).Error; err != nil {
+			return err
+		}
+		return tx.Exec(dailyStatsSQL, sql.Named("day", day), sql.Named("today", Today().Format("2006-01-02"))).Error
+	})
+}
+
+// StartScheduler computes the statistics missing since the last run, then
+// keeps the current day up to date. The previous day is refreshed once more
+// after midnight to include its last hour.
+func StartScheduler() {
+	go func() {
+		backfillDailyStats()
+
+		ticker := time.NewTicker(statsInterval)
+		defer ticker.Stop()
+		last := Today()
+		for now := range ticker.C {
+			today := Day(now)
+			if today.After(last) {
+				refresh(last)
+				last = today
+			}
+			refresh(today)
+		}
+	}()
+}
+
+// backfillDailyStats refreshes every day from the last computed day, or the
+// first price record, until today
+func backfillDailyStats() {
+	var start sql.NullTime
+	if err := database.DB.Model(&models.DailyPriceStat{}).Select("MAX(date)").Scan(&start).Error; err != nil {
+		log.Println("Failed to load market price statistics:", err)
+		return
+	}
+	if !start.Valid {
+		if err := database.DB.Model(&models.ProductPriceHistory{}).Select("MIN(recorded_at)").Scan(&start).Error; err != nil || !start.Valid {
+			refresh(Today())
+			return
+		}
+	}
+
+	today := Today()
+	from := Day(start.Time)
+	if oldest := today.AddDate(0, 0, -maxBackfillDays); from.Before(oldest) {
+		from = oldest
+	}
+	for day := from; !day.After(today); day = day.AddDate(0, 0, 1) {
+		refresh(day)
+	}
+}
+
+func refresh(day time.Time) {
+	if err := RefreshDailyStats(day); err != nil {
+		log.Printf("Failed to refresh market prices of %s: %v", day.Format("2006-01-02"), err)
+	}
+}
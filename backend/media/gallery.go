@@ -0,0 +1,122 @@
+package media
+
+import (
+	"agro-connect/models"
+	"agro-connect/storage"
+	"context"
+	"log"
+	"path"
+
+	"gorm.io/gorm"
+)
+
+// SyncPrimaryImage makes sure a product with images has exactly one primary
+// image, promoting the first one when needed, and copies its URLs onto the
+// product for clients that only read Product.ImageURL
+func SyncPrimaryImage(db *gorm.DB, productID uint) error {
+	var images []models.ProductImage
+	if err := db.Where("product_id = ?", productID).Order("position, id").Find(&images).Error; err != nil {
+		return err
+	}
+
+	var primary *models.ProductImage
+	for i := range images {
+		if images[i].IsPrimary {
+			primary = &images[i]
+			break
+		}
+	}
+	if primary == nil && len(images) > 0 {
+		primary = &images[0]
+	}
+
+	imageURL, thumbnailURL := "", ""
+	if primary != nil {
+		if err := db.Model(&models.ProductImage{}).
+			Where("product_id = ? AND id <> ?", productID, primary.ID).
+			Update("is_primary", false).Error; err != nil {
+			return err
+		}
+		if err := db.Model(primary).Update("is_primary", true).Error; err != nil {
+			return err
+		}
+		imageURL, thumbnailURL = primary.URL, primary.ThumbnailURL
+	}
+
+	return db.Model(&models.Product{}).Where("id = ?", productID).
+		Updates(map[string]interface{}{"image_url": imageURL, "thumbnail_url": thumbnailURL}).Error
+}
+
+// BackfillProductImages moves the single image of products created before
+// galleries existed into their gallery, generating the variants and deleting
+// the original, which may still carry EXIF data
+func BackfillProductImages(db *gorm.DB) {
+	var products []models.Product
+	if err := db.Where("image_url <> '' AND NOT EXISTS (SELECT 1 FROM product_images WHERE product_images.product_id = products.id)").
+		Find(&products).Error; err != nil {
+		log.Println("Failed to load products for image backfill:", err)
+		return
+	}
+
+	for _, product := range products {
+		f, err := storage.Open(context.Background(), product.ImageURL)
+		if err != nil {
+			log.Printf("Skipping image of product %d: %v", product.ID, err)
+			continue
+		}
+		upload, err := Validate(context.Background(), path.Base(product.ImageURL), f)
+		f.Close()
+		if err != nil {
+			log.Printf("Skipping image of product %d: %v", product.ID, err)
+			continue
+		}
+		image, err := SaveProductImage(product.ID, upload)
+		if err != nil {
+			log.Printf("Failed to process image of product %d: %v", product.ID, err)
+			continue
+		}
+
+		image.IsPrimary = true
+		if err := db.Create(&image).Error; err != nil {
+			RemoveFiles(image.Files()...)
+			log.Printf("Failed to save image of product %d: %v", product.ID, err)
+			continue
+		}
+		if err := SyncPrimaryImage(db, product.ID); err != nil {
+			log.Printf("Failed to update image of product %d: %v", product.ID, err)
+			continue
+		}
+		RemoveFiles(product.ImageURL)
+	}
+}
+
+// BackfillFingerprints computes the fingerprint of gallery images stored
+// before fingerprints existed, from their largest stored variant
+func BackfillFingerprints(db *gorm.DB) {
+	var images []models.ProductImage
+	if err := db.Where("fingerprint IS NULL OR fingerprint = ''").Find(&images).Error; err != nil {
+		log.Println("Failed to load images for fingerprint backfill:", err)
+		return
+	}
+
+	for _, image := range images {
+		f, err := storage.Open(context.Background(), image.URL)
+		if err != nil {
+			log.Printf("Skipping fingerprint of image %d: %v", image.ID, err)
+			continue
+		}
+		img, _, err := decode(f)
+		f.Close()
+		if err != nil {
+			log.Printf("Skipping fingerprint of image %d: %v", image.ID, err)
+			continue
+		}
+		fingerprint := Fingerprint(img)
+		if fingerprint == "" {
+			continue
+		}
+		if err := db.Model(&image).Update("fingerprint", fingerprint).Error; err != nil {
+			log.Printf("Failed to save fingerprint of image %d: %v", image.ID, err)
+		}
+	}
+}
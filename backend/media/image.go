@@ -0,0 +1,153 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp" // decode WebP uploads
+)
+
+// Variant is a size generated for every uploaded image
+type Variant struct {
+	Name    string
+	MaxSize int  // longest side in pixels
+	Square  bool // crop to a centered square
+}
+
+// Variant names
+const (
+	VariantFull      = "full"
+	VariantMedium    = "medium"
+	VariantThumbnail = "thumbnail"
+)
+
+// Variants lists the sizes generated on upload. The full variant replaces
+// the original, which is never stored.
+var Variants = []Variant{
+	{Name: VariantFull, MaxSize: 2048},
+	{Name: VariantMedium, MaxSize: 800},
+	{Name: VariantThumbnail, MaxSize: 240, Square: true},
+}
+
+// Formats of the encoded variants
+const (
+	FormatJPEG = "jpg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+const jpegQuality = 85
+
+// File is one encoded variant of an image
+type File struct {
+	Variant string
+	Format  string
+	Data    []byte
+	Width   int
+	Height  int
+}
+
+// Processed holds the variants of an uploaded image
+type Processed struct {
+	Width       int // of the full variant
+	Height      int
+	Fingerprint string
+	Files       []File
+}
+
+// Process decodes an uploaded image, applies its EXIF orientation and encodes
+// every variant as JPEG, or PNG for images with transparency, plus WebP when
+// an encoder is available. Re-encoding drops all metadata, including EXIF
+// location data.
+func Process(r io.Reader) (*Processed, error) {
+	img, format, err := decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	processed := &Processed{Fingerprint: Fingerprint(img)}
+	for _, v := range Variants {
+		resized := resize(img, v)
+		bounds := resized.Bounds()
+		if v.Name == VariantFull {
+			processed.Width, processed.Height = bounds.Dx(), bounds.Dy()
+		}
+
+		data, err := encode(resized, format)
+		if err != nil {
+			return nil, err
+		}
+		processed.Files = append(processed.Files, File{v.Name, format, data, bounds.Dx(), bounds.Dy()})
+
+		if webpEncoder == nil {
+			continue
+		}
+		webp, err := webpEncoder.EncodeWebP(resized)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode WebP: %w", err)
+		}
+		processed.Files = append(processed.Files, File{v.Name, FormatWebP, webp, bounds.Dx(), bounds.Dy()})
+	}
+	return processed, nil
+}
+
+// decode decodes an image with its EXIF orientation applied and picks the
+// format of its variants
+func decode(r io.Reader) (image.Image, string, error) {
+	img, err := imaging.Decode(r, imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	format := FormatJPEG
+	if o, ok := img.(interface{ Opaque() bool }); ok && !o.Opaque() {
+		format = FormatPNG
+	}
+	return img, format, nil
+}
+
+// Fingerprint returns a difference hash of an image: 64 bits telling whether
+// each pixel of a 9x8 grayscale copy is brighter than its right neighbour.
+// Resized and re-encoded copies of a photo share the hash. Images without
+// any detail, such as a blank picture, have no fingerprint.
+func Fingerprint(img image.Image) string {
+	small := imaging.Grayscale(imaging.Resize(img, 9, 8, imaging.Box))
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if small.NRGBAAt(x, y).R > small.NRGBAAt(x+1, y).R {
+				hash |= 1
+			}
+		}
+	}
+	if hash == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%016x", hash)
+}
+
+func resize(img image.Image, v Variant) image.Image {
+	if v.Square {
+		return imaging.Fill(img, v.MaxSize, v.MaxSize, imaging.Center, imaging.Lanczos)
+	}
+	// Fit never enlarges small images
+	return imaging.Fit(img, v.MaxSize, v.MaxSize, imaging.Lanczos)
+}
+
+func encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == FormatPNG {
+		err = imaging.Encode(&buf, img, imaging.PNG)
+	} else {
+		err = imaging.Encode(&buf, img, imaging.JPEG, imaging.JPEGQuality(jpegQuality))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
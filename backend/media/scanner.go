@@ -0,0 +1,83 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// ErrInfected is returned by scanners for files that contain malware
+var ErrInfected = errors.New("file rejected by malware scanner")
+
+// Scanner checks uploaded files for malware before they are processed
+type Scanner interface {
+	Scan(ctx context.Context, filename string, data []byte) error
+}
+
+type nopScanner struct{}
+
+func (nopScanner) Scan(ctx context.Context, filename string, data []byte) error { return nil }
+
+// scanner is called for every upload; the default accepts everything
+var scanner Scanner = nopScanner{}
+
+// SetScanner replaces the malware scanner; nil disables scanning
+func SetScanner(s Scanner) {
+	if s == nil {
+		s = nopScanner{}
+	}
+	scanner = s
+}
+
+// CommandScanner scans files with a command line scanner following the
+// clamscan convention: exit status 0 for clean files and 1 for infected ones
+type CommandScanner struct {
+	Path string
+	Args []string
+}
+
+// NewCommandScanner returns a scanner running the executable at path, or an
+// error when it cannot be found
+func NewCommandScanner(path string, args ...string) (*CommandScanner, error) {
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return &CommandScanner{Path: resolved, Args: args}, nil
+}
+
+// Scan writes the data to a temporary file and passes it to the scanner
+func (s *CommandScanner) Scan(ctx context.Context, filename string, data []byte) error {
+	f, err := os.CreateTemp("", "scan-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.Path, append(s.Args, f.Name())...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return ErrInfected
+	default:
+		return fmt.Errorf("malware scan of %s failed: %v: %s", filename, err, output.String())
+	}
+}
@@ -0,0 +1,136 @@
+package media
+
+import (
+	"agro-connect/config"
+	"agro-connect/models"
+	"agro-connect/storage"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+)
+
+// Setup configures the WebP encoder and the malware scanner from the media
+// configuration. WebP variants need the cwebp binary from libwebp, as Go has
+// no WebP encoder; without it the server stops when REQUIRE_WEBP is set and
+// otherwise warns that only JPEG and PNG variants are served.
+func Setup() {
+	if encoder, err := NewCWebPEncoder(config.Media.CWebPPath, config.Media.WebPQuality); err != nil {
+		if config.Media.RequireWebP {
+			log.Fatalf("cwebp not found at %q and REQUIRE_WEBP is set: %v", config.Media.CWebPPath, err)
+		}
+		log.Printf("WARNING: cwebp not found at %q (%v). WebP image variants are DISABLED and uploads get JPEG and PNG variants only. Install libwebp (cwebp) or set CWEBP_PATH, and set REQUIRE_WEBP=true to refuse to start without it.", config.Media.CWebPPath, err)
+		SetWebPEncoder(nil)
+	} else {
+		SetWebPEncoder(encoder)
+	}
+
+	if fields := strings.Fields(config.Media.ScanCommand); len(fields) > 0 {
+		s, err := NewCommandScanner(fields[0], fields[1:]...)
+		if err != nil {
+			log.Fatalf("Upload scanner %s not found: %v", fields[0], err)
+		}
+		SetScanner(s)
+	}
+}
+
+var contentTypes = map[string]string{
+	FormatJPEG: "image/jpeg",
+	FormatPNG:  "image/png",
+	FormatWebP: "image/webp",
+}
+
+// SaveProductImage processes a validated upload and stores its variants
+// under products/<productID>/. The returned image is not saved to the
+// database.
+func SaveProductImage(productID uint, upload *Upload) (models.ProductImage, error) {
+	processed, err := Process(bytes.NewReader(upload.Data))
+	if err != nil {
+		return models.ProductImage{}, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return models.ProductImage{}, err
+	}
+	dir := path.Join("products", fmt.Sprint(productID))
+
+	image := models.ProductImage{
+		ProductID:   productID,
+		Width:       processed.Width,
+		Height:      processed.Height,
+		Fingerprint: processed.Fingerprint,
+	}
+	for _, file := range processed.Files {
+		key := path.Join(dir, fmt.Sprintf("%s_%s.%s", token, file.Variant, file.Format))
+		url, err := storage.Put(context.Background(), key, bytes.NewReader(file.Data), int64(len(file.Data)), contentTypes[file.Format])
+		if err != nil {
+			RemoveFiles(image.Files()...)
+			return models.ProductImage{}, err
+		}
+		setVariantURL(&image, file, url)
+	}
+	return image, nil
+}
+
+// profilePicture is the only size stored for profile pictures
+var profilePicture = Variant{Name: "profile", MaxSize: 512, Square: true}
+
+// SaveProfilePicture re-encodes a validated upload as a square picture and
+// stores it under profiles/, returning its URL. Re-encoding drops metadata
+// and anything appended to the image data.
+func SaveProfilePicture(userID uint, upload *Upload) (string, error) {
+	img, format, err := decode(bytes.NewReader(upload.Data))
+	if err != nil {
+		return "", err
+	}
+	data, err := encode(resize(img, profilePicture), format)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("profiles/user_%d_%s.%s", userID, token, format)
+	return storage.Put(context.Background(), key, bytes.NewReader(data), int64(len(data)), contentTypes[format])
+}
+
+// randomToken makes stored file names unguessable
+func randomToken() (string, error) {
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+func setVariantURL(image *models.ProductImage, file File, url string) {
+	webp := file.Format == FormatWebP
+	switch {
+	case file.Variant == VariantFull && webp:
+		image.WebPURL = url
+	case file.Variant == VariantFull:
+		image.URL = url
+	case file.Variant == VariantMedium && webp:
+		image.MediumWebPURL = url
+	case file.Variant == VariantMedium:
+		image.MediumURL = url
+	case file.Variant == VariantThumbnail && webp:
+		image.ThumbnailWebPURL = url
+	case file.Variant == VariantThumbnail:
+		image.ThumbnailURL = url
+	}
+}
+
+// RemoveFiles deletes stored files by URL, logging failures
+func RemoveFiles(urls ...string) {
+	if err := storage.Remove(context.Background(), urls...); err != nil {
+		log.Printf("Failed to remove files: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// Limits applied to every uploaded image
+const (
+	MaxUploadSize = 2 * 1024 * 1024 // 2MB
+	MaxDimension  = 8000            // longest side in pixels
+	// MaxPixels bounds the memory needed to decode an image, so small files
+	// declaring huge dimensions (decompression bombs) are rejected before
+	// decoding
+	MaxPixels = 40_000_000
+)
+
+// imageTypes maps the allowed formats, as named by image.DecodeConfig, to
+// the content type sniffed from the same bytes
+var imageTypes = map[string]string{
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+	"gif":  "image/gif",
+	"webp": "image/webp",
+}
+
+// ValidationError reports an upload rejected because of its content. Its
+// message is safe to show to the client.
+type ValidationError struct {
+	msg string
+}
+
+func (e *ValidationError) Error() string { return e.msg }
+
+func invalid(format string, args ...interface{}) error {
+	return &ValidationError{msg: fmt.Sprintf(format, args...)}
+}
+
+// IsRejected reports whether err rejects the upload itself, as opposed to a
+// failure while checking it
+func IsRejected(err error) bool {
+	var v *ValidationError
+	return errors.As(err, &v) || errors.Is(err, ErrInfected)
+}
+
+// Upload is an uploaded image that passed validation
+type Upload struct {
+	Filename    string // sanitized client file name
+	Format      string // jpeg, png, gif or webp
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// ValidateImage reads and validates an uploaded image file
+func ValidateImage(ctx context.Context, file *multipart.FileHeader) (*Upload, error) {
+	if file.Size > MaxUploadSize {
+		return nil, invalid("image too large. Maximum size is %dMB", MaxUploadSize/(1024*1024))
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image file: %w", err)
+	}
+	defer f.Close()
+	return Validate(ctx, file.Filename, f)
+}
+
+// Validate is the validation pipeline shared by all image uploads. The
+// client's file name and content type are never trusted: the format is
+// taken from the decoded header and must agree with the sniffed content
+// type. The dimensions are checked before the image is decoded, and the
+// file is passed to the malware scanner last.
+func Validate(ctx context.Context, filename string, r io.Reader) (*Upload, error) {
+	filename = SanitizeFilename(filename)
+
+	// The declared size may lie, so never read more than the limit
+	data, err := io.ReadAll(io.LimitReader(r, MaxUploadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image file: %w", err)
+	}
+	if len(data) > MaxUploadSize {
+		return nil, invalid("image too large. Maximum size is %dMB", MaxUploadSize/(1024*1024))
+	}
+	if len(data) == 0 {
+		return nil, invalid("image file is empty")
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, invalid("invalid image type. Allowed: JPEG, PNG, GIF, WebP")
+	}
+	contentType, ok := imageTypes[format]
+	if !ok || http.DetectContentType(data) != contentType {
+		return nil, invalid("invalid image type. Allowed: JPEG, PNG, GIF, WebP")
+	}
+
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, invalid("invalid image dimensions")
+	}
+	if cfg.Width > MaxDimension || cfg.Height > MaxDimension ||
+		int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return nil, invalid("image dimensions too large. Maximum is %dx%d pixels", MaxDimension, MaxDimension)
+	}
+
+	if err := scanner.Scan(ctx, filename, data); err != nil {
+		return nil, err
+	}
+
+	return &Upload{
+		Filename:    filename,
+		Format:      format,
+		ContentType: contentType,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		Data:        data,
+	}, nil
+}
+
+// SanitizeFilename reduces a client file name to its base name with only
+// letters, digits, dots, dashes and underscores, so it is safe to log, show
+// and use in storage keys
+func SanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+
+	name = strings.TrimLeft(b.String(), ".")
+	if len(name) > 100 {
+		ext := filepath.Ext(name)
+		if len(ext) > 10 {
+			ext = ""
+		}
+		name = name[:100-len(ext)] + ext
+	}
+	if name == "" {
+		return "upload"
+	}
+	return name
+}
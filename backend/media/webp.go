@@ -0,0 +1,73 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// WebPEncoder encodes images as WebP
+type WebPEncoder interface {
+	EncodeWebP(img image.Image) ([]byte, error)
+}
+
+// webpEncoder is nil when no encoder is available, in which case only JPEG
+// and PNG variants are generated
+var webpEncoder WebPEncoder
+
+// SetWebPEncoder replaces the WebP encoder; nil disables WebP variants
+func SetWebPEncoder(e WebPEncoder) {
+	webpEncoder = e
+}
+
+// CWebPEncoder encodes WebP with the cwebp command line tool from libwebp
+type CWebPEncoder struct {
+	Path    string
+	Quality string
+}
+
+// NewCWebPEncoder returns an encoder using the cwebp executable, or an error
+// when it cannot be found
+func NewCWebPEncoder(path, quality string) (*CWebPEncoder, error) {
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return &CWebPEncoder{Path: resolved, Quality: quality}, nil
+}
+
+// EncodeWebP passes the image to cwebp as a lossless PNG
+func (e *CWebPEncoder) EncodeWebP(img image.Image) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "webp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in.png")
+	out := filepath.Join(dir, "out.webp")
+
+	f, err := os.Create(in)
+	if err != nil {
+		return nil, err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(e.Path, "-quiet", "-metadata", "none", "-q", e.Quality, in, "-o", out)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("cwebp: %v: %s", err, stderr.String())
+	}
+	return os.ReadFile(out)
+}
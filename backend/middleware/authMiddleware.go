@@ -41,6 +41,37 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// StreamAuthMiddleware authenticates long-lived streaming connections with the
+// same JWT as AuthMiddleware. Browsers cannot set headers on an EventSource, so
+// the token may also be passed as the "token" query parameter, which Logger
+// redacts.
+func StreamAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenStr := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenStr == "" {
+			tokenStr = c.Query("token")
+		}
+		if tokenStr == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization token is required"})
+			c.Abort()
+			return
+		}
+
+		claims, err := utils.ValidateJWT(tokenStr)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			c.Abort()
+			return
+		}
+
+		c.Set("userID", claims.UserID)
+		c.Set("role", claims.Role)
+		c.Set("claims", claims)
+
+		c.Next()
+	}
+}
+
 // Role-checking middlewares
 func AdminOnly() gin.HandlerFunc {
 	return func(c *gin.Context) {
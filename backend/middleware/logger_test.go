@@ -0,0 +1,21 @@
+package middleware
+
+import "testing"
+
+func TestRedactToken(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/realtime", "/realtime"},
+		{"/realtime?token=eyJhbGci.eyJ1c2Vy.c2lnbg", "/realtime?token=REDACTED"},
+		{"/realtime?lastEventId=7&token=eyJhbGci", "/realtime?lastEventId=7&token=REDACTED"},
+		{"/products?q=rice&page=2", "/products?q=rice&page=2"},
+		{"/realtime?token=%zz", "/realtime?REDACTED"},
+	}
+	for _, tt := range tests {
+		if got := redactToken(tt.path); got != tt.want {
+			t.Errorf("redactToken(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
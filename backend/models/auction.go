@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Statuses of an auction
+const (
+	AuctionOpen      = "open"      // scheduled or taking bids until EndsAt
+	AuctionSold      = "sold"      // closed with a winning bid at or above the reserve
+	AuctionUnsold    = "unsold"    // closed without a bid at or above the reserve
+	AuctionCancelled = "cancelled" // cancelled by the farmer before any bid
+)
+
+// Auction sells a lot of a listing to the highest bidder. Quantities are in
+// the listing's unit and prices are per unit.
+type Auction struct {
+	gorm.Model
+
+	ProductID    uint      `json:"product_id" gorm:"index;not null"`
+	VariantID    *uint     `json:"variant_id"`
+	FarmerID     uint      `json:"farmer_id" gorm:"index;not null"`
+	Quantity     float64   `json:"quantity"`
+	Unit         string    `json:"unit"`
+	StartPrice   float64   `json:"start_price"`
+	ReservePrice float64   `json:"reserve_price,omitempty"` // shown to the farmer only
+	MinIncrement float64   `json:"min_increment"`
+	StartsAt     time.Time `json:"starts_at"`
+	// EndsAt moves later when a bid comes in just before the end
+	EndsAt     time.Time `json:"ends_at" gorm:"index"`
+	Extensions int       `json:"extensions"`
+	Status     string    `json:"status" gorm:"index;not null;default:'open'"`
+
+	HighestBid      *float64   `json:"highest_bid"`
+	HighestBidderID *uint      `json:"highest_bidder_id"`
+	BidCount        int        `json:"bid_count"`
+	WinnerID        *uint      `json:"winner_id"`
+	OrderID         *uint      `json:"order_id"`
+	ClosedAt        *time.Time `json:"closed_at"`
+
+	// ReserveMet tells bidders whether the highest bid would win
+	ReserveMet bool `json:"reserve_met" gorm:"-"`
+}
+
+// Live reports whether the auction takes bids at now
+func (a Auction) Live(now time.Time) bool {
+	return a.Status == AuctionOpen && !now.Before(a.StartsAt) && now.Before(a.EndsAt)
+}
+
+// AuctionBid is a buyer's bid per unit on an auction lot
+type AuctionBid struct {
+	gorm.Model
+
+	AuctionID uint    `json:"auction_id" gorm:"index;not null"`
+	BuyerID   uint    `json:"buyer_id" gorm:"index;not null"`
+	Amount    float64 `json:"amount"`
+}
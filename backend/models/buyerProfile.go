@@ -22,4 +22,9 @@ type BuyerProfile struct {
 	Verified        bool   `json:"verified" gorm:"default:false"`
 	BuyerCategory   string `json:"buyer_category" gorm:"type:enum('SMALL','MEDIUM','LARGE','INSTITUTIONAL');default:'SMALL'"`
 	ProfilePhoto    string `json:"profile_photo" gorm:"default:''"`
+
+	// Latitude and Longitude of the business address, geocoded from
+	// District and Municipality unless given explicitly
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
 }
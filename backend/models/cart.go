@@ -0,0 +1,18 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// CartItem is a listing in a buyer's cart. The quantity is in the listing's
+// unit; the price is taken from the listing at checkout.
+type CartItem struct {
+	gorm.Model
+
+	BuyerID   uint    `json:"buyer_id" gorm:"index;not null"`
+	ProductID uint    `json:"product_id" gorm:"not null"`
+	VariantID *uint   `json:"variant_id"` // required for listings with variants
+	Quantity  float64 `json:"quantity"`
+
+	Product *Product `json:"product,omitempty"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Category is a node of the product category tree, e.g.
+// Vegetables → Leafy → Spinach. Defaults left empty on a category are
+// inherited from its nearest ancestor.
+type Category struct {
+	gorm.Model
+
+	ParentID *uint  `json:"parent_id" gorm:"index"`
+	Slug     string `json:"slug" gorm:"uniqueIndex;not null"`
+	NameEn   string `json:"name_en" gorm:"not null"`
+	NameNp   string `json:"name_np"`
+	// Aliases are free-text names mapped to this category, e.g. "tarkari"
+	Aliases   []string `json:"aliases" gorm:"serializer:json"`
+	SortOrder int      `json:"sort_order" gorm:"default:0"`
+
+	AllowedUnits  []string `json:"allowed_units" gorm:"serializer:json"`
+	Perishable    *bool    `json:"perishable"`
+	ShelfLifeDays int      `json:"shelf_life_days" gorm:"default:0"` // 0 = unspecified
+	// KgPerLitre is the typical density, used to weigh volume units such as muri
+	KgPerLitre float64 `json:"kg_per_litre" gorm:"default:0"` // 0 = unspecified
+
+	Children []Category `json:"children,omitempty" gorm:"-"`
+}
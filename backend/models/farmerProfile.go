@@ -13,4 +13,11 @@ type FarmerProfile struct {
 	FarmSize       float64 `json:"farm_size"` // IN ROPANI OR BIGHA
 	FarmLocation   string  `json:"farm_location"`
 	Certifications string  `json:"certifications"` // organic, GAP
+
+	// District and Municipality locate the farm; Latitude and Longitude are
+	// geocoded from them unless given explicitly
+	District     string   `json:"district" gorm:"index"`
+	Municipality string   `json:"municipality"`
+	Latitude     *float64 `json:"latitude"`
+	Longitude    *float64 `json:"longitude"`
 }
@@ -8,10 +8,11 @@ import (
 
 type Notification struct {
 	gorm.Model
-	UserID    uint   `json:"user_id"`
+	UserID    uint   `json:"user_id" gorm:"index;not null"`
 	Message   string `json:"message"`
-	Type      string `json:"type"` // offer, order, transport, system
-	IsRead    bool   `json:"is_read"`
+	Type      string `json:"type"`       // offer, order, transport, system, product
+	RelatedID uint   `json:"related_id"` // ID of the offer/order the notification is about
+	IsRead    bool   `json:"is_read" gorm:"default:false;index"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
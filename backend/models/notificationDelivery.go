@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NotificationDelivery records one attempt to deliver a message over a channel
+type NotificationDelivery struct {
+	gorm.Model
+
+	NotificationID    uint       `json:"notification_id" gorm:"index"`
+	UserID            uint       `json:"user_id" gorm:"index;not null"`
+	Channel           string     `json:"channel"` // in_app, sms, email
+	Recipient         string     `json:"recipient"`
+	Body              string     `json:"body"`
+	Status            string     `json:"status" gorm:"default:'pending'"` // pending, sent, failed
+	ProviderMessageID string     `json:"provider_message_id"`
+	Error             string     `json:"error"`
+	SentAt            *time.Time `json:"sent_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// NotificationPreference selects the channels a user receives one type of
+// notification on. Missing rows fall back to the channel defaults.
+type NotificationPreference struct {
+	gorm.Model
+
+	UserID uint   `json:"user_id" gorm:"uniqueIndex:idx_notification_pref_user_type;not null"`
+	Type   string `json:"type" gorm:"uniqueIndex:idx_notification_pref_user_type;not null"` // offer, order, transport, system, product
+	InApp  bool   `json:"in_app" gorm:"not null"`
+	SMS    bool   `json:"sms" gorm:"not null"`
+	Email  bool   `json:"email" gorm:"not null"`
+
+	// Frequency of SMS/email delivery: immediate, hourly or daily digest.
+	// In-app notifications are always immediate.
+	Frequency string `json:"frequency" gorm:"default:'immediate'"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// QueuedNotification is an SMS or email held back for a digest, an SMS held
+// back until quiet hours end, or an item waiting to retry a failed delivery
+type QueuedNotification struct {
+	gorm.Model
+
+	UserID         uint       `json:"user_id" gorm:"index;not null"`
+	NotificationID uint       `json:"notification_id"`
+	Channel        string     `json:"channel"` // sms, email
+	Type           string     `json:"type"`    // offer, order, transport, system, product
+	Event          string     `json:"event"`
+	Body           string     `json:"body"`
+	Digest         bool       `json:"digest"` // part of an hourly/daily summary
+	DeliverAfter   time.Time  `json:"deliver_after" gorm:"index"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	SentAt         *time.Time `json:"sent_at" gorm:"index"`
+}
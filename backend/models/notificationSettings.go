@@ -0,0 +1,17 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// NotificationSettings holds per-user delivery settings that apply to every
+// notification type. Times are "HH:MM" in Asia/Kathmandu.
+type NotificationSettings struct {
+	gorm.Model
+
+	UserID            uint   `json:"user_id" gorm:"uniqueIndex;not null"`
+	QuietHoursEnabled bool   `json:"quiet_hours_enabled" gorm:"not null"`
+	QuietHoursStart   string `json:"quiet_hours_start" gorm:"size:5;default:'21:00'"`
+	QuietHoursEnd     string `json:"quiet_hours_end" gorm:"size:5;default:'07:00'"`
+	DailyDigestTime   string `json:"daily_digest_time" gorm:"size:5;default:'08:00'"`
+}
@@ -8,13 +8,19 @@ import (
 
 type Offer struct {
 	gorm.Model
-	UserID     uint    `json:"user_id"`
-	BuyerID    uint    `json:"buyer_id"`
-	ProductID  uint    `json:"product_id"`
-	Quantity   float64 `json:"quantity"`
-	Price      float64 `json:"price"`
-	Status     string  `gorm:"default:'pending'"` // accepted, rejected
-	PickupDate string  `json:"pickup_date"`
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	UserID    uint `json:"user_id"`
+	BuyerID   uint `json:"buyer_id"`
+	ProductID uint `json:"product_id"`
+	// VariantID is required for listings with variants
+	VariantID *uint   `json:"variant_id" gorm:"index"`
+	Quantity  float64 `json:"quantity"`
+	Unit      string  `json:"unit"`  // defaults to the listing's unit
+	Price     float64 `json:"price"` // per Unit
+	// ListingQuantity and ListingPrice express the offer in the listing's unit
+	ListingQuantity float64 `json:"listing_quantity"`
+	ListingPrice    float64 `json:"listing_price"`
+	Status          string  `gorm:"default:'pending'"` // accepted, rejected
+	PickupDate      string  `json:"pickup_date"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
@@ -12,8 +12,20 @@ type Order struct {
 	FarmerID  uint   `json:"farmer_id"`
 	BuyerID   uint   `json:"buyer_id"`
 	ProductID uint   `json:"product_id"`
+	VariantID *uint  `json:"variant_id" gorm:"index"` // required for listings with variants
 	OrderDate string `json:"order_date"`
 	Status    string `gorm:"type:order_status;default:'processing'"` // processing, completed, canceled
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	// CompletedAt is set when the order is completed and counts its volume in
+	// the market price statistics of that day
+	CompletedAt *time.Time `json:"completed_at"`
+	// Items are the lines of an order placed from the cart, all from the
+	// same farmer; ProductID is then the product of the first line
+	Items []OrderItem `json:"items,omitempty"`
+	Total float64     `json:"total"` // sum of the line subtotals
+	// StockQuantity is what an order without lines took from the stock of
+	// its listing, in the listing's unit, e.g. an awarded bid. It goes back
+	// when the order is canceled.
+	StockQuantity float64 `json:"stock_quantity"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
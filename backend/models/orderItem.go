@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrderItem is a line of an order placed from the cart. Each line has its
+// own status so an order can be fulfilled in part.
+type OrderItem struct {
+	gorm.Model
+
+	OrderID   uint    `json:"order_id" gorm:"index;not null"`
+	ProductID uint    `json:"product_id" gorm:"index;not null"`
+	VariantID *uint   `json:"variant_id"`
+	Quantity  float64 `json:"quantity"`
+	Unit      string  `json:"unit"`
+	UnitPrice float64 `json:"unit_price"` // the listing price at checkout
+	Subtotal  float64 `json:"subtotal"`
+	Status    string  `json:"status" gorm:"type:order_status;default:'processing'"` // processing, completed, canceled
+	// CompletedAt counts the line's volume in the market price statistics
+	// of that day
+	CompletedAt *time.Time `json:"completed_at"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Statuses of a pre-order
+const (
+	PreOrderPlaced    = "placed"    // waiting for the harvest
+	PreOrderConverted = "converted" // turned into an order at harvest
+	PreOrderCancelled = "cancelled"
+)
+
+// Statuses of a pre-order deposit. The deposit is paid to the farmer
+// directly; the farmer records its receipt.
+const (
+	DepositNone      = "none"
+	DepositPending   = "pending"    // promised by the buyer
+	DepositReceived  = "received"   // confirmed by the farmer
+	DepositApplied   = "applied"    // counted as payment of the order
+	DepositRefundDue = "refund_due" // the pre-order was cancelled
+	DepositRefunded  = "refunded"   // returned to the buyer
+)
+
+// PreOrder reserves part of the expected harvest of a forward listing for
+// a buyer. Quantities are in the listing's unit.
+type PreOrder struct {
+	gorm.Model
+
+	ProductID     uint    `json:"product_id" gorm:"index;not null"`
+	BuyerID       uint    `json:"buyer_id" gorm:"index;not null"`
+	FarmerID      uint    `json:"farmer_id" gorm:"index;not null"`
+	Quantity      float64 `json:"quantity"`
+	PricePerUnit  float64 `json:"price_per_unit"` // the listing price when the pre-order was placed
+	Deposit       float64 `json:"deposit"`
+	DepositStatus string  `json:"deposit_status" gorm:"not null;default:'none'"`
+	// DepositRefund is the part of a received deposit owed back to the
+	// buyer: all of it when the pre-order is cancelled, or what exceeds the
+	// value of a partly filled pre-order
+	DepositRefund float64 `json:"deposit_refund"`
+	Note          string  `json:"note"`
+	Status        string  `json:"status" gorm:"index;not null;default:'placed'"`
+	// FulfilledQuantity is the part of the harvest allocated to the
+	// pre-order, which may be less than Quantity after a short harvest
+	FulfilledQuantity float64    `json:"fulfilled_quantity"`
+	OrderID           *uint      `json:"order_id"`
+	CancelReason      string     `json:"cancel_reason,omitempty"`
+	ConvertedAt       *time.Time `json:"converted_at"`
+	CancelledAt       *time.Time `json:"cancelled_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// ProductPriceHistory records a listing price from the moment it was set
+// until the next record of the same product. Category, unit and district
+// are copied so the history survives edits and deletion of the product.
+type ProductPriceHistory struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	ProductID    uint      `json:"product_id" gorm:"index:idx_price_history_product,priority:1;not null"`
+	RecordedAt   time.Time `json:"recorded_at" gorm:"index:idx_price_history_product,priority:2;index;not null"`
+	CategoryID   *uint     `json:"category_id"`
+	Category     string    `json:"category"`
+	District     string    `json:"district"`
+	Unit         string    `json:"unit"`
+	PricePerUnit float64   `json:"price_per_unit"`
+	PricePerKg   *float64  `json:"price_per_kg"`
+}
+
+// DailyPriceStat aggregates the listing prices in effect at the end of a day
+// and the orders completed that day, per category, unit and district. Rows
+// with an empty District cover the whole country.
+type DailyPriceStat struct {
+	ID       uint      `json:"-" gorm:"primarykey"`
+	Date     time.Time `json:"date" gorm:"type:date;uniqueIndex:idx_daily_price_stat,priority:1;not null"`
+	Category string    `json:"category" gorm:"uniqueIndex:idx_daily_price_stat,priority:2;not null"`
+	Unit     string    `json:"unit" gorm:"uniqueIndex:idx_daily_price_stat,priority:3;not null"`
+	District string    `json:"district" gorm:"uniqueIndex:idx_daily_price_stat,priority:4;not null"`
+
+	Listings         int      `json:"listings"`
+	MinPrice         float64  `json:"min_price"`
+	MaxPrice         float64  `json:"max_price"`
+	MedianPrice      float64  `json:"median_price"`
+	MedianPricePerKg *float64 `json:"median_price_per_kg"`
+	// Volume is the quantity, in Unit, of the orders completed that day
+	Volume    float64   `json:"volume"`
+	Orders    int       `json:"orders"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
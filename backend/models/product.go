@@ -14,14 +14,50 @@ type Product struct {
 	NameNp        string  `json:"name_np"`
 	DescriptionEn string  `json:"description_en"`
 	DescriptionNp string  `json:"description_np"`
-	Category      string  `json:"category"`
+	CategoryID    *uint   `json:"category_id" gorm:"index"`
+	Category      string  `json:"category"` // slug of the category, kept for older clients
+	Perishable    bool    `json:"perishable" gorm:"not null;default:false"`
 	Quantity      float64 `json:"quantity"`
 	Unit          string  `json:"unit"` // ✅ fixed typo here
 	PricePerUnit  float64 `json:"price_per_unit"`
-	AvailableFrom string  `json:"available_from"`
-	AvailableTo   string  `json:"available_to"`
-	Status        string  `gorm:"default:'available'"`
-	ImageURL      string  `json:"image_url"`
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	// PricePerKg is PricePerUnit normalized to kilograms, set when the unit
+	// converts to a weight, so listings in different units can be compared
+	PricePerKg *float64 `json:"price_per_kg" gorm:"index"`
+	// AvailableFrom and AvailableTo bound the listing. The availability
+	// scheduler activates scheduled listings on AvailableFrom and expires
+	// them after AvailableTo.
+	AvailableFrom Date          `json:"available_from" gorm:"index"`
+	AvailableTo   Date          `json:"available_to" gorm:"index"`
+	Status        ProductStatus `gorm:"type:product_status;not null;default:'available'"`
+	// HarvestDate and the yield range describe the coming harvest of a
+	// forward listing, sold through pre-orders until the farmer confirms
+	// the harvest
+	HarvestDate        Date       `json:"harvest_date" gorm:"index"`
+	YieldMin           float64    `json:"yield_min"`
+	YieldMax           float64    `json:"yield_max"`
+	HarvestConfirmedAt *time.Time `json:"harvest_confirmed_at"`
+	// ExpiryNotifiedAt is set once the farmer was reminded of the expiry
+	ExpiryNotifiedAt *time.Time     `json:"-"`
+	ImageURL         string         `json:"image_url"` // URL of the primary image
+	ThumbnailURL     string         `json:"thumbnail_url"`
+	Images           []ProductImage `json:"images,omitempty" gorm:"foreignKey:ProductID"`
+	// Variants of the listing; when present they set Quantity and
+	// PricePerUnit
+	Variants []ProductVariant `json:"variants,omitempty" gorm:"foreignKey:ProductID"`
+	// ReferencePrice is the latest wholesale market price of the same
+	// produce, attached when listings are returned
+	ReferencePrice *ReferencePrice `json:"reference_price,omitempty" gorm:"-"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Forward reports whether the listing sells an expected harvest
+func (p Product) Forward() bool {
+	return !p.HarvestDate.IsZero()
+}
+
+// AwaitingHarvest reports whether the listing takes pre-orders: it is a
+// forward listing whose harvest is not confirmed yet
+func (p Product) AwaitingHarvest() bool {
+	return p.Forward() && p.HarvestConfirmedAt == nil
 }
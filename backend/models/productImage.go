@@ -0,0 +1,39 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// ProductImage is an image of a product gallery. Each upload is stored in
+// several sizes, as JPEG (PNG for transparent images) and, when an encoder
+// is available, WebP.
+type ProductImage struct {
+	gorm.Model
+
+	ProductID uint `json:"product_id" gorm:"index;not null"`
+	Position  int  `json:"position" gorm:"not null"`
+	IsPrimary bool `json:"is_primary" gorm:"not null"`
+	Width     int  `json:"width"`
+	Height    int  `json:"height"`
+	// Fingerprint is a perceptual hash used to find the same photo on
+	// other listings
+	Fingerprint string `json:"-" gorm:"index"`
+
+	URL              string `json:"url"`
+	MediumURL        string `json:"medium_url"`
+	ThumbnailURL     string `json:"thumbnail_url"`
+	WebPURL          string `json:"webp_url"`
+	MediumWebPURL    string `json:"medium_webp_url"`
+	ThumbnailWebPURL string `json:"thumbnail_webp_url"`
+}
+
+// Files returns the URLs of every stored variant
+func (i ProductImage) Files() []string {
+	var urls []string
+	for _, u := range []string{i.URL, i.MediumURL, i.ThumbnailURL, i.WebPURL, i.MediumWebPURL, i.ThumbnailWebPURL} {
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Moderation statuses
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+)
+
+// Reasons a listing enters the moderation queue
+const (
+	ModerationReasonCreated   = "created"   // a new listing
+	ModerationReasonPublished = "published" // a draft or rejected listing was published
+	ModerationReasonEdited    = "edited"    // the name, price or image of a live listing changed
+)
+
+// ModerationFlag is a suspicion raised automatically about a listing
+type ModerationFlag struct {
+	Code   string `json:"code"` // price_outlier, banned_keyword, duplicate_image
+	Detail string `json:"detail"`
+}
+
+// ProductModeration is a listing submitted for review by an admin. A listing
+// has at most one pending entry; later edits are merged into it.
+type ProductModeration struct {
+	gorm.Model
+
+	ProductID uint     `json:"product_id" gorm:"index;not null"`
+	Product   *Product `json:"product,omitempty"`
+	UserID    uint     `json:"user_id" gorm:"index;not null"` // the farmer
+	Reason    string   `json:"reason"`                        // see the ModerationReason constants
+	// Changes lists the material fields changed by an edit: name, price, image
+	Changes []string         `json:"changes" gorm:"serializer:json"`
+	Flags   []ModerationFlag `json:"flags" gorm:"serializer:json"`
+	Flagged bool             `json:"flagged" gorm:"index"`
+	Status  string           `json:"status" gorm:"index;not null;default:'pending'"`
+	// Note is the rejection reason, or an optional note on approval
+	Note       string     `json:"note"`
+	ReviewedBy *uint      `json:"reviewed_by"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+}
@@ -0,0 +1,79 @@
+package models
+
+// ProductStatus is the lifecycle state of a listing, stored as the
+// product_status enum
+type ProductStatus string
+
+const (
+	ProductStatusDraft         ProductStatus = "draft"          // not yet published
+	ProductStatusPendingReview ProductStatus = "pending_review" // waiting for an admin
+	ProductStatusScheduled     ProductStatus = "scheduled"      // AvailableFrom is in the future
+	ProductStatusAvailable     ProductStatus = "available"
+	ProductStatusReserved      ProductStatus = "reserved" // held for a buyer
+	ProductStatusSoldOut       ProductStatus = "sold_out" // no quantity left
+	ProductStatusExpired       ProductStatus = "expired"  // AvailableTo has passed
+	ProductStatusArchived      ProductStatus = "archived" // withdrawn by the farmer
+)
+
+// ProductStatuses lists every status in lifecycle order
+var ProductStatuses = []ProductStatus{
+	ProductStatusDraft,
+	ProductStatusPendingReview,
+	ProductStatusScheduled,
+	ProductStatusAvailable,
+	ProductStatusReserved,
+	ProductStatusSoldOut,
+	ProductStatusExpired,
+	ProductStatusArchived,
+}
+
+// productStatusTransitions lists the statuses each status may change to.
+// Drafts are published for review or straight to a live status; archived
+// listings only come back as drafts. Live statuses follow from the quantity
+// and dates, so a sold out listing only becomes available again once it is
+// restocked.
+var productStatusTransitions = map[ProductStatus][]ProductStatus{
+	ProductStatusDraft:         {ProductStatusPendingReview, ProductStatusScheduled, ProductStatusAvailable, ProductStatusArchived},
+	ProductStatusPendingReview: {ProductStatusDraft, ProductStatusScheduled, ProductStatusAvailable, ProductStatusArchived},
+	ProductStatusScheduled:     {ProductStatusDraft, ProductStatusAvailable, ProductStatusSoldOut, ProductStatusExpired, ProductStatusArchived},
+	ProductStatusAvailable:     {ProductStatusDraft, ProductStatusScheduled, ProductStatusReserved, ProductStatusSoldOut, ProductStatusExpired, ProductStatusArchived},
+	ProductStatusReserved:      {ProductStatusAvailable, ProductStatusSoldOut, ProductStatusArchived},
+	ProductStatusSoldOut:       {ProductStatusDraft, ProductStatusScheduled, ProductStatusAvailable, ProductStatusArchived},
+	ProductStatusExpired:       {ProductStatusDraft, ProductStatusScheduled, ProductStatusAvailable, ProductStatusSoldOut, ProductStatusArchived},
+	ProductStatusArchived:      {ProductStatusDraft},
+}
+
+// ProductStatusTransitions returns the statuses each status may change to
+func ProductStatusTransitions() map[ProductStatus][]ProductStatus {
+	return productStatusTransitions
+}
+
+// Valid reports whether s is a known status
+func (s ProductStatus) Valid() bool {
+	_, ok := productStatusTransitions[s]
+	return ok
+}
+
+// CanTransition reports whether a listing may change from s to next.
+// Keeping the same status is always allowed.
+func (s ProductStatus) CanTransition(next ProductStatus) bool {
+	if s == next {
+		return next.Valid()
+	}
+	for _, allowed := range productStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Live reports whether the availability scheduler manages the status from
+// the listing's quantity and dates
+func (s ProductStatus) Live() bool {
+	switch s {
+	case ProductStatusScheduled, ProductStatusAvailable, ProductStatusSoldOut, ProductStatusExpired:
+		return true
+	}
+	return false
+}
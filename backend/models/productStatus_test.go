@@ -0,0 +1,63 @@
+package models
+
+import "testing"
+
+func TestProductStatusCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to ProductStatus
+		want     bool
+	}{
+		{ProductStatusDraft, ProductStatusPendingReview, true},
+		{ProductStatusDraft, ProductStatusAvailable, true},
+		{ProductStatusPendingReview, ProductStatusDraft, true},
+		{ProductStatusAvailable, ProductStatusSoldOut, true},
+		{ProductStatusAvailable, ProductStatusReserved, true},
+		{ProductStatusReserved, ProductStatusAvailable, true},
+		{ProductStatusSoldOut, ProductStatusAvailable, true},
+		{ProductStatusExpired, ProductStatusAvailable, true},
+		{ProductStatusArchived, ProductStatusDraft, true},
+		{ProductStatusArchived, ProductStatusArchived, true},
+
+		// Archived listings are only revived as drafts
+		{ProductStatusArchived, ProductStatusAvailable, false},
+		{ProductStatusArchived, ProductStatusPendingReview, false},
+		{ProductStatusArchived, ProductStatusScheduled, false},
+		{ProductStatusArchived, ProductStatusSoldOut, false},
+		{ProductStatusArchived, ProductStatusExpired, false},
+		// Drafts are not published empty or already expired
+		{ProductStatusDraft, ProductStatusSoldOut, false},
+		{ProductStatusDraft, ProductStatusExpired, false},
+		{ProductStatusDraft, ProductStatusReserved, false},
+		// Only available listings are reserved
+		{ProductStatusSoldOut, ProductStatusReserved, false},
+		{ProductStatusPendingReview, ProductStatusReserved, false},
+		// Review is entered by publishing, not from live statuses
+		{ProductStatusAvailable, ProductStatusPendingReview, false},
+		{ProductStatusSoldOut, ProductStatusPendingReview, false},
+		// Sold out listings do not expire, and reserved ones stay put
+		{ProductStatusSoldOut, ProductStatusExpired, false},
+		{ProductStatusReserved, ProductStatusExpired, false},
+		{ProductStatusReserved, ProductStatusDraft, false},
+
+		{ProductStatusAvailable, ProductStatus("gone"), false},
+		{ProductStatus("gone"), ProductStatus("gone"), false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransition(tt.to); got != tt.want {
+			t.Errorf("%s -> %s: got %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestProductStatusTransitionsAreValid(t *testing.T) {
+	for _, from := range ProductStatuses {
+		for _, to := range ProductStatusTransitions()[from] {
+			if !to.Valid() {
+				t.Errorf("%s -> %s: unknown status", from, to)
+			}
+			if to == from {
+				t.Errorf("%s lists itself as a transition", from)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ProductVariant is a grade, size or packaging of a listing with its own
+// quantity and price. The listing's quantity is the sum of its variants and
+// its price the lowest variant price.
+type ProductVariant struct {
+	gorm.Model
+
+	ProductID    uint     `json:"product_id" gorm:"index;not null"`
+	Grade        string   `json:"grade"`     // e.g. "A"
+	Size         string   `json:"size"`      // e.g. "large"
+	Packaging    string   `json:"packaging"` // e.g. "50 kg sack"
+	Quantity     float64  `json:"quantity"`
+	PricePerUnit float64  `json:"price_per_unit"` // in the listing's unit
+	PricePerKg   *float64 `json:"price_per_kg"`
+	Position     int      `json:"position" gorm:"not null"`
+}
+
+// Label describes the variant, e.g. "Grade A, large, 50 kg sack"
+func (v ProductVariant) Label() string {
+	var parts []string
+	if v.Grade != "" {
+		parts = append(parts, "Grade "+v.Grade)
+	}
+	for _, part := range []string{v.Size, v.Packaging} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
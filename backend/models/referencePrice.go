@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReferencePrice is the published wholesale price of a commodity on one day
+// at a market such as Kalimati. Listings of the same produce show the latest
+// reference price so farmers can price against the market.
+type ReferencePrice struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Market    string    `json:"market" gorm:"uniqueIndex:idx_reference_price,priority:1;not null"`
+	Date      time.Time `json:"date" gorm:"type:date;uniqueIndex:idx_reference_price,priority:2;index;not null"`
+	Commodity string    `json:"commodity" gorm:"uniqueIndex:idx_reference_price,priority:3;not null"` // as published
+
+	// CategoryID, Category and Produce are resolved from the commodity name
+	// through the commodity mappings; they are empty for unmapped commodities
+	CategoryID *uint  `json:"category_id" gorm:"index"`
+	Category   string `json:"category"`
+	Produce    string `json:"produce" gorm:"index"` // canonical produce name, e.g. "tomato"
+
+	Unit     string  `json:"unit"`
+	MinPrice float64 `json:"min_price"`
+	MaxPrice float64 `json:"max_price"`
+	AvgPrice float64 `json:"avg_price"`
+	// AvgPricePerKg is AvgPrice normalized to kilograms when the unit
+	// converts to a weight
+	AvgPricePerKg *float64  `json:"avg_price_per_kg"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// CommodityMapping overrides how a commodity name of the price sheets maps
+// to a category and produce. Commodities without a mapping are matched
+// through the produce synonym dictionary.
+type CommodityMapping struct {
+	gorm.Model
+
+	Commodity  string `json:"commodity" gorm:"uniqueIndex;not null"` // normalized, e.g. "tomato big(nepali)"
+	CategoryID *uint  `json:"category_id"`
+	Produce    string `json:"produce"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Statuses of a request for quotation
+const (
+	RFQOpen    = "open"    // taking bids
+	RFQAwarded = "awarded" // the requested quantity was awarded
+	RFQClosed  = "closed"  // closed by the buyer
+)
+
+// Statuses of a bid on a request for quotation
+const (
+	BidSubmitted = "submitted"
+	BidAwarded   = "awarded"
+	BidRejected  = "rejected"  // another bid won or the request closed
+	BidWithdrawn = "withdrawn" // withdrawn by the farmer
+)
+
+// RFQ is a buyer's request for quotation: a demand for produce farmers bid
+// on, e.g. 2 tonnes of onions delivered to Pokhara by the 15th
+type RFQ struct {
+	gorm.Model
+
+	BuyerID     uint    `json:"buyer_id" gorm:"index;not null"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	CategoryID  *uint   `json:"category_id" gorm:"index"`
+	Category    string  `json:"category"` // slug of the category
+	Quantity    float64 `json:"quantity"`
+	Unit        string  `json:"unit"`
+	// MaxPricePerUnit is the price per unit the buyer expects to pay at most
+	MaxPricePerUnit  *float64 `json:"max_price_per_unit"`
+	DeliveryLocation string   `json:"delivery_location"`
+	District         string   `json:"district" gorm:"index"`
+	// Deadline is the day the produce must be delivered by; bids are taken
+	// until then
+	Deadline        Date    `json:"deadline" gorm:"index"`
+	Status          string  `json:"status" gorm:"index;not null;default:'open'"`
+	AwardedQuantity float64 `json:"awarded_quantity"`
+	BidCount        int64   `json:"bid_count" gorm:"-"`
+}
+
+// RFQBid is a farmer's bid on a request for quotation. Quantities and
+// prices are in the unit of the request.
+type RFQBid struct {
+	gorm.Model
+
+	RFQID    uint `json:"rfq_id" gorm:"column:rfq_id;index;not null"`
+	FarmerID uint `json:"farmer_id" gorm:"index;not null"`
+	// ProductID names the farmer's listing the produce comes from; the
+	// variant is required for listings with variants
+	ProductID       uint    `json:"product_id" gorm:"not null"`
+	VariantID       *uint   `json:"variant_id"`
+	Quantity        float64 `json:"quantity"`
+	PricePerUnit    float64 `json:"price_per_unit"`
+	DeliveryDate    Date    `json:"delivery_date"`
+	Note            string  `json:"note"`
+	Status          string  `json:"status" gorm:"index;not null;default:'submitted'"`
+	AwardedQuantity float64 `json:"awarded_quantity"`
+	OrderID         *uint   `json:"order_id"`
+
+	RFQ *RFQ `json:"rfq,omitempty" gorm:"foreignKey:RFQID"`
+}
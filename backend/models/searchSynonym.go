@@ -0,0 +1,14 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// SearchSynonym adds a term to a synonym group used by product search. Terms
+// sharing a Canonical name (usually the English produce name) match each other.
+type SearchSynonym struct {
+	gorm.Model
+
+	Canonical string `json:"canonical" gorm:"index;not null"`
+	Term      string `json:"term" gorm:"uniqueIndex;not null"`
+}
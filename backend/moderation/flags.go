@@ -0,0 +1,123 @@
+package moderation
+
+import (
+	"agro-connect/config"
+	"agro-connect/market"
+	"agro-connect/models"
+	"agro-connect/search"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Flag codes
+const (
+	FlagPriceOutlier   = "price_outlier"
+	FlagBannedKeyword  = "banned_keyword"
+	FlagDuplicateImage = "duplicate_image"
+)
+
+// statsMaxAgeDays is how old daily price statistics may be to serve as the
+// market price when no reference price is known
+const statsMaxAgeDays = 30
+
+// Check returns the automatic flags of a listing: banned keywords in its
+// text, a price far outside the market range and images also used on
+// another farmer's listings
+func Check(db *gorm.DB, product models.Product) []models.ModerationFlag {
+	flags := []models.ModerationFlag{}
+	flags = append(flags, bannedKeywords(product)...)
+	if flag, ok := priceOutlier(db, product); ok {
+		flags = append(flags, flag)
+	}
+	flags = append(flags, duplicateImages(db, product)...)
+	return flags
+}
+
+// bannedKeywords flags each configured keyword found in the listing text.
+// Text is compared word by word after normalization, so "Ganja" matches
+// "ganja" but "organic" does not match "gan".
+func bannedKeywords(product models.Product) []models.ModerationFlag {
+	text := " " + strings.Join(search.Terms(strings.Join([]string{
+		product.NameEn, product.NameNp, product.DescriptionEn, product.DescriptionNp,
+	}, " ")), " ") + " "
+
+	var flags []models.ModerationFlag
+	for _, keyword := range config.Moderation.BannedKeywords {
+		terms := search.Terms(keyword)
+		if len(terms) == 0 {
+			continue
+		}
+		if strings.Contains(text, " "+strings.Join(terms, " ")+" ") {
+			flags = append(flags, models.ModerationFlag{
+				Code:   FlagBannedKeyword,
+				Detail: fmt.Sprintf("listing mentions %q", keyword),
+			})
+		}
+	}
+	return flags
+}
+
+// priceOutlier compares the price with the wholesale reference price of the
+// produce, or else with the latest national median of the category
+func priceOutlier(db *gorm.DB, product models.Product) (models.ModerationFlag, bool) {
+	price, marketPrice, unit, source := 0.0, 0.0, "", ""
+
+	products := []models.Product{product}
+	if err := market.AttachReferencePrices(products); err == nil && products[0].ReferencePrice != nil {
+		ref := products[0].ReferencePrice
+		switch {
+		case product.PricePerKg != nil && ref.AvgPricePerKg != nil:
+			price, marketPrice, unit = *product.PricePerKg, *ref.AvgPricePerKg, "kg"
+		case ref.Unit == product.Unit:
+			price, marketPrice, unit = product.PricePerUnit, ref.AvgPrice, product.Unit
+		}
+		source = ref.Commodity + " at " + ref.Market
+	}
+
+	if marketPrice == 0 && product.Category != "" {
+		var stat models.DailyPriceStat
+		err := db.Where("category = ? AND unit = ? AND district = '' AND date >= ?",
+			product.Category, product.Unit, time.Now().AddDate(0, 0, -statsMaxAgeDays).Format(models.DateLayout)).
+			Order("date DESC").First(&stat).Error
+		if err == nil && stat.MedianPrice > 0 {
+			price, marketPrice, unit = product.PricePerUnit, stat.MedianPrice, product.Unit
+			source = "median " + product.Category + " listing"
+		}
+	}
+
+	factor := config.Moderation.PriceOutlierFactor
+	if marketPrice <= 0 || price <= 0 || (price <= marketPrice*factor && price >= marketPrice/factor) {
+		return models.ModerationFlag{}, false
+	}
+	return models.ModerationFlag{
+		Code: FlagPriceOutlier,
+		Detail: fmt.Sprintf("Rs. %.2f/%s is more than %gx away from the market price of Rs. %.2f/%s (%s)",
+			price, unit, factor, marketPrice, unit, source),
+	}, true
+}
+
+// duplicateImages flags images of the listing whose fingerprint matches an
+// image of another farmer's listing
+func duplicateImages(db *gorm.DB, product models.Product) []models.ModerationFlag {
+	var others []uint
+	db.Raw(`SELECT DISTINCT others.product_id
+		FROM product_images mine
+		JOIN product_images others ON others.fingerprint = mine.fingerprint AND others.product_id <> mine.product_id
+		JOIN products ON products.id = others.product_id
+		WHERE mine.product_id = ? AND mine.deleted_at IS NULL AND mine.fingerprint <> ''
+			AND others.deleted_at IS NULL AND products.deleted_at IS NULL AND products.user_id <> ?
+		ORDER BY others.product_id
+		LIMIT 5`, product.ID, product.UserID).Scan(&others)
+
+	var flags []models.ModerationFlag
+	for _, other := range others {
+		flags = append(flags, models.ModerationFlag{
+			Code:   FlagDuplicateImage,
+			Detail: fmt.Sprintf("an image also appears on product #%d of another farmer", other),
+		})
+	}
+	return flags
+}
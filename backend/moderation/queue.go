@@ -0,0 +1,179 @@
+package moderation
+
+import (
+	"agro-connect/availability"
+	"agro-connect/database"
+	"agro-connect/models"
+	"agro-connect/notify"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrReviewed is returned when approving or rejecting an entry that was
+// already reviewed
+var ErrReviewed = errors.New("listing was already reviewed")
+
+// Required reports whether listings published by user go through moderation.
+// Admins and verified farmers bypass it.
+func Required(user models.User) bool {
+	return user.Role != "admin" && !user.Verified
+}
+
+// MaterialChanges lists the changes between two versions of a listing that
+// need a new review: its name and its price
+func MaterialChanges(previous, current models.Product) []string {
+	var changes []string
+	if previous.NameEn != current.NameEn || previous.NameNp != current.NameNp {
+		changes = append(changes, "name")
+	}
+	if previous.PricePerUnit != current.PricePerUnit || previous.Unit != current.Unit {
+		changes = append(changes, "price")
+	}
+	return changes
+}
+
+// VariantChanges lists the changes between two versions of a variant that
+// need a new review of its listing: its grade, size or packaging, which name
+// it, and its price. A new variant is compared with the zero variant.
+func VariantChanges(previous, current models.ProductVariant) []string {
+	var changes []string
+	if previous.Label() != current.Label() {
+		changes = append(changes, "name")
+	}
+	if previous.PricePerUnit != current.PricePerUnit {
+		changes = append(changes, "price")
+	}
+	return changes
+}
+
+// Submit puts a listing into the moderation queue: it is hidden as
+// pending_review until an admin approves it. Submitting a listing that is
+// already pending merges the changes into its pending entry and checks the
+// listing again.
+func Submit(db *gorm.DB, product *models.Product, reason string, changes []string) (*models.ProductModeration, error) {
+	if err := db.Model(product).Update("status", models.ProductStatusPendingReview).Error; err != nil {
+		return nil, err
+	}
+	product.Status = models.ProductStatusPendingReview
+
+	var entry models.ProductModeration
+	err := db.Where("product_id = ? AND status = ?", product.ID, models.ModerationPending).First(&entry).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		entry = models.ProductModeration{
+			ProductID: product.ID,
+			UserID:    product.UserID,
+			Reason:    reason,
+			Status:    models.ModerationPending,
+		}
+	case err != nil:
+		return nil, err
+	}
+
+	for _, change := range changes {
+		if !contains(entry.Changes, change) {
+			entry.Changes = append(entry.Changes, change)
+		}
+	}
+	entry.Flags = Check(db, *product)
+	entry.Flagged = len(entry.Flags) > 0
+	if err := db.Save(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Approve publishes a pending listing. The listing becomes available, or
+// scheduled, sold out or expired as its quantity and dates require.
+func Approve(entry *models.ProductModeration, adminID uint, note string) error {
+	var product models.Product
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := review(tx, entry, models.ModerationApproved, adminID, note); err != nil {
+			return err
+		}
+		if err := tx.First(&product, entry.ProductID).Error; err != nil {
+			return err
+		}
+		if product.Status != models.ProductStatusPendingReview {
+			return nil
+		}
+		product.Status = models.ProductStatusAvailable
+		product.Status = availability.Status(product, availability.Today())
+		return tx.Model(&product).Update("status", product.Status).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	notify.Send(product.UserID, notify.TypeProduct, notify.EventListingApproved, product.ID, listingParams(product, note))
+	return nil
+}
+
+// Reject returns a pending listing to the farmer as a draft with the reason
+func Reject(entry *models.ProductModeration, adminID uint, reason string) error {
+	var product models.Product
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := review(tx, entry, models.ModerationRejected, adminID, reason); err != nil {
+			return err
+		}
+		if err := tx.First(&product, entry.ProductID).Error; err != nil {
+			return err
+		}
+		if product.Status != models.ProductStatusPendingReview {
+			return nil
+		}
+		product.Status = models.ProductStatusDraft
+		return tx.Model(&product).Update("status", product.Status).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	notify.Send(product.UserID, notify.TypeProduct, notify.EventListingRejected, product.ID, listingParams(product, reason))
+	return nil
+}
+
+// review records the outcome of a pending entry, failing when another admin
+// reviewed it first
+func review(tx *gorm.DB, entry *models.ProductModeration, status string, adminID uint, note string) error {
+	now := time.Now()
+	result := tx.Model(&models.ProductModeration{}).
+		Where("id = ? AND status = ?", entry.ID, models.ModerationPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"note":        note,
+			"reviewed_by": adminID,
+			"reviewed_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReviewed
+	}
+	entry.Status, entry.Note, entry.ReviewedBy, entry.ReviewedAt = status, note, &adminID, &now
+	return nil
+}
+
+func listingParams(product models.Product, note string) map[string]interface{} {
+	productNp := product.NameNp
+	if productNp == "" {
+		productNp = product.NameEn
+	}
+	return map[string]interface{}{
+		"product":    product.NameEn,
+		"product_np": productNp,
+		"reason":     note,
+	}
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
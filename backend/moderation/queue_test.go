@@ -0,0 +1,29 @@
+package moderation
+
+import (
+	"agro-connect/models"
+	"slices"
+	"testing"
+)
+
+func TestVariantChanges(t *testing.T) {
+	gradeA := models.ProductVariant{Grade: "A", Size: "large", Quantity: 100, PricePerUnit: 60}
+	tests := []struct {
+		name     string
+		previous models.ProductVariant
+		current  models.ProductVariant
+		want     []string
+	}{
+		{"unchanged", gradeA, gradeA, nil},
+		{"restocked", gradeA, models.ProductVariant{Grade: "A", Size: "large", Quantity: 400, PricePerUnit: 60}, nil},
+		{"new price", gradeA, models.ProductVariant{Grade: "A", Size: "large", Quantity: 100, PricePerUnit: 65}, []string{"price"}},
+		{"new grade", gradeA, models.ProductVariant{Grade: "B", Size: "large", Quantity: 100, PricePerUnit: 60}, []string{"name"}},
+		{"new packaging", gradeA, models.ProductVariant{Grade: "A", Size: "large", Packaging: "sack", Quantity: 100, PricePerUnit: 60}, []string{"name"}},
+		{"new variant", models.ProductVariant{}, gradeA, []string{"name", "price"}},
+	}
+	for _, tt := range tests {
+		if got := VariantChanges(tt.previous, tt.current); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"agro-connect/database"
+	"agro-connect/models"
+	"agro-connect/realtime"
+	"errors"
+)
+
+// Channel names
+const (
+	ChannelInApp = "in_app"
+	ChannelSMS   = "sms"
+	ChannelEmail = "email"
+)
+
+// Notification types users can set preferences for
+const (
+	TypeOffer     = "offer"
+	TypeOrder     = "order"
+	TypeTransport = "transport"
+	TypeSystem    = "system"
+	TypeProduct   = "product"
+)
+
+// Message is a rendered notification addressed to one user
+type Message struct {
+	User      models.User
+	Type      string // offer, order, transport, system, product
+	Event     string
+	RelatedID uint
+	Subject   string
+	Body      string
+}
+
+// Channel delivers a message. It returns the recipient address and the
+// provider message ID for the delivery record.
+type Channel interface {
+	Name() string
+	Deliver(msg *Message, notificationID uint) (recipient string, providerID string, err error)
+}
+
+// InAppChannel stores the notification for the dashboard and pushes it to
+// connected clients
+type InAppChannel struct{}
+
+func (InAppChannel) Name() string { return ChannelInApp }
+
+func (InAppChannel) Deliver(msg *Message, notificationID uint) (string, string, error) {
+	var notification models.Notification
+	if err := database.DB.First(&notification, notificationID).Error; err != nil {
+		return "", "", err
+	}
+	realtime.Publish(msg.User.ID, realtime.EventNotification, notification)
+	return "", "", nil
+}
+
+// SMSChannel sends the message body to the user's phone
+type SMSChannel struct {
+	Provider SMSProvider
+}
+
+func (SMSChannel) Name() string { return ChannelSMS }
+
+func (ch SMSChannel) Deliver(msg *Message, _ uint) (string, string, error) {
+	if msg.User.Phone == "" {
+		return "", "", errors.New("user has no phone number")
+	}
+	id, err := ch.Provider.SendSMS(msg.User.Phone, msg.Body)
+	return msg.User.Phone, id, err
+}
+
+// EmailChannel sends the message to the user's email address
+type EmailChannel struct {
+	Provider EmailProvider
+}
+
+func (EmailChannel) Name() string { return ChannelEmail }
+
+func (ch EmailChannel) Deliver(msg *Message, _ uint) (string, string, error) {
+	if msg.User.Email == "" {
+		return "", "", errors.New("user has no email address")
+	}
+	id, err := ch.Provider.SendEmail(msg.User.Email, msg.Subject, msg.Body)
+	return msg.User.Email, id, err
+}
@@ -0,0 +1,220 @@
+package notify
+
+import (
+	"agro-connect/database"
+	"agro-connect/i18n"
+	"agro-connect/models"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// schedulerInterval is how often queued notifications are checked
+	schedulerInterval = time.Minute
+	// digestMaxLines limits the number of messages listed in one digest
+	digestMaxLines = 5
+	// retryDelay is how long a queued item waits after each failed delivery
+	retryDelay = 15 * time.Minute
+	// maxAttempts is how often a queued item is tried before it is dropped
+	maxAttempts = 5
+)
+
+// dispatchExternal sends an SMS or email now, or queues it for a digest.
+// Quiet hours only hold back SMS; email does not wake anyone up.
+func dispatchExternal(channel Channel, msg *Message, notificationID uint, pref models.NotificationPreference, settings models.NotificationSettings) {
+	now := time.Now()
+	if channel.Name() != ChannelSMS {
+		settings.QuietHoursEnabled = false
+	}
+
+	queued := models.QueuedNotification{
+		UserID:         msg.User.ID,
+		NotificationID: notificationID,
+		Channel:        channel.Name(),
+		Type:           msg.Type,
+		Event:          msg.Event,
+		Body:           msg.Body,
+	}
+
+	switch {
+	case pref.Frequency == FrequencyHourly || pref.Frequency == FrequencyDaily:
+		queued.Digest = true
+		queued.DeliverAfter = deferUntil(settings, nextDigestTime(settings, pref.Frequency, now))
+	case !urgentEvents[msg.Event] && !quietHoursEnd(settings, now).IsZero():
+		queued.DeliverAfter = quietHoursEnd(settings, now)
+	default:
+		go deliver(channel, msg, notificationID)
+		return
+	}
+
+	if err := database.DB.Create(&queued).Error; err != nil {
+		log.Printf("Failed to queue %s notification for user %d, sending now: %v", channel.Name(), msg.User.ID, err)
+		go deliver(channel, msg, notificationID)
+	}
+}
+
+// StartScheduler periodically sends deferred notifications and digests
+func StartScheduler() {
+	go func() {
+		ticker := time.NewTicker(schedulerInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			RunDue(now)
+		}
+	}()
+}
+
+// RunDue sends every queued notification that is due at now. Items of the
+// same user and channel marked as digest are combined into one message.
+func RunDue(now time.Time) {
+	var due []models.QueuedNotification
+	if err := database.DB.Where("sent_at IS NULL AND deliver_after <= ?", now).
+		Order("user_id, channel, created_at").
+		Find(&due).Error; err != nil {
+		log.Printf("Failed to load queued notifications: %v", err)
+		return
+	}
+
+	type digestKey struct {
+		userID  uint
+		channel string
+	}
+	digests := map[digestKey][]models.QueuedNotification{}
+	var order []digestKey
+
+	for _, item := range due {
+		if item.Digest {
+			key := digestKey{item.UserID, item.Channel}
+			if _, ok := digests[key]; !ok {
+				order = append(order, key)
+			}
+			digests[key] = append(digests[key], item)
+			continue
+		}
+		sendQueued([]models.QueuedNotification{item}, now)
+	}
+
+	for _, key := range order {
+		sendQueued(digests[key], now)
+	}
+}
+
+// sendQueued delivers one queued item, or a digest of several, for a single
+// user and channel
+func sendQueued(items []models.QueuedNotification, now time.Time) {
+	first := items[0]
+
+	// Quiet hours may have been enabled after the item was queued
+	if first.Channel == ChannelSMS {
+		if end := quietHoursEnd(SettingsFor(first.UserID), now); !end.IsZero() {
+			database.DB.Model(&models.QueuedNotification{}).
+				Where("id IN ?", queuedIDs(items)).
+				Update("deliver_after", end)
+			return
+		}
+	}
+
+	// Claim the items so a concurrent run does not send them twice. The
+	// claim is released again when the delivery fails.
+	result := database.DB.Model(&models.QueuedNotification{}).
+		Where("id IN ? AND sent_at IS NULL", queuedIDs(items)).
+		Update("sent_at", now)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+
+	if err := sendClaimed(items); err != nil {
+		log.Printf("Failed to send queued %s notification to user %d: %v", first.Channel, first.UserID, err)
+		releaseQueued(items, now)
+	}
+}
+
+// sendClaimed renders and delivers claimed queue items
+func sendClaimed(items []models.QueuedNotification) error {
+	first := items[0]
+
+	var user models.User
+	if err := database.DB.First(&user, first.UserID).Error; err != nil {
+		return err
+	}
+
+	channel := channelByName(first.Channel)
+	if channel == nil {
+		return fmt.Errorf("unknown channel %q", first.Channel)
+	}
+
+	msg := &Message{
+		User:    user,
+		Type:    first.Type,
+		Event:   first.Event,
+		Subject: "AgroConnect",
+		Body:    first.Body,
+	}
+	if first.Digest {
+		msg.Body = renderDigest(user.Language, items)
+		msg.Subject = i18n.T(user.Language, "notification.digest_subject", nil)
+	}
+
+	return deliver(channel, msg, first.NotificationID)
+}
+
+// releaseQueued returns items whose delivery failed to the queue, to be tried
+// again later. Items that failed maxAttempts times are dropped.
+func releaseQueued(items []models.QueuedNotification, now time.Time) {
+	attempts := 0
+	for _, item := range items {
+		attempts = max(attempts, item.Attempts)
+	}
+	attempts++
+	if attempts >= maxAttempts {
+		log.Printf("Dropping queued %s notification to user %d after %d attempts", items[0].Channel, items[0].UserID, attempts)
+		if err := database.DB.Where("id IN ?", queuedIDs(items)).Delete(&models.QueuedNotification{}).Error; err != nil {
+			log.Printf("Failed to drop queued notifications: %v", err)
+		}
+		return
+	}
+
+	if err := database.DB.Model(&models.QueuedNotification{}).
+		Where("id IN ?", queuedIDs(items)).
+		Updates(map[string]interface{}{
+			"sent_at":       nil,
+			"attempts":      attempts,
+			"deliver_after": now.Add(time.Duration(attempts) * retryDelay),
+		}).Error; err != nil {
+		log.Printf("Failed to release queued notifications: %v", err)
+	}
+}
+
+// renderDigest summarizes several messages in one body
+func renderDigest(language string, items []models.QueuedNotification) string {
+	lines := []string{i18n.T(language, "notification.digest", map[string]interface{}{"count": len(items)})}
+	for i, item := range items {
+		if i == digestMaxLines {
+			lines = append(lines, i18n.T(language, "notification.digest_more", map[string]interface{}{"count": len(items) - digestMaxLines}))
+			break
+		}
+		lines = append(lines, "- "+item.Body)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func channelByName(name string) Channel {
+	switch name {
+	case ChannelSMS:
+		return smsChannel
+	case ChannelEmail:
+		return emailChannel
+	default:
+		return nil
+	}
+}
+
+func queuedIDs(items []models.QueuedNotification) []uint {
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"agro-connect/database"
+	"agro-connect/database/testdb"
+	"agro-connect/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+// outbox records the messages sent through it
+type outbox struct {
+	bodies []string
+}
+
+func (o *outbox) SendSMS(_, body string) (string, error) {
+	o.bodies = append(o.bodies, body)
+	return "sms", nil
+}
+
+func (o *outbox) SendEmail(_, _, body string) (string, error) {
+	o.bodies = append(o.bodies, body)
+	return "email", nil
+}
+
+func TestRunDueReleasesDigestsAfterQuietHours(t *testing.T) {
+	tests := []struct {
+		name      string
+		channel   string
+		quiet     bool
+		now       time.Time
+		sent      int
+		remaining int
+		deferred  time.Time
+	}{
+		{"sms digest", ChannelSMS, false, at(15, 8, 0), 1, 1, time.Time{}},
+		{"sms digest in quiet hours", ChannelSMS, true, at(15, 22, 0), 0, 3, at(16, 7, 0)},
+		{"email digest in quiet hours", ChannelEmail, true, at(15, 22, 0), 1, 1, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testdb.Open(t, &models.User{}, &models.NotificationSettings{},
+				&models.QueuedNotification{}, &models.NotificationDelivery{})
+			sms, email := smsChannel, emailChannel
+			t.Cleanup(func() { smsChannel, emailChannel = sms, email })
+			box := &outbox{}
+			SetSMSProvider(box)
+			SetEmailProvider(box)
+
+			user := models.User{FullName: "Sita", Role: "buyer", Phone: "9800000000", Email: "sita@example.com", Language: "en"}
+			if err := db.Create(&user).Error; err != nil {
+				t.Fatal(err)
+			}
+			settings := DefaultSettings(user.ID)
+			settings.QuietHoursEnabled = tt.quiet
+			if err := db.Create(&settings).Error; err != nil {
+				t.Fatal(err)
+			}
+			now := tt.now.UTC()
+			for i, item := range []models.QueuedNotification{
+				{Body: "New offer on Tomatoes", DeliverAfter: now.Add(-time.Hour)},
+				{Body: "New offer on Onions", DeliverAfter: now},
+				{Body: "New offer on Garlic", DeliverAfter: now.Add(time.Hour)},
+			} {
+				item.UserID, item.Channel, item.Digest = user.ID, tt.channel, true
+				item.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+				if err := db.Create(&item).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			RunDue(now)
+
+			if len(box.bodies) != tt.sent {
+				t.Fatalf("sent %d messages, want %d", len(box.bodies), tt.sent)
+			}
+			if tt.sent > 0 {
+				body := box.bodies[0]
+				if !strings.Contains(body, "Tomatoes") || !strings.Contains(body, "Onions") || strings.Contains(body, "Garlic") {
+					t.Errorf("digest lists the wrong messages:\n%s", body)
+				}
+			}
+
+			var remaining []models.QueuedNotification
+			if err := database.DB.Where("sent_at IS NULL").Order("id").Find(&remaining).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(remaining) != tt.remaining {
+				t.Fatalf("%d messages left in the queue, want %d", len(remaining), tt.remaining)
+			}
+			if !tt.deferred.IsZero() && !remaining[0].DeliverAfter.Equal(tt.deferred) {
+				t.Errorf("deferred until %v, want %v", remaining[0].DeliverAfter.In(Kathmandu), tt.deferred)
+			}
+		})
+	}
+}
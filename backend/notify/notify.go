@@ -0,0 +1,161 @@
+package notify
+
+import (
+	"agro-connect/config"
+	"agro-connect/database"
+	"agro-connect/models"
+	"log"
+	"time"
+)
+
+var (
+	smsChannel   Channel = SMSChannel{Provider: NewFileProvider("sms_outbox.log")}
+	emailChannel Channel = EmailChannel{Provider: NewFileProvider("email_outbox.log")}
+)
+
+// Setup selects the SMS and email providers from the configuration
+func Setup() {
+	switch config.Notify.SMSProvider {
+	case "file", "":
+		smsChannel = SMSChannel{Provider: NewFileProvider(config.Notify.SMSStubFile)}
+	default:
+		log.Printf("Unknown SMS provider %q, falling back to file provider", config.Notify.SMSProvider)
+		smsChannel = SMSChannel{Provider: NewFileProvider(config.Notify.SMSStubFile)}
+	}
+
+	switch config.Notify.EmailProvider {
+	case "file", "":
+		emailChannel = EmailChannel{Provider: NewFileProvider(config.Notify.EmailStubFile)}
+	default:
+		log.Printf("Unknown email provider %q, falling back to file provider", config.Notify.EmailProvider)
+		emailChannel = EmailChannel{Provider: NewFileProvider(config.Notify.EmailStubFile)}
+	}
+}
+
+// SetSMSProvider replaces the SMS provider, e.g. with a real gateway client
+func SetSMSProvider(provider SMSProvider) {
+	smsChannel = SMSChannel{Provider: provider}
+}
+
+// SetEmailProvider replaces the email provider
+func SetEmailProvider(provider EmailProvider) {
+	emailChannel = EmailChannel{Provider: provider}
+}
+
+// DefaultPreference returns the channels used when a user has not saved a
+// preference for a notification type. Farmers get SMS by default because most
+// of them do not open the web app.
+func DefaultPreference(user models.User, notificationType string) models.NotificationPreference {
+	return models.NotificationPreference{
+		UserID: user.ID,
+		Type:   notificationType,
+		InApp:  true,
+		SMS:    user.Role == "farmer",
+		Email:  false,
+
+		Frequency: FrequencyImmediate,
+	}
+}
+
+// PreferenceFor loads the user's channel preference for a notification type
+func PreferenceFor(user models.User, notificationType string) models.NotificationPreference {
+	var pref models.NotificationPreference
+	if err := database.DB.Where("user_id = ? AND type = ?", user.ID, notificationType).First(&pref).Error; err != nil {
+		return DefaultPreference(user, notificationType)
+	}
+	if !ValidFrequency(pref.Frequency) {
+		pref.Frequency = FrequencyImmediate
+	}
+	return pref
+}
+
+// Send renders the event template in the user's language and delivers it on
+// every channel the user enabled for the notification type. In-app delivery
+// happens synchronously; SMS and email are sent in the background, or queued
+// for a digest, and SMS waits until quiet hours end.
+func Send(userID uint, notificationType, event string, relatedID uint, params map[string]interface{}) {
+	if userID == 0 {
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		log.Printf("Failed to load user %d for notification: %v", userID, err)
+		return
+	}
+
+	body := Render(event, user.Language, params)
+
+	msg := &Message{
+		User:      user,
+		Type:      notificationType,
+		Event:     event,
+		RelatedID: relatedID,
+		Subject:   "AgroConnect",
+		Body:      body,
+	}
+
+	pref := PreferenceFor(user, notificationType)
+
+	var notificationID uint
+	if pref.InApp {
+		notification := models.Notification{
+			UserID:    user.ID,
+			Type:      notificationType,
+			RelatedID: relatedID,
+			Message:   body,
+		}
+		if err := database.DB.Create(&notification).Error; err != nil {
+			log.Printf("Failed to create notification for user %d: %v", user.ID, err)
+		} else {
+			notificationID = notification.ID
+			deliver(InAppChannel{}, msg, notificationID)
+		}
+	}
+
+	if !pref.SMS && !pref.Email {
+		return
+	}
+
+	settings := SettingsFor(user.ID)
+	if pref.SMS {
+		dispatchExternal(smsChannel, msg, notificationID, pref, settings)
+	}
+	if pref.Email {
+		dispatchExternal(emailChannel, msg, notificationID, pref, settings)
+	}
+}
+
+// deliver sends msg over one channel and records the outcome
+func deliver(channel Channel, msg *Message, notificationID uint) error {
+	delivery := models.NotificationDelivery{
+		NotificationID: notificationID,
+		UserID:         msg.User.ID,
+		Channel:        channel.Name(),
+		Body:           msg.Body,
+		Status:         "pending",
+	}
+	if err := database.DB.Create(&delivery).Error; err != nil {
+		log.Printf("Failed to record %s delivery: %v", channel.Name(), err)
+	}
+
+	recipient, providerID, err := channel.Deliver(msg, notificationID)
+	delivery.Recipient = recipient
+	delivery.ProviderMessageID = providerID
+	if err != nil {
+		delivery.Status = "failed"
+		delivery.Error = err.Error()
+		log.Printf("Failed to deliver %s notification to user %d: %v", channel.Name(), msg.User.ID, err)
+	} else {
+		now := time.Now()
+		delivery.Status = "sent"
+		delivery.SentAt = &now
+	}
+
+	if delivery.ID != 0 {
+		if err := database.DB.Save(&delivery).Error; err != nil {
+			log.Printf("Failed to update %s delivery: %v", channel.Name(), err)
+		}
+	}
+	return err
+}
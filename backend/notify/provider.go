@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SMSProvider sends a text message to a phone number and returns the
+// provider's message ID. Real gateways (Sparrow SMS, Aakash SMS, ...) implement
+// this interface.
+type SMSProvider interface {
+	SendSMS(to, body string) (string, error)
+}
+
+// EmailProvider sends an email and returns the provider's message ID
+type EmailProvider interface {
+	SendEmail(to, subject, body string) (string, error)
+}
+
+// FileProvider is a development provider that appends every message to a
+// local file instead of sending it
+type FileProvider struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileProvider creates a provider writing to path
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+// SendSMS implements SMSProvider
+func (p *FileProvider) SendSMS(to, body string) (string, error) {
+	return p.write(fmt.Sprintf("SMS to=%s\n%s", to, body))
+}
+
+// SendEmail implements EmailProvider
+func (p *FileProvider) SendEmail(to, subject, body string) (string, error) {
+	return p.write(fmt.Sprintf("EMAIL to=%s subject=%q\n%s", to, subject, body))
+}
+
+func (p *FileProvider) write(entry string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, err := os.OpenFile(p.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	id := "file-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	line := fmt.Sprintf("[%s] id=%s %s\n---\n", time.Now().Format(time.RFC3339), id, strings.TrimSpace(entry))
+	if _, err := f.WriteString(line); err != nil {
+		return "", err
+	}
+	return id, nil
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"agro-connect/database"
+	"agro-connect/models"
+	"fmt"
+	"time"
+)
+
+// Delivery frequencies for SMS and email
+const (
+	FrequencyImmediate = "immediate"
+	FrequencyHourly    = "hourly"
+	FrequencyDaily     = "daily"
+)
+
+// urgentEvents are delivered immediately even during quiet hours
+var urgentEvents = map[string]bool{
+	EventOrderStatusChanged: true,
+}
+
+// Kathmandu is the timezone quiet hours and digest times are expressed in
+var Kathmandu = loadKathmandu()
+
+func loadKathmandu() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Kathmandu"); err == nil {
+		return loc
+	}
+	// Nepal has no daylight saving time, so a fixed zone is exact
+	return time.FixedZone("NPT", 5*60*60+45*60)
+}
+
+// ValidFrequency reports whether frequency is a supported delivery frequency
+func ValidFrequency(frequency string) bool {
+	switch frequency {
+	case FrequencyImmediate, FrequencyHourly, FrequencyDaily:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseClock parses an "HH:MM" time of day into minutes after midnight
+func ParseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// DefaultSettings returns the settings used when a user has not saved any
+func DefaultSettings(userID uint) models.NotificationSettings {
+	return models.NotificationSettings{
+		UserID:          userID,
+		QuietHoursStart: "21:00",
+		QuietHoursEnd:   "07:00",
+		DailyDigestTime: "08:00",
+	}
+}
+
+// SettingsFor loads the user's notification settings
+func SettingsFor(userID uint) models.NotificationSettings {
+	var settings models.NotificationSettings
+	if err := database.DB.Where("user_id = ?", userID).First(&settings).Error; err != nil {
+		return DefaultSettings(userID)
+	}
+	return settings
+}
+
+// quietHoursEnd returns the end of the quiet period containing t, or the zero
+// time when t is outside quiet hours. Periods may span midnight.
+func quietHoursEnd(settings models.NotificationSettings, t time.Time) time.Time {
+	if !settings.QuietHoursEnabled {
+		return time.Time{}
+	}
+	start, err := ParseClock(settings.QuietHoursStart)
+	if err != nil {
+		return time.Time{}
+	}
+	end, err := ParseClock(settings.QuietHoursEnd)
+	if err != nil || start == end {
+		return time.Time{}
+	}
+
+	local := t.In(Kathmandu)
+	now := local.Hour()*60 + local.Minute()
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, Kathmandu)
+
+	switch {
+	case start < end && now >= start && now < end:
+		return midnight.Add(time.Duration(end) * time.Minute)
+	case start > end && now >= start:
+		return midnight.AddDate(0, 0, 1).Add(time.Duration(end) * time.Minute)
+	case start > end && now < end:
+		return midnight.Add(time.Duration(end) * time.Minute)
+	default:
+		return time.Time{}
+	}
+}
+
+// nextDigestTime returns when a digest collecting a message sent at t is due
+func nextDigestTime(settings models.NotificationSettings, frequency string, t time.Time) time.Time {
+	local := t.In(Kathmandu)
+	if frequency == FrequencyHourly {
+		hour := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, Kathmandu)
+		return hour.Add(time.Hour)
+	}
+
+	at, err := ParseClock(settings.DailyDigestTime)
+	if err != nil {
+		at = 8 * 60
+	}
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, Kathmandu)
+	due := midnight.Add(time.Duration(at) * time.Minute)
+	if !due.After(local) {
+		due = due.AddDate(0, 0, 1)
+	}
+	return due
+}
+
+// deferUntil moves t past quiet hours when needed
+func deferUntil(settings models.NotificationSettings, t time.Time) time.Time {
+	if end := quietHoursEnd(settings, t); !end.IsZero() {
+		return end
+	}
+	return t
+}
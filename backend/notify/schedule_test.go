@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"agro-connect/models"
+	"testing"
+	"time"
+)
+
+// at returns a time on a day of January 2025 in Kathmandu
+func at(day, hour, minute int) time.Time {
+	return time.Date(2025, time.January, day, hour, minute, 0, 0, Kathmandu)
+}
+
+func TestDeferUntil(t *testing.T) {
+	overnight := models.NotificationSettings{QuietHoursEnabled: true, QuietHoursStart: "21:00", QuietHoursEnd: "07:00"}
+	afternoon := models.NotificationSettings{QuietHoursEnabled: true, QuietHoursStart: "13:00", QuietHoursEnd: "15:30"}
+	disabled := overnight
+	disabled.QuietHoursEnabled = false
+
+	tests := []struct {
+		name     string
+		settings models.NotificationSettings
+		t        time.Time
+		want     time.Time
+	}{
+		{"disabled", disabled, at(15, 23, 0), at(15, 23, 0)},
+		{"before overnight quiet hours", overnight, at(15, 20, 59), at(15, 20, 59)},
+		{"evening", overnight, at(15, 21, 0), at(16, 7, 0)},
+		{"early morning", overnight, at(15, 6, 59), at(15, 7, 0)},
+		{"after overnight quiet hours", overnight, at(15, 7, 0), at(15, 7, 0)},
+		{"during the day", afternoon, at(15, 14, 10), at(15, 15, 30)},
+		{"after the day's quiet hours", afternoon, at(15, 16, 0), at(15, 16, 0)},
+		{"in another timezone", overnight, at(15, 22, 0).UTC(), at(16, 7, 0)},
+	}
+	for _, tt := range tests {
+		if got := deferUntil(tt.settings, tt.t); !got.Equal(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got.In(Kathmandu), tt.want)
+		}
+	}
+}
+
+func TestNextDigestTime(t *testing.T) {
+	settings := models.NotificationSettings{DailyDigestTime: "08:00"}
+	tests := []struct {
+		name      string
+		settings  models.NotificationSettings
+		frequency string
+		t         time.Time
+		want      time.Time
+	}{
+		{"hourly", settings, FrequencyHourly, at(15, 10, 20), at(15, 11, 0)},
+		{"hourly on the hour", settings, FrequencyHourly, at(15, 10, 0), at(15, 11, 0)},
+		{"daily before digest time", settings, FrequencyDaily, at(15, 7, 30), at(15, 8, 0)},
+		{"daily at digest time", settings, FrequencyDaily, at(15, 8, 0), at(16, 8, 0)},
+		{"daily after digest time", settings, FrequencyDaily, at(15, 18, 0), at(16, 8, 0)},
+		{"invalid digest time", models.NotificationSettings{DailyDigestTime: "8am"}, FrequencyDaily, at(15, 18, 0), at(16, 8, 0)},
+	}
+	for _, tt := range tests {
+		if got := nextDigestTime(tt.settings, tt.frequency, tt.t); !got.Equal(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
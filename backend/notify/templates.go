@@ -0,0 +1,33 @@
+package notify
+
+import (
+	"agro-connect/i18n"
+)
+
+// Events that generate notifications. Each event has a message catalogue
+// entry named "notification.<event>".
+const (
+	EventOfferCreated       = "offer_created"
+	EventOfferStatusChanged = "offer_status_changed"
+	EventOrderStatusChanged = "order_status_changed"
+	EventOrderPlaced        = "order_placed"
+	EventProductExpiring    = "product_expiring"
+	EventListingApproved    = "listing_approved"
+	EventListingRejected    = "listing_rejected"
+	EventPreOrderPlaced     = "pre_order_placed"
+	EventPreOrderConverted  = "pre_order_converted"
+	EventPreOrderCancelled  = "pre_order_cancelled"
+	EventRFQPosted          = "rfq_posted"
+	EventRFQBidReceived     = "rfq_bid_received"
+	EventRFQBidAwarded      = "rfq_bid_awarded"
+	EventRFQBidRejected     = "rfq_bid_rejected"
+	EventAuctionOutbid      = "auction_outbid"
+	EventAuctionWon         = "auction_won"
+	EventAuctionSold        = "auction_sold"
+	EventAuctionUnsold      = "auction_unsold"
+)
+
+// Render returns the text for event in the given language
+func Render(event, language string, params map[string]interface{}) string {
+	return i18n.T(language, "notification."+event, params)
+}
@@ -0,0 +1,384 @@
+package preorder
+
+import (
+	"agro-connect/availability"
+	"agro-connect/database"
+	"agro-connect/models"
+	"agro-connect/notify"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Errors of pre-order operations
+var (
+	ErrNotForward       = errors.New("listing does not take pre-orders")
+	ErrNotPublished     = errors.New("listing is not published")
+	ErrHarvestConfirmed = errors.New("the harvest of this listing was already confirmed")
+	ErrNotPlaced        = errors.New("pre-order was already converted or cancelled")
+	ErrDeposit          = errors.New("deposit cannot exceed the pre-order total")
+)
+
+// CapacityError is returned when a pre-order exceeds the remaining capacity
+type CapacityError struct {
+	Remaining float64
+}
+
+func (e *CapacityError) Error() string {
+	return fmt.Sprintf("only %g left to pre-order", e.Remaining)
+}
+
+// Capacity is how much of a forward listing can be pre-ordered. Capacity is
+// the lower end of the expected yield, so a harvest within the estimate
+// covers every pre-order.
+type Capacity struct {
+	Capacity  float64 `json:"capacity"`
+	Reserved  float64 `json:"reserved"`
+	Remaining float64 `json:"remaining"`
+}
+
+// CapacityOf returns the pre-order capacity of a forward listing
+func CapacityOf(db *gorm.DB, product models.Product) (Capacity, error) {
+	var reserved float64
+	if err := db.Model(&models.PreOrder{}).
+		Where("product_id = ? AND status = ?", product.ID, models.PreOrderPlaced).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&reserved).Error; err != nil {
+		return Capacity{}, err
+	}
+	capacity := Capacity{Reserved: reserved}
+	if product.AwaitingHarvest() {
+		capacity.Capacity = product.YieldMin
+		if remaining := product.YieldMin - reserved; remaining > 0 {
+			capacity.Remaining = remaining
+		}
+	}
+	return capacity, nil
+}
+
+// lockProduct loads a listing and locks it for the rest of the
+// transaction, so concurrent pre-orders are checked against each other
+func lockProduct(tx *gorm.DB, id uint) (models.Product, error) {
+	var product models.Product
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, id).Error
+	return product, err
+}
+
+// Place reserves part of a forward listing's harvest for a buyer at the
+// current listing price
+func Place(preOrder *models.PreOrder) error {
+	var product models.Product
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if product, err = lockProduct(tx, preOrder.ProductID); err != nil {
+			return err
+		}
+		switch {
+		case !product.Forward():
+			return ErrNotForward
+		case !product.AwaitingHarvest():
+			return ErrHarvestConfirmed
+		case !product.Status.Live():
+			return ErrNotPublished
+		}
+
+		capacity, err := CapacityOf(tx, product)
+		if err != nil {
+			return err
+		}
+		if preOrder.Quantity > capacity.Remaining {
+			return &CapacityError{Remaining: capacity.Remaining}
+		}
+		if total := preOrder.Quantity * product.PricePerUnit; preOrder.Deposit > total {
+			return fmt.Errorf("%w of Rs. %g", ErrDeposit, total)
+		}
+
+		preOrder.FarmerID = product.UserID
+		preOrder.PricePerUnit = product.PricePerUnit
+		preOrder.Status = models.PreOrderPlaced
+		preOrder.DepositStatus = models.DepositNone
+		if preOrder.Deposit > 0 {
+			preOrder.DepositStatus = models.DepositPending
+		}
+		return tx.Create(preOrder).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	params := preOrderParams(product, *preOrder)
+	notify.Send(product.UserID, notify.TypeOrder, notify.EventPreOrderPlaced, preOrder.ID, params)
+	return nil
+}
+
+// Cancel cancels a placed pre-order. A received deposit becomes due for
+// refund. The other party is notified.
+func Cancel(preOrder *models.PreOrder, actorID uint, reason string) error {
+	now := time.Now()
+	deposit, refund := preOrder.DepositStatus, preOrder.DepositRefund
+	switch deposit {
+	case models.DepositPending:
+		deposit = models.DepositNone
+	case models.DepositReceived:
+		deposit, refund = models.DepositRefundDue, preOrder.Deposit
+	}
+
+	result := database.DB.Model(&models.PreOrder{}).
+		Where("id = ? AND status = ?", preOrder.ID, models.PreOrderPlaced).
+		Updates(map[string]interface{}{
+			"status":         models.PreOrderCancelled,
+			"cancel_reason":  reason,
+			"cancelled_at":   now,
+			"deposit_status": deposit,
+			"deposit_refund": refund,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotPlaced
+	}
+	preOrder.Status, preOrder.CancelReason, preOrder.CancelledAt = models.PreOrderCancelled, reason, &now
+	preOrder.DepositStatus, preOrder.DepositRefund = deposit, refund
+
+	var product models.Product
+	database.DB.Unscoped().First(&product, preOrder.ProductID)
+	params := preOrderParams(product, *preOrder)
+	for _, recipient := range []uint{preOrder.BuyerID, preOrder.FarmerID} {
+		if recipient != actorID {
+			notify.Send(recipient, notify.TypeOrder, notify.EventPreOrderCancelled, preOrder.ID, params)
+		}
+	}
+	return nil
+}
+
+// depositTransitions lists the deposit statuses a farmer records and the
+// status each follows
+var depositTransitions = map[string]string{
+	models.DepositReceived: models.DepositPending,
+	models.DepositRefunded: models.DepositRefundDue,
+}
+
+// SetDeposit records that the farmer received a promised deposit, or
+// returned the deposit of a cancelled pre-order
+func SetDeposit(preOrder *models.PreOrder, status string) error {
+	from, ok := depositTransitions[status]
+	if !ok {
+		return fmt.Errorf("invalid deposit status %q, expected received or refunded", status)
+	}
+	if preOrder.DepositStatus != from {
+		return fmt.Errorf("cannot mark a %s deposit as %s", preOrder.DepositStatus, status)
+	}
+	result := database.DB.Model(&models.PreOrder{}).
+		Where("id = ? AND deposit_status = ?", preOrder.ID, from).
+		Update("deposit_status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("deposit status changed, reload the pre-order")
+	}
+	preOrder.DepositStatus = status
+	return nil
+}
+
+// Harvest is the outcome of confirming a harvest
+type Harvest struct {
+	Product   models.Product    `json:"product"`
+	PreOrders []models.PreOrder `json:"pre_orders"`
+	Orders    []models.Order    `json:"orders"`
+}
+
+// shortHarvestReason is the cancel reason of pre-orders left unfilled
+const shortHarvestReason = "The harvest was smaller than expected"
+
+// ConfirmHarvest records the actual harvest of a forward listing and turns
+// its pre-orders into orders, first placed first served. Each filled
+// pre-order becomes an accepted offer and an order at the pre-order price;
+// a received deposit is recorded as a payment of the order up to the value
+// of the filled quantity, and the rest is due back to the buyer. Pre-orders the
+// harvest cannot fill are cancelled. Whatever remains stays on the listing.
+func ConfirmHarvest(productID uint, quantity float64, now time.Time) (*Harvest, error) {
+	harvest := &Harvest{}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		product, err := lockProduct(tx, productID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !product.Forward():
+			return ErrNotForward
+		case !product.AwaitingHarvest():
+			return ErrHarvestConfirmed
+		}
+
+		var preOrders []models.PreOrder
+		if err := tx.Where("product_id = ? AND status = ?", product.ID, models.PreOrderPlaced).
+			Order("created_at, id").Find(&preOrders).Error; err != nil {
+			return err
+		}
+
+		remaining := quantity
+		today := availability.Day(now)
+		for i := range preOrders {
+			preOrder := &preOrders[i]
+			filled := preOrder.Quantity
+			if filled > remaining {
+				filled = remaining
+			}
+			if filled <= 0 {
+				if err := cancelUnfilled(tx, preOrder, now); err != nil {
+					return err
+				}
+				continue
+			}
+
+			order, err := convert(tx, product, preOrder, filled, today, now)
+			if err != nil {
+				return err
+			}
+			remaining -= filled
+			harvest.Orders = append(harvest.Orders, *order)
+		}
+
+		product.HarvestConfirmedAt = &now
+		product.Quantity = remaining
+		if product.AvailableFrom.After(today.Time) {
+			product.AvailableFrom = today
+		}
+		product.Status = availability.Status(product, today)
+		if err := tx.Model(&product).Updates(map[string]interface{}{
+			"harvest_confirmed_at": product.HarvestConfirmedAt,
+			"quantity":             product.Quantity,
+			"available_from":       product.AvailableFrom,
+			"status":               product.Status,
+		}).Error; err != nil {
+			return err
+		}
+
+		harvest.Product = product
+		harvest.PreOrders = preOrders
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, preOrder := range harvest.PreOrders {
+		event := notify.EventPreOrderConverted
+		if preOrder.Status == models.PreOrderCancelled {
+			event = notify.EventPreOrderCancelled
+		}
+		notify.Send(preOrder.BuyerID, notify.TypeOrder, event, preOrder.ID, preOrderParams(harvest.Product, preOrder))
+	}
+	return harvest, nil
+}
+
+// convert turns a pre-order into an accepted offer and an order for the
+// filled quantity
+func convert(tx *gorm.DB, product models.Product, preOrder *models.PreOrder, filled float64, today models.Date, now time.Time) (*models.Order, error) {
+	offer := models.Offer{
+		BuyerID:         preOrder.BuyerID,
+		ProductID:       product.ID,
+		Quantity:        filled,
+		Unit:            product.Unit,
+		Price:           preOrder.PricePerUnit,
+		ListingQuantity: filled,
+		ListingPrice:    preOrder.PricePerUnit,
+		Status:          "ACCEPTED",
+		PickupDate:      today.String(),
+	}
+	if err := tx.Create(&offer).Error; err != nil {
+		return nil, err
+	}
+	order := models.Order{
+		OfferID:   offer.ID,
+		FarmerID:  preOrder.FarmerID,
+		BuyerID:   preOrder.BuyerID,
+		ProductID: product.ID,
+		OrderDate: today.String(),
+		Status:    "processing",
+	}
+	if err := tx.Create(&order).Error; err != nil {
+		return nil, err
+	}
+
+	if preOrder.DepositStatus == models.DepositReceived {
+		applied := math.Min(preOrder.Deposit, math.Round(filled*preOrder.PricePerUnit*100)/100)
+		payment := models.Transaction{
+			OrderID:  order.ID,
+			BuyerID:  preOrder.BuyerID,
+			FarmerID: preOrder.FarmerID,
+			Amount:   applied,
+			Method:   "deposit",
+			Status:   "success",
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return nil, err
+		}
+		preOrder.DepositStatus = models.DepositApplied
+		if refund := math.Round((preOrder.Deposit-applied)*100) / 100; refund > 0 {
+			preOrder.DepositStatus, preOrder.DepositRefund = models.DepositRefundDue, refund
+		}
+	} else if preOrder.DepositStatus == models.DepositPending {
+		preOrder.DepositStatus = models.DepositNone
+	}
+
+	preOrder.Status = models.PreOrderConverted
+	preOrder.FulfilledQuantity = filled
+	preOrder.OrderID = &order.ID
+	preOrder.ConvertedAt = &now
+	return &order, tx.Model(preOrder).Updates(map[string]interface{}{
+		"status":             preOrder.Status,
+		"fulfilled_quantity": preOrder.FulfilledQuantity,
+		"order_id":           preOrder.OrderID,
+		"converted_at":       preOrder.ConvertedAt,
+		"deposit_status":     preOrder.DepositStatus,
+		"deposit_refund":     preOrder.DepositRefund,
+	}).Error
+}
+
+// cancelUnfilled cancels a pre-order left out by a short harvest
+func cancelUnfilled(tx *gorm.DB, preOrder *models.PreOrder, now time.Time) error {
+	switch preOrder.DepositStatus {
+	case models.DepositPending:
+		preOrder.DepositStatus = models.DepositNone
+	case models.DepositReceived:
+		preOrder.DepositStatus, preOrder.DepositRefund = models.DepositRefundDue, preOrder.Deposit
+	}
+	preOrder.Status = models.PreOrderCancelled
+	preOrder.CancelReason = shortHarvestReason
+	preOrder.CancelledAt = &now
+	return tx.Model(preOrder).Updates(map[string]interface{}{
+		"status":         preOrder.Status,
+		"cancel_reason":  preOrder.CancelReason,
+		"cancelled_at":   preOrder.CancelledAt,
+		"deposit_status": preOrder.DepositStatus,
+		"deposit_refund": preOrder.DepositRefund,
+	}).Error
+}
+
+func preOrderParams(product models.Product, preOrder models.PreOrder) map[string]interface{} {
+	productNp := product.NameNp
+	if productNp == "" {
+		productNp = product.NameEn
+	}
+	var orderID uint
+	if preOrder.OrderID != nil {
+		orderID = *preOrder.OrderID
+	}
+	return map[string]interface{}{
+		"pre_order_id": preOrder.ID,
+		"product":      product.NameEn,
+		"product_np":   productNp,
+		"quantity":     preOrder.Quantity,
+		"filled":       preOrder.FulfilledQuantity,
+		"unit":         product.Unit,
+		"date":         product.HarvestDate.String(),
+		"reason":       preOrder.CancelReason,
+		"order_id":     orderID,
+	}
+}
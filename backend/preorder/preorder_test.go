@@ -0,0 +1,68 @@
+package preorder
+
+import (
+	"agro-connect/database"
+	"agro-connect/database/testdb"
+	"agro-connect/models"
+	"testing"
+	"time"
+)
+
+func TestConfirmHarvestAppliesDepositsUpToTheFilledValue(t *testing.T) {
+	db := testdb.Open(t, &models.User{}, &models.Product{}, &models.PreOrder{},
+		&models.Offer{}, &models.Order{}, &models.Transaction{})
+
+	now := time.Now()
+	product := models.Product{UserID: 1, NameEn: "Maize", Unit: "kg", PricePerUnit: 50,
+		HarvestDate: models.NewDate(now.AddDate(0, 0, 30)), Status: models.ProductStatusAvailable}
+	if err := db.Create(&product).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		preOrder models.PreOrder
+		paid     float64 // recorded as payment of the order
+		status   string
+		refund   float64
+	}{
+		{"filled", models.PreOrder{Quantity: 10, Deposit: 200, DepositStatus: models.DepositReceived}, 200, models.DepositApplied, 0},
+		{"partly filled", models.PreOrder{Quantity: 10, Deposit: 300, DepositStatus: models.DepositReceived}, 200, models.DepositRefundDue, 100},
+		{"unfilled", models.PreOrder{Quantity: 5, Deposit: 100, DepositStatus: models.DepositReceived}, 0, models.DepositRefundDue, 100},
+		{"promised only", models.PreOrder{Quantity: 5, Deposit: 100, DepositStatus: models.DepositPending}, 0, models.DepositNone, 0},
+	}
+	for i := range tests {
+		preOrder := &tests[i].preOrder
+		preOrder.ProductID, preOrder.BuyerID, preOrder.FarmerID = product.ID, uint(10+i), 1
+		preOrder.PricePerUnit, preOrder.Status = 50, models.PreOrderPlaced
+		preOrder.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+		if err := db.Create(preOrder).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 14 kg fills the first pre-order and 4 kg of the second
+	if _, err := ConfirmHarvest(product.ID, 14, now); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		var preOrder models.PreOrder
+		if err := database.DB.First(&preOrder, tt.preOrder.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if preOrder.DepositStatus != tt.status || preOrder.DepositRefund != tt.refund {
+			t.Errorf("%s: deposit is %s with %g due back, want %s with %g",
+				tt.name, preOrder.DepositStatus, preOrder.DepositRefund, tt.status, tt.refund)
+		}
+
+		var paid float64
+		if preOrder.OrderID != nil {
+			database.DB.Model(&models.Transaction{}).Where("order_id = ?", *preOrder.OrderID).
+				Select("COALESCE(SUM(amount), 0)").Scan(&paid)
+		}
+		if paid != tt.paid {
+			t.Errorf("%s: %g of the deposit paid for the order, want %g", tt.name, paid, tt.paid)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package realtime
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types pushed to connected clients
+const (
+	EventNotification   = "notification"
+	EventOfferUpdated   = "offer.updated"
+	EventOrderUpdated   = "order.updated"
+	EventAuctionUpdated = "auction.updated"
+)
+
+// Event is a single message delivered to one user
+type Event struct {
+	ID        uint64      `json:"id"`
+	UserID    uint        `json:"user_id"`
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Broker distributes events to subscribers. The in-process Hub is the default;
+// a Redis or NATS backed implementation can satisfy the same interface when
+// running more than one backend instance.
+type Broker interface {
+	// Publish delivers an event to every subscriber of event.UserID
+	Publish(event Event)
+	// Subscribe registers a subscriber for userID. Events newer than
+	// lastEventID that are still buffered are returned so a reconnecting
+	// client can catch up. The returned function must be called to unsubscribe.
+	Subscribe(userID uint, lastEventID uint64) (<-chan Event, []Event, func())
+}
+
+const (
+	// historySize is the number of recent events kept per user for resume
+	historySize = 100
+	// subscriberBuffer is the channel size of each subscriber; slow clients
+	// that fall further behind are disconnected and recover through resume
+	subscriberBuffer = 32
+)
+
+// Hub is an in-process Broker
+type Hub struct {
+	mu          sync.Mutex
+	lastID      uint64
+	subscribers map[uint]map[chan Event]struct{}
+	history     map[uint][]Event
+}
+
+// NewHub creates an empty in-process hub
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[uint]map[chan Event]struct{}),
+		history:     make(map[uint][]Event),
+	}
+}
+
+// Publish implements Broker
+func (h *Hub) Publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	event.ID = h.lastID
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	history := append(h.history[event.UserID], event)
+	if len(history) > historySize {
+		history = history[len(history)-historySize:]
+	}
+	h.history[event.UserID] = history
+
+	for ch := range h.subscribers[event.UserID] {
+		select {
+		case ch <- event:
+		default:
+			// Subscriber is not keeping up: close its channel so the stream
+			// ends and the client reconnects with Last-Event-ID, receiving
+			// this event from the history
+			h.evict(event.UserID, ch)
+		}
+	}
+}
+
+// evict removes and closes a subscriber channel. The caller holds h.mu.
+func (h *Hub) evict(userID uint, ch chan Event) {
+	subs, ok := h.subscribers[userID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[ch]; ok {
+		delete(subs, ch)
+		close(ch)
+	}
+	if len(subs) == 0 {
+		delete(h.subscribers, userID)
+	}
+}
+
+// Subscribe implements Broker
+func (h *Hub) Subscribe(userID uint, lastEventID uint64) (<-chan Event, []Event, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan Event]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+
+	var missed []Event
+	if lastEventID > 0 {
+		for _, event := range h.history[userID] {
+			if event.ID > lastEventID {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.evict(userID, ch)
+	}
+
+	return ch, missed, unsubscribe
+}
+
+// DefaultBroker is the broker used by the application
+var DefaultBroker Broker = NewHub()
+
+// Publish sends an event of the given type to a user through DefaultBroker
+func Publish(userID uint, eventType string, data interface{}) {
+	if userID == 0 {
+		return
+	}
+	DefaultBroker.Publish(Event{UserID: userID, Type: eventType, Data: data})
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"agro-connect/controllers"
+	"agro-connect/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAuctionRoutes(router *gin.Engine) {
+	auctionGroup := router.Group("/auctions")
+	auctionGroup.Use(middleware.AuthMiddleware())
+	{
+		auctionGroup.POST("/", middleware.FarmerOnly(), controllers.CreateAuction)
+		auctionGroup.GET("/", controllers.GetAuctions)
+		auctionGroup.GET("/:id", controllers.GetAuction)
+		auctionGroup.GET("/:id/bids", controllers.GetAuctionBids)
+		auctionGroup.POST("/:id/bids", middleware.BuyerOnly(), controllers.PlaceAuctionBid)
+		auctionGroup.POST("/:id/cancel", controllers.CancelAuction)
+	}
+}
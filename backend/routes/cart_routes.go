@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"agro-connect/controllers"
+	"agro-connect/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCartRoutes(router *gin.Engine) {
+	cartGroup := router.Group("/cart")
+	cartGroup.Use(middleware.AuthMiddleware(), middleware.BuyerOnly())
+	{
+		cartGroup.GET("/", controllers.GetCart)
+		cartGroup.DELETE("/", controllers.ClearCart)
+		cartGroup.POST("/items", controllers.AddCartItem)
+		cartGroup.PUT("/items/:id", controllers.UpdateCartItem)
+		cartGroup.DELETE("/items/:id", controllers.RemoveCartItem)
+		cartGroup.POST("/checkout", controllers.Checkout)
+	}
+}
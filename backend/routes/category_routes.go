@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"agro-connect/controllers"
+	"agro-connect/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCategoryRoutes(router *gin.Engine) {
+	// Public category tree
+	categories := router.Group("/categories")
+	{
+		categories.GET("", controllers.GetCategories)
+		categories.GET("/:id", controllers.GetCategoryByID)
+	}
+
+	// Admin management of the taxonomy
+	admin := router.Group("/admin/categories")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminOnly())
+	{
+		admin.POST("", controllers.CreateCategory)
+		admin.PUT("/:id", controllers.UpdateCategory)
+		admin.DELETE("/:id", controllers.DeleteCategory)
+	}
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"agro-connect/controllers"
+	"agro-connect/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterMarketRoutes(router *gin.Engine) {
+	// Public price statistics and wholesale reference prices
+	marketGroup := router.Group("/market")
+	{
+		marketGroup.GET("/prices", controllers.GetMarketPrices)
+		marketGroup.GET("/reference-prices", controllers.GetReferencePrices)
+	}
+
+	// Admin import of wholesale market price sheets
+	admin := router.Group("/admin/reference-prices")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminOnly())
+	{
+		admin.POST("/import", controllers.ImportReferencePrices)
+		admin.GET("/mappings", controllers.GetCommodityMappings)
+		admin.PUT("/mappings", controllers.SaveCommodityMapping)
+		admin.DELETE("/mappings/:id", controllers.DeleteCommodityMapping)
+	}
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"agro-connect/controllers"
+	"agro-connect/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterModerationRoutes(r *gin.Engine) {
+	// Admin-only Routes
+	admin := r.Group("/admin/moderation")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminOnly())
+	{
+		admin.GET("/", controllers.GetModerationQueue)         // GET  /admin/moderation?status=pending
+		admin.POST("/:id/approve", controllers.ApproveListing) // POST /admin/moderation/:id/approve
+		admin.POST("/:id/reject", controllers.RejectListing)   // POST /admin/moderation/:id/reject
+	}
+}
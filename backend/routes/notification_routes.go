@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"agro-connect/controllers"
+	"agro-connect/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterNotificationRoutes(router *gin.Engine) {
+	notificationGroup := router.Group("/notifications")
+	notificationGroup.Use(middleware.AuthMiddleware()) // Users only see their own notifications
+
+	{
+		// GET /notifications?page=1&pageSize=10&unread=true&type=offer
+		notificationGroup.GET("/", controllers.GetNotifications)
+
+		// Unread count for the dashboard bell
+		// GET /notifications/unread-count
+		notificationGroup.GET("/unread-count", controllers.GetUnreadNotificationCount)
+
+		// Channel preferences (in-app, SMS, email) per notification type
+		// GET /notifications/preferences
+		// PUT /notifications/preferences
+		notificationGroup.GET("/preferences", controllers.GetNotificationPreferences)
+		notificationGroup.PUT("/preferences", controllers.UpdateNotificationPreferences)
+
+		// Quiet hours and daily digest time (Asia/Kathmandu)
+		// GET /notifications/settings
+		// PUT /notifications/settings
+		notificationGroup.GET("/settings", controllers.GetNotificationSettings)
+		notificationGroup.PUT("/settings", controllers.UpdateNotificationSettings)
+
+		// Delivery status of sent messages
+		// GET /notifications/deliveries?channel=sms&status=failed
+		notificationGroup.GET("/deliveries", controllers.GetNotificationDeliveries)
+
+		// PATCH /notifications/read-all
+		notificationGroup.PATCH("/read-all", controllers.MarkAllNotificationsRead)
+
+		// PATCH /notifications/:id/read
+		notificationGroup.PATCH("/:id/read", controllers.MarkNotificationRead)
+	}
+}
@@ -38,6 +38,10 @@ func RegisterOrderRoutes(router *gin.Engine) {
 		// PATCH /orders/:id/status
 		orderGroup.PATCH("/:id/status", middleware.RolesAllowed("farmer", "admin"), controllers.UpdateOrderStatus)
 
+		// Update the status of one line of an order placed from the cart
+		// PATCH /orders/:id/items/:itemId/status
+		orderGroup.PATCH("/:id/items/:itemId/status", middleware.RolesAllowed("farmer", "admin"), controllers.UpdateOrderItemStatus)
+
 		// Get orders by buyer
 		// GET /orders/buyer/:buyer_id
 		orderGroup.GET("/buyer/:buyer_id", middleware.RolesAllowed("buyer", "admin"), controllers.GetOrdersByBuyer)
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"agro-connect/controllers"
+	"agro-connect/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterPreOrderRoutes(router *gin.Engine) {
+	preOrderGroup := router.Group("/pre-orders")
+	preOrderGroup.Use(middleware.AuthMiddleware())
+	{
+		preOrderGroup.POST("/", middleware.BuyerOnly(), controllers.PlacePreOrder)
+		preOrderGroup.GET("/", controllers.GetPreOrders)
+		preOrderGroup.GET("/:id", controllers.GetPreOrder)
+		preOrderGroup.POST("/:id/cancel", controllers.CancelPreOrder)
+		preOrderGroup.PUT("/:id/deposit", controllers.UpdatePreOrderDeposit)
+	}
+}
@@ -28,6 +28,14 @@ func RegisterProductRoutes(router *gin.Engine) {
 	productGroup.GET("/", controllers.GetAllProducts)
 	productGroup.GET("/:id", controllers.GetProductByID)
 	productGroup.GET("/search", controllers.SearchProducts) // New search endpoint
+	productGroup.GET("/nearby", controllers.GetNearbyProducts)
+	productGroup.GET("/statuses", controllers.GetProductStatuses)
+	productGroup.GET("/:id/images", controllers.GetProductImages)
+	productGroup.GET("/:id/variants", controllers.GetProductVariants)
+	productGroup.GET("/:id/pre-orders/capacity", controllers.GetPreOrderCapacity)
+	productGroup.GET("/:id/price-history", controllers.GetProductPriceHistory)
+	productGroup.GET("/:id/extend", controllers.ExtendProductByLink) // Signed link from expiry reminders
+	productGroup.POST("/:id/extend/confirm", controllers.ConfirmExtendByLink)
 
 	// Health check endpoint
 	productGroup.GET("/ping", func(c *gin.Context) {
@@ -49,12 +57,29 @@ func RegisterProductRoutes(router *gin.Engine) {
 		productGroup.Use(middleware.FarmerOnly())
 		{
 			productGroup.POST("/", controllers.CreateProduct)
+			productGroup.POST("/import", controllers.ImportProducts)
+			productGroup.GET("/import/template", controllers.GetProductImportTemplate)
+			productGroup.GET("/me/export", controllers.ExportProducts)
 			productGroup.PUT("/:id", controllers.UpdateProduct)
 			productGroup.PATCH("/:id", controllers.PartialUpdateProduct) // New endpoint
 			productGroup.DELETE("/:id", controllers.DeleteProduct)
 
 			// Product status management
 			productGroup.PUT("/:id/status", controllers.UpdateProductStatus)
+			productGroup.POST("/:id/extend", controllers.ExtendProduct)
+			productGroup.GET("/:id/moderation", controllers.GetProductModeration)
+			productGroup.POST("/:id/harvest", controllers.ConfirmHarvest) // Converts pre-orders into orders
+
+			// Product gallery management
+			productGroup.POST("/:id/images", controllers.AddProductImages)
+			productGroup.PUT("/:id/images/order", controllers.ReorderProductImages)
+			productGroup.PUT("/:id/images/:imageId/primary", controllers.SetPrimaryProductImage)
+			productGroup.DELETE("/:id/images/:imageId", controllers.DeleteProductImage)
+
+			// Product variants (grades, sizes, packaging)
+			productGroup.POST("/:id/variants", controllers.AddProductVariant)
+			productGroup.PUT("/:id/variants/:variantId", controllers.UpdateProductVariant)
+			productGroup.DELETE("/:id/variants/:variantId", controllers.DeleteProductVariant)
 		}
 
 		// Admin-only routes
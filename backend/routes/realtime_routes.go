@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"agro-connect/controllers"
+	"agro-connect/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRealtimeRoutes(router *gin.Engine) {
+	// Server-Sent Events stream of notifications, offer and order updates
+	// GET /realtime?token=<jwt>
+	router.GET("/realtime", middleware.StreamAuthMiddleware(), controllers.StreamEvents)
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"agro-connect/controllers"
+	"agro-connect/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRFQRoutes(router *gin.Engine) {
+	rfqGroup := router.Group("/rfqs")
+	rfqGroup.Use(middleware.AuthMiddleware())
+	{
+		rfqGroup.POST("/", middleware.BuyerOnly(), controllers.CreateRFQ)
+		rfqGroup.GET("/", controllers.GetRFQs)
+		rfqGroup.GET("/bids", middleware.FarmerOnly(), controllers.GetMyBids)
+		rfqGroup.GET("/:id", controllers.GetRFQ)
+		rfqGroup.POST("/:id/award", controllers.AwardRFQ)
+		rfqGroup.POST("/:id/close", controllers.CloseRFQ)
+		rfqGroup.GET("/:id/bids", controllers.GetRFQBids)
+		rfqGroup.POST("/:id/bids", middleware.FarmerOnly(), controllers.SubmitBid)
+		rfqGroup.PUT("/:id/bids/:bidId", middleware.FarmerOnly(), controllers.UpdateBid)
+		rfqGroup.DELETE("/:id/bids/:bidId", middleware.FarmerOnly(), controllers.WithdrawBid)
+	}
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"agro-connect/controllers"
+	"agro-connect/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSearchRoutes(router *gin.Engine) {
+	// Admin management of the produce synonym dictionary used by product search
+	admin := router.Group("/admin/search")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminOnly())
+	{
+		admin.GET("/synonyms", controllers.GetSearchSynonyms)
+		admin.POST("/synonyms", controllers.CreateSearchSynonyms)
+		admin.DELETE("/synonyms/:id", controllers.DeleteSearchSynonym)
+	}
+}
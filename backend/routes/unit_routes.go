@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"agro-connect/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterUnitRoutes(router *gin.Engine) {
+	// Public units registry
+	unitGroup := router.Group("/units")
+	{
+		unitGroup.GET("", controllers.GetUnits)
+		unitGroup.GET("/convert", controllers.ConvertUnits)
+	}
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"agro-connect/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterUploadRoutes(router *gin.Engine) {
+	// Uploaded files, served from the configured storage
+	router.GET("/uploads/*key", controllers.ServeUpload)
+	router.HEAD("/uploads/*key", controllers.ServeUpload)
+}
@@ -0,0 +1,124 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ProductVector is the weighted full-text document of a product. English
+// fields use the English stemmer; Nepali fields use the "simple" configuration
+// because Postgres ships no Nepali stemmer. The expression must match the
+// index created in database.createSearchIndexes exactly.
+const ProductVector = `(
+	setweight(to_tsvector('english', coalesce(name_en, '')), 'A') ||
+	setweight(to_tsvector('simple', coalesce(name_np, '')), 'A') ||
+	setweight(to_tsvector('english', coalesce(description_en, '')), 'B') ||
+	setweight(to_tsvector('simple', coalesce(description_np, '')), 'B')
+)`
+
+// Terms splits a raw query into lower-cased words, dropping punctuation and
+// tsquery operators. Devanagari vowel signs and viramas are kept.
+func Terms(query string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r))
+	})
+
+	seen := map[string]bool{}
+	terms := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if !seen[f] {
+			seen[f] = true
+			terms = append(terms, f)
+		}
+	}
+	return terms
+}
+
+// Query is a search query expanded with synonyms and transliterations
+type Query struct {
+	// Terms are the words the user typed
+	Terms []string
+	// Groups holds, per typed word, the word followed by its alternatives.
+	// A multi-word query that is itself a known synonym forms one group.
+	Groups [][]string
+	// TSQuery matches any alternative of every group as a prefix
+	TSQuery string
+	// Alternatives is every spelling across all groups, used for fuzzy
+	// trigram matching of product names
+	Alternatives []string
+}
+
+// Build splits a raw query and expands every word with curated synonyms and
+// Latin/Devanagari transliterations, so "golbheda", "गोलभेडा" and "tomato"
+// match the same listings
+func Build(raw string) Query {
+	q := Query{Terms: Terms(raw)}
+	if len(q.Terms) == 0 {
+		return q
+	}
+
+	phrase := strings.Join(q.Terms, " ")
+	if len(q.Terms) > 1 && len(Synonyms(phrase)) > 0 {
+		q.Groups = [][]string{append([]string{phrase}, Synonyms(phrase)...)}
+	} else {
+		for _, term := range q.Terms {
+			q.Groups = append(q.Groups, alternatives(term))
+		}
+	}
+
+	seen := map[string]bool{}
+	groupQueries := make([]string, 0, len(q.Groups))
+	for _, group := range q.Groups {
+		options := make([]string, 0, len(group))
+		for _, alt := range group {
+			if pq := phraseQuery(alt); pq != "" {
+				options = append(options, pq)
+			}
+			if !seen[alt] {
+				seen[alt] = true
+				q.Alternatives = append(q.Alternatives, alt)
+			}
+		}
+		groupQueries = append(groupQueries, "("+strings.Join(options, " | ")+")")
+	}
+	q.TSQuery = strings.Join(groupQueries, " & ")
+
+	return q
+}
+
+// alternatives returns term followed by its synonyms and transliterations
+func alternatives(term string) []string {
+	result := []string{term}
+	seen := map[string]bool{term: true}
+	add := func(values ...string) {
+		for _, v := range values {
+			if !seen[v] {
+				seen[v] = true
+				result = append(result, v)
+			}
+		}
+	}
+
+	add(Synonyms(term)...)
+	if IsDevanagari(term) {
+		if roman := ToRoman(term); roman != "" {
+			add(roman)
+			add(Synonyms(roman)...)
+		}
+	} else {
+		for _, spelling := range ToDevanagari(term) {
+			add(spelling)
+			add(Synonyms(spelling)...)
+		}
+	}
+	return result
+}
+
+// phraseQuery matches the words of text in sequence, each as a prefix
+func phraseQuery(text string) string {
+	words := Terms(text)
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " <-> ")
+}
@@ -0,0 +1,150 @@
+package search
+
+import (
+	"agro-connect/models"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// BuiltinSynonyms maps common produce to their English, romanized Nepali and
+// Devanagari names. Admins extend it through the SearchSynonym table.
+var BuiltinSynonyms = map[string][]string{
+	"tomato":       {"tomatoes", "golbheda", "golveda", "golbhenda", "गोलभेडा"},
+	"potato":       {"potatoes", "aalu", "alu", "aloo", "आलु"},
+	"cauliflower":  {"kauli", "cauli", "काउली"},
+	"cabbage":      {"banda", "bandakobi", "bandakopi", "बन्दा", "बन्दाकोपी"},
+	"onion":        {"onions", "pyaj", "pyaaj", "प्याज"},
+	"garlic":       {"lasun", "lasoon", "लसुन"},
+	"ginger":       {"aduwa", "adua", "अदुवा"},
+	"chilli":       {"chili", "chilly", "khursani", "खुर्सानी"},
+	"spinach":      {"palungo", "palungo saag", "पालुङ्गो"},
+	"mustard leaf": {"rayo", "rayo saag", "रायोको साग", "रायो"},
+	"radish":       {"mula", "moola", "मुला"},
+	"carrot":       {"gajar", "गाजर"},
+	"pumpkin":      {"pharsi", "farsi", "फर्सी"},
+	"cucumber":     {"kakro", "काक्रो"},
+	"eggplant":     {"brinjal", "bhanta", "भण्टा", "भन्टा"},
+	"okra":         {"ladyfinger", "bhindi", "भिण्डी", "भिन्डी"},
+	"beans":        {"simi", "सिमी"},
+	"peas":         {"kerau", "केराउ"},
+	"bitter gourd": {"karela", "करेला"},
+	"coriander":    {"dhaniya", "dhaniya patta", "धनियाँ"},
+	"mushroom":     {"chyau", "च्याउ"},
+	"rice":         {"chamal", "चामल"},
+	"paddy":        {"dhan", "धान"},
+	"maize":        {"corn", "makai", "मकै"},
+	"wheat":        {"gahun", "gahu", "गहुँ"},
+	"lentil":       {"dal", "daal", "दाल"},
+	"soybean":      {"bhatmas", "भटमास"},
+	"milk":         {"dudh", "दूध"},
+	"ghee":         {"ghiu", "घिउ"},
+	"honey":        {"maha", "मह"},
+	"apple":        {"syau", "स्याउ"},
+	"orange":       {"suntala", "सुन्तला"},
+	"banana":       {"kera", "केरा"},
+	"mango":        {"aanp", "aap", "आँप"},
+	"lemon":        {"kagati", "कागती"},
+	"cardamom":     {"alaichi", "alainchi", "अलैंची"},
+}
+
+// dictionary maps every known term to the canonical names it belongs to
+type dictionary struct {
+	mu      sync.RWMutex
+	groups  map[string][]string // canonical -> terms
+	byTerms map[string][]string // term -> canonicals
+}
+
+var synonyms = &dictionary{}
+
+func init() {
+	synonyms.load(nil)
+}
+
+// load rebuilds the dictionary from the built-in groups plus custom entries
+func (d *dictionary) load(custom []models.SearchSynonym) {
+	groups := map[string][]string{}
+	add := func(canonical, term string) {
+		canonical = strings.ToLower(strings.TrimSpace(canonical))
+		term = strings.ToLower(strings.TrimSpace(term))
+		if canonical == "" || term == "" {
+			return
+		}
+		for _, existing := range groups[canonical] {
+			if existing == term {
+				return
+			}
+		}
+		groups[canonical] = append(groups[canonical], term)
+	}
+
+	for canonical, terms := range BuiltinSynonyms {
+		add(canonical, canonical)
+		for _, term := range terms {
+			add(canonical, term)
+		}
+	}
+	for _, s := range custom {
+		add(s.Canonical, s.Canonical)
+		add(s.Canonical, s.Term)
+	}
+
+	byTerms := map[string][]string{}
+	for canonical, terms := range groups {
+		for _, term := range terms {
+			byTerms[term] = append(byTerms[term], canonical)
+		}
+	}
+
+	d.mu.Lock()
+	d.groups, d.byTerms = groups, byTerms
+	d.mu.Unlock()
+}
+
+// ReloadSynonyms merges the custom synonyms stored in the database into the
+// built-in dictionary. Call it at startup and after admins change synonyms.
+func ReloadSynonyms(db *gorm.DB) error {
+	var custom []models.SearchSynonym
+	if err := db.Find(&custom).Error; err != nil {
+		return err
+	}
+	synonyms.load(custom)
+	return nil
+}
+
+// Synonyms returns every term sharing a synonym group with term, excluding
+// term itself
+func Synonyms(term string) []string {
+	term = strings.ToLower(strings.TrimSpace(term))
+
+	synonyms.mu.RLock()
+	defer synonyms.mu.RUnlock()
+
+	seen := map[string]bool{term: true}
+	var result []string
+	for _, canonical := range synonyms.byTerms[term] {
+		for _, t := range synonyms.groups[canonical] {
+			if !seen[t] {
+				seen[t] = true
+				result = append(result, t)
+			}
+		}
+	}
+	return result
+}
+
+// SynonymGroups returns a copy of the merged dictionary with sorted terms
+func SynonymGroups() map[string][]string {
+	synonyms.mu.RLock()
+	defer synonyms.mu.RUnlock()
+
+	groups := make(map[string][]string, len(synonyms.groups))
+	for canonical, terms := range synonyms.groups {
+		sorted := append([]string(nil), terms...)
+		sort.Strings(sorted)
+		groups[canonical] = sorted
+	}
+	return groups
+}
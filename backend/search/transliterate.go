@@ -0,0 +1,229 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	virama = "्"
+	// maxCandidates bounds the spelling variants generated for one word
+	maxCandidates = 16
+)
+
+type letter struct {
+	roman string
+	// alternatives lists Devanagari spellings in order of likelihood. Romanized
+	// Nepali does not distinguish dental and retroflex consonants or short and
+	// long vowels, so several spellings are tried.
+	alternatives []string
+}
+
+// consonants ordered longest roman spelling first for greedy matching
+var consonants = []letter{
+	{"ksh", []string{"क्ष"}},
+	{"chh", []string{"छ"}},
+	{"gy", []string{"ज्ञ"}},
+	{"kh", []string{"ख"}},
+	{"gh", []string{"घ"}},
+	{"ng", []string{"ङ"}},
+	{"ch", []string{"च"}},
+	{"jh", []string{"झ"}},
+	{"th", []string{"थ", "ठ"}},
+	{"dh", []string{"ध", "ढ"}},
+	{"ph", []string{"फ"}},
+	{"bh", []string{"भ"}},
+	{"sh", []string{"श", "ष"}},
+	{"k", []string{"क"}},
+	{"q", []string{"क"}},
+	{"g", []string{"ग"}},
+	{"c", []string{"च"}},
+	{"j", []string{"ज"}},
+	{"z", []string{"ज"}},
+	{"t", []string{"त", "ट"}},
+	{"d", []string{"द", "ड"}},
+	{"n", []string{"न", "ण"}},
+	{"p", []string{"प"}},
+	{"f", []string{"फ"}},
+	{"b", []string{"ब"}},
+	{"m", []string{"म"}},
+	{"y", []string{"य"}},
+	{"r", []string{"र"}},
+	{"l", []string{"ल"}},
+	{"w", []string{"व"}},
+	{"v", []string{"व"}},
+	{"s", []string{"स"}},
+	{"h", []string{"ह"}},
+	{"x", []string{"क्स"}},
+}
+
+// vowels ordered longest first. independent is the letter used at the start
+// of a syllable, sign the dependent form (matra) after a consonant.
+var vowels = []struct {
+	roman       string
+	independent []string
+	sign        []string
+}{
+	{"aa", []string{"आ"}, []string{"ा"}},
+	{"ai", []string{"ऐ"}, []string{"ै"}},
+	{"au", []string{"औ", "आउ"}, []string{"ौ", "ाउ"}},
+	{"ee", []string{"ई"}, []string{"ी"}},
+	{"ii", []string{"ई"}, []string{"ी"}},
+	{"oo", []string{"ऊ"}, []string{"ू"}},
+	{"uu", []string{"ऊ"}, []string{"ू"}},
+	{"ri", []string{"ऋ", "रि"}, []string{"ृ", "्रि"}},
+	{"a", []string{"अ", "आ"}, []string{"", "ा"}},
+	{"i", []string{"इ", "ई"}, []string{"ि", "ी"}},
+	{"u", []string{"उ", "ऊ"}, []string{"ु", "ू"}},
+	{"e", []string{"ए"}, []string{"े"}},
+	{"o", []string{"ओ"}, []string{"ो"}},
+}
+
+// ToDevanagari returns likely Devanagari spellings of a romanized Nepali
+// word, most likely first. It returns nil for words that are not plain Latin
+// letters.
+func ToDevanagari(word string) []string {
+	word = strings.ToLower(word)
+	for _, r := range word {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			return nil
+		}
+	}
+
+	// Beam of spellings; cost counts how many less likely alternatives a
+	// spelling used, so the most likely spellings survive the cap
+	type candidate struct {
+		text string
+		cost int
+	}
+	candidates := []candidate{{}}
+	extend := func(options []string) {
+		next := make([]candidate, 0, len(candidates)*len(options))
+		for _, c := range candidates {
+			for cost, o := range options {
+				next = append(next, candidate{c.text + o, c.cost + cost})
+			}
+		}
+		sort.SliceStable(next, func(i, j int) bool { return next[i].cost < next[j].cost })
+		if len(next) > maxCandidates {
+			next = next[:maxCandidates]
+		}
+		candidates = next
+	}
+
+	for i := 0; i < len(word); {
+		if cons, n := matchConsonant(word[i:]); n > 0 {
+			i += n
+			extend(cons.alternatives)
+
+			if sign, n := matchVowel(word[i:], false); n > 0 {
+				i += n
+				extend(sign)
+			} else if i < len(word) {
+				// Consonant cluster, e.g. "py" in "pyaj"
+				extend([]string{virama})
+			}
+			continue
+		}
+
+		if independent, n := matchVowel(word[i:], true); n > 0 {
+			i += n
+			extend(independent)
+			continue
+		}
+		i++
+	}
+
+	spellings := make([]string, len(candidates))
+	for i, c := range candidates {
+		spellings[i] = c.text
+	}
+	return spellings
+}
+
+func matchConsonant(s string) (letter, int) {
+	for _, c := range consonants {
+		if strings.HasPrefix(s, c.roman) {
+			return c, len(c.roman)
+		}
+	}
+	return letter{}, 0
+}
+
+func matchVowel(s string, independent bool) ([]string, int) {
+	for _, v := range vowels {
+		if strings.HasPrefix(s, v.roman) {
+			if independent {
+				return v.independent, len(v.roman)
+			}
+			return v.sign, len(v.roman)
+		}
+	}
+	return nil, 0
+}
+
+var devanagariToRoman = map[rune]string{
+	'अ': "a", 'आ': "aa", 'इ': "i", 'ई': "i", 'उ': "u", 'ऊ': "u", 'ऋ': "ri",
+	'ए': "e", 'ऐ': "ai", 'ओ': "o", 'औ': "au",
+	'क': "k", 'ख': "kh", 'ग': "g", 'घ': "gh", 'ङ': "ng",
+	'च': "ch", 'छ': "chh", 'ज': "j", 'झ': "jh", 'ञ': "ny",
+	'ट': "t", 'ठ': "th", 'ड': "d", 'ढ': "dh", 'ण': "n",
+	'त': "t", 'थ': "th", 'द': "d", 'ध': "dh", 'न': "n",
+	'प': "p", 'फ': "ph", 'ब': "b", 'भ': "bh", 'म': "m",
+	'य': "y", 'र': "r", 'ल': "l", 'व': "w", 'श': "sh", 'ष': "sh", 'स': "s", 'ह': "h",
+}
+
+var devanagariSigns = map[rune]string{
+	'ा': "a", 'ि': "i", 'ी': "i", 'ु': "u", 'ू': "u", 'ृ': "ri",
+	'े': "e", 'ै': "ai", 'ो': "o", 'ौ': "au",
+	'ं': "n", 'ँ': "n", 'ः': "h",
+}
+
+// ToRoman returns the common Latin spelling of a Devanagari word, or "" when
+// the word is not Devanagari. The inherent vowel is dropped at the end of the
+// word, as is usual when writing Nepali in Latin script.
+func ToRoman(word string) string {
+	runes := []rune(word)
+	var b strings.Builder
+	converted := false
+
+	for i, r := range runes {
+		if roman, ok := devanagariToRoman[r]; ok {
+			converted = true
+			b.WriteString(roman)
+
+			isConsonant := r >= 'क' && r <= 'ह'
+			if !isConsonant || i == len(runes)-1 {
+				continue
+			}
+			next := runes[i+1]
+			if _, sign := devanagariSigns[next]; sign && next != 'ं' && next != 'ँ' && next != 'ः' {
+				continue
+			}
+			if string(next) == virama {
+				continue
+			}
+			b.WriteString("a")
+			continue
+		}
+		if roman, ok := devanagariSigns[r]; ok {
+			b.WriteString(roman)
+		}
+	}
+
+	if !converted {
+		return ""
+	}
+	return b.String()
+}
+
+// IsDevanagari reports whether s contains Devanagari letters
+func IsDevanagari(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Devanagari, r) {
+			return true
+		}
+	}
+	return false
+}
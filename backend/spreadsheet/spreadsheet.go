@@ -0,0 +1,205 @@
+package spreadsheet
+
+import (
+	"agro-connect/search"
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Formats of written sheets
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ContentTypes maps each format to its content type
+var ContentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ReadRecords reads the rows of a CSV file, or of the first sheet of an XLSX
+// file, chosen by the file extension. Sheets written by this package and by
+// spreadsheet programs both read back.
+func ReadRecords(r io.Reader, filename string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		// Skip the byte order mark written by spreadsheet programs
+		buffered := bufio.NewReader(r)
+		if bom, err := buffered.Peek(3); err == nil && string(bom) == "\ufeff" {
+			buffered.Discard(3)
+		}
+		reader := csv.NewReader(buffered)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		return records, nil
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid XLSX: %w", err)
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("XLSX file has no sheets")
+		}
+		// Raw values keep dates as serial numbers instead of a locale format
+		records, err := f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, fmt.Errorf("invalid XLSX: %w", err)
+		}
+		return records, nil
+	default:
+		return nil, fmt.Errorf("unsupported file type %q, expected .csv or .xlsx", filepath.Ext(filename))
+	}
+}
+
+// FindHeader returns the index of the first row naming every required
+// column, and the index of each recognized column in it. columns lists the
+// accepted headers of each column. Headers are compared after lower-casing
+// and dropping punctuation, so "Name (EN)" reads as "name en". A header also
+// matches a name it starts with, e.g. "Minimum (Rs.)" matches "minimum",
+// unless another column names it exactly.
+func FindHeader(records [][]string, columns map[string][]string, required ...string) (int, map[string]int) {
+	for i, record := range records {
+		found := map[string]int{}
+		for j, value := range record {
+			header := strings.Join(search.Terms(value), " ")
+			if header == "" {
+				continue
+			}
+			if column := matchHeader(header, columns, found, false); column != "" {
+				found[column] = j
+			} else if column := matchHeader(header, columns, found, true); column != "" {
+				found[column] = j
+			}
+		}
+
+		complete := true
+		for _, column := range required {
+			if _, ok := found[column]; !ok {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			return i, found
+		}
+	}
+	return -1, nil
+}
+
+func matchHeader(header string, columns map[string][]string, found map[string]int, prefix bool) string {
+	for column, names := range columns {
+		if _, ok := found[column]; ok {
+			continue
+		}
+		for _, name := range names {
+			if header == name || (prefix && strings.HasPrefix(header, name+" ")) {
+				return column
+			}
+		}
+	}
+	return ""
+}
+
+var (
+	devanagariDigits = strings.NewReplacer("०", "0", "१", "1", "२", "2", "३", "3", "४", "4", "५", "5", "६", "6", "७", "7", "८", "8", "९", "9")
+	numberPattern    = regexp.MustCompile(`[0-9]+(\.[0-9]+)?`)
+)
+
+// ParseNumber reads numbers such as "60", "Rs. 1,200.50" or "रू ६०"
+func ParseNumber(value string) (float64, error) {
+	value = strings.ReplaceAll(devanagariDigits.Replace(value), ",", "")
+	number := numberPattern.FindString(value)
+	if number == "" {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	return strconv.ParseFloat(number, 64)
+}
+
+// ParseDate reads ISO dates, with "-", "/" or "." as separator, and Excel
+// serial dates
+func ParseDate(value string) (time.Time, error) {
+	value = devanagariDigits.Replace(value)
+	for _, layout := range []string{"2006-01-02", "2006/01/02", "2006.01.02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if t.Year() > 2060 {
+				return time.Time{}, fmt.Errorf("date %s looks like a Bikram Sambat date; use Gregorian dates", value)
+			}
+			return t, nil
+		}
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 0 {
+		return excelize.ExcelDateToTime(serial, false)
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+}
+
+// Sheet is a named table of rows, the first of which is the header
+type Sheet struct {
+	Name string
+	Rows [][]string
+}
+
+// Write writes sheets in format. CSV holds only the first sheet and starts
+// with a byte order mark, so spreadsheet programs read Devanagari text as
+// UTF-8.
+func Write(w io.Writer, format string, sheets ...Sheet) error {
+	switch format {
+	case FormatCSV:
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return err
+		}
+		writer := csv.NewWriter(w)
+		if len(sheets) > 0 {
+			if err := writer.WriteAll(sheets[0].Rows); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case FormatXLSX:
+		f := excelize.NewFile()
+		defer f.Close()
+		for i, sheet := range sheets {
+			if i == 0 {
+				if err := f.SetSheetName(f.GetSheetName(0), sheet.Name); err != nil {
+					return err
+				}
+			} else if _, err := f.NewSheet(sheet.Name); err != nil {
+				return err
+			}
+			for j, row := range sheet.Rows {
+				cell, err := excelize.CoordinatesToCellName(1, j+1)
+				if err != nil {
+					return err
+				}
+				values := make([]interface{}, len(row))
+				for k, value := range row {
+					values[k] = value
+				}
+				if err := f.SetSheetRow(sheet.Name, cell, &values); err != nil {
+					return err
+				}
+			}
+		}
+		_, err := f.WriteTo(w)
+		return err
+	default:
+		return fmt.Errorf("unsupported format %q, expected csv or xlsx", format)
+	}
+}
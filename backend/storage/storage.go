@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"agro-connect/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Get for keys that do not exist
+var ErrNotFound = errors.New("object not found")
+
+// Storage stores uploaded files by key, e.g. "products/12/3f9c_full.jpg".
+// Files are served under /uploads/<key> by every backend instance, so URLs
+// saved in the database do not depend on the driver.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL granting read access to key until expiry
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// Default is the storage used by all upload paths
+var Default Storage
+
+// Setup creates the storage selected by the configuration
+func Setup() error {
+	switch config.Storage.Driver {
+	case "", "local":
+		Default = NewLocal(config.Storage.LocalDir, []byte(config.Storage.SigningKey))
+		return nil
+	case "s3":
+		s3, err := NewS3(context.Background(), S3Options{
+			Endpoint:  config.Storage.S3Endpoint,
+			Region:    config.Storage.S3Region,
+			Bucket:    config.Storage.S3Bucket,
+			AccessKey: config.Storage.S3AccessKey,
+			SecretKey: config.Storage.S3SecretKey,
+			UseSSL:    config.Storage.S3UseSSL,
+		})
+		if err != nil {
+			return err
+		}
+		Default = s3
+		return nil
+	default:
+		return fmt.Errorf("unknown storage driver: %s", config.Storage.Driver)
+	}
+}
+
+// URLPrefix is the path under which stored files are served
+const URLPrefix = "/uploads/"
+
+// PrivatePrefix marks keys that are only readable through signed URLs
+const PrivatePrefix = "private/"
+
+// IsPrivate reports whether key is only readable through signed URLs
+func IsPrivate(key string) bool {
+	return strings.HasPrefix(key, PrivatePrefix)
+}
+
+// URL returns the stable URL of a stored file
+func URL(key string) string {
+	return URLPrefix + key
+}
+
+// KeyFromURL returns the key of a URL built by URL, rejecting URLs outside
+// the uploads path or escaping it with ".."
+func KeyFromURL(url string) (string, bool) {
+	cleaned := path.Clean("/" + url)
+	if !strings.HasPrefix(cleaned, URLPrefix) {
+		return "", false
+	}
+	return CleanKey(strings.TrimPrefix(cleaned, URLPrefix))
+}
+
+// CleanKey normalizes a key and reports whether it is valid
+func CleanKey(key string) (string, bool) {
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	if key == "" || key == "." || strings.HasPrefix(key, "..") {
+		return "", false
+	}
+	return key, true
+}
+
+// Put stores data in the default storage and returns its URL
+func Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (string, error) {
+	key, ok := CleanKey(key)
+	if !ok {
+		return "", fmt.Errorf("invalid storage key")
+	}
+	if err := Default.Put(ctx, key, r, size, contentType); err != nil {
+		return "", err
+	}
+	return URL(key), nil
+}
+
+// Open reads a stored file by URL
+func Open(ctx context.Context, url string) (io.ReadCloser, error) {
+	key, ok := KeyFromURL(url)
+	if !ok {
+		return nil, fmt.Errorf("not an uploaded file: %s", url)
+	}
+	return Default.Get(ctx, key)
+}
+
+// Remove deletes stored files by URL, ignoring URLs outside the uploads path
+func Remove(ctx context.Context, urls ...string) error {
+	var errs []error
+	for _, url := range urls {
+		key, ok := KeyFromURL(url)
+		if !ok {
+			continue
+		}
+		if err := Default.Delete(ctx, key); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,164 @@
+package units
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Dimension groups units that convert into each other
+type Dimension string
+
+// Mass units convert to kilograms, volume units to litres and count units to
+// pieces. Packaging units such as bunch or box only convert to themselves.
+const (
+	Mass   Dimension = "mass"
+	Volume Dimension = "volume"
+	Count  Dimension = "count"
+)
+
+// Unit is a unit of measure of the registry
+type Unit struct {
+	Code      string    `json:"code"`
+	NameEn    string    `json:"name_en"`
+	NameNp    string    `json:"name_np"`
+	Dimension Dimension `json:"dimension"`
+	// Factor is the size of the unit in the base unit of its dimension
+	Factor      float64  `json:"factor"`
+	Traditional bool     `json:"traditional"`
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+// registry lists the supported units. Traditional Nepali units use the
+// values common in wholesale markets; they vary slightly by region.
+var registry = []Unit{
+	{Code: "kg", NameEn: "Kilogram", NameNp: "किलो", Dimension: Mass, Factor: 1,
+		Aliases: []string{"kgs", "kilo", "kilos", "kilogram", "kilograms", "केजी", "किलोग्राम"}},
+	{Code: "g", NameEn: "Gram", NameNp: "ग्राम", Dimension: Mass, Factor: 0.001,
+		Aliases: []string{"gm", "gms", "gram", "grams", "gr"}},
+	{Code: "quintal", NameEn: "Quintal", NameNp: "क्विन्टल", Dimension: Mass, Factor: 100,
+		Aliases: []string{"quintals", "qtl", "q"}},
+	{Code: "tonne", NameEn: "Tonne", NameNp: "टन", Dimension: Mass, Factor: 1000,
+		Aliases: []string{"ton", "tons", "tonnes", "t"}},
+	{Code: "dharni", NameEn: "Dharni", NameNp: "धार्नी", Dimension: Mass, Factor: 2.39, Traditional: true,
+		Aliases: []string{"dharnee", "dharani"}},
+	{Code: "ser", NameEn: "Ser", NameNp: "सेर", Dimension: Mass, Factor: 2.39 / 3, Traditional: true,
+		Aliases: []string{"seer", "sher"}},
+	{Code: "pau", NameEn: "Pau", NameNp: "पाउ", Dimension: Mass, Factor: 2.39 / 12, Traditional: true},
+
+	{Code: "litre", NameEn: "Litre", NameNp: "लिटर", Dimension: Volume, Factor: 1,
+		Aliases: []string{"l", "ltr", "liter", "liters", "litres", "लि"}},
+	{Code: "ml", NameEn: "Millilitre", NameNp: "मिलिलिटर", Dimension: Volume, Factor: 0.001,
+		Aliases: []string{"millilitre", "milliliter", "millilitres", "milliliters"}},
+	{Code: "muri", NameEn: "Muri", NameNp: "मुरी", Dimension: Volume, Factor: 90.92, Traditional: true,
+		Aliases: []string{"muree"}},
+	{Code: "pathi", NameEn: "Pathi", NameNp: "पाथी", Dimension: Volume, Factor: 4.546, Traditional: true,
+		Aliases: []string{"paathi"}},
+	{Code: "mana", NameEn: "Mana", NameNp: "माना", Dimension: Volume, Factor: 0.568, Traditional: true,
+		Aliases: []string{"maana"}},
+
+	{Code: "piece", NameEn: "Piece", NameNp: "गोटा", Dimension: Count, Factor: 1,
+		Aliases: []string{"pieces", "pc", "pcs", "gota", "wota"}},
+	{Code: "dozen", NameEn: "Dozen", NameNp: "दर्जन", Dimension: Count, Factor: 12,
+		Aliases: []string{"dozens", "doz", "darjan"}},
+
+	{Code: "bunch", NameEn: "Bunch", NameNp: "मुठा", Dimension: "bunch", Factor: 1,
+		Aliases: []string{"bunches", "mutha"}},
+	{Code: "packet", NameEn: "Packet", NameNp: "प्याकेट", Dimension: "packet", Factor: 1,
+		Aliases: []string{"packets", "pack", "pkt"}},
+	{Code: "bottle", NameEn: "Bottle", NameNp: "बोतल", Dimension: "bottle", Factor: 1,
+		Aliases: []string{"bottles"}},
+	{Code: "box", NameEn: "Box", NameNp: "बाकस", Dimension: "box", Factor: 1,
+		Aliases: []string{"boxes", "crate", "crates"}},
+}
+
+// byName maps codes and aliases to units
+var byName = map[string]Unit{}
+
+func init() {
+	for _, u := range registry {
+		byName[u.Code] = u
+		for _, alias := range u.Aliases {
+			byName[alias] = u
+		}
+	}
+}
+
+// All returns the registry ordered by dimension and size
+func All() []Unit {
+	all := append([]Unit(nil), registry...)
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Dimension != all[j].Dimension {
+			return dimensionOrder(all[i].Dimension) < dimensionOrder(all[j].Dimension)
+		}
+		return all[i].Factor < all[j].Factor
+	})
+	return all
+}
+
+func dimensionOrder(d Dimension) int {
+	switch d {
+	case Mass:
+		return 0
+	case Volume:
+		return 1
+	case Count:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Lookup finds a unit by code or alias, ignoring case, surrounding spaces
+// and a trailing dot
+func Lookup(name string) (Unit, bool) {
+	name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+	u, ok := byName[name]
+	return u, ok
+}
+
+// Normalize returns the registry code of a unit name
+func Normalize(name string) (string, error) {
+	u, ok := Lookup(name)
+	if !ok {
+		return "", fmt.Errorf("unknown unit: %s", name)
+	}
+	return u.Code, nil
+}
+
+// KgPer returns how many kilograms one unit weighs. Volume units need the
+// density of the commodity in kg per litre; pass 0 when it is unknown.
+func KgPer(u Unit, kgPerLitre float64) (float64, bool) {
+	switch {
+	case u.Dimension == Mass:
+		return u.Factor, true
+	case u.Dimension == Volume && kgPerLitre > 0:
+		return u.Factor * kgPerLitre, true
+	default:
+		return 0, false
+	}
+}
+
+// Convert expresses quantity in unit from as a quantity in unit to. Mass and
+// volume convert into each other when the density is known.
+func Convert(quantity float64, from, to Unit, kgPerLitre float64) (float64, error) {
+	if from.Dimension == to.Dimension {
+		return quantity * from.Factor / to.Factor, nil
+	}
+
+	fromKg, okFrom := KgPer(from, kgPerLitre)
+	toKg, okTo := KgPer(to, kgPerLitre)
+	if !okFrom || !okTo {
+		return 0, fmt.Errorf("cannot convert %s to %s", from.Code, to.Code)
+	}
+	return quantity * fromKg / toKg, nil
+}
+
+// PricePerKg converts a price per unit into a price per kilogram
+func PricePerKg(price float64, u Unit, kgPerLitre float64) (float64, bool) {
+	kg, ok := KgPer(u, kgPerLitre)
+	if !ok || kg == 0 {
+		return 0, false
+	}
+	return price / kg, true
+}
@@ -0,0 +1,62 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		quantity   float64
+		from, to   string
+		kgPerLitre float64
+		want       float64
+		ok         bool
+	}{
+		{2, "quintal", "kg", 0, 200, true},
+		{500, "g", "kg", 0, 0.5, true},
+		{3, "ser", "dharni", 0, 1, true},
+		{12, "pau", "kg", 0, 2.39, true},
+		{2, "muri", "pathi", 0, 40, true},
+		{3, "dozen", "piece", 0, 36, true},
+		{10, "litre", "kg", 0.92, 9.2, true},
+		{1, "pathi", "kg", 0.8, 3.6368, true},
+		{10, "litre", "kg", 0, 0, false},
+		{5, "piece", "kg", 0, 0, false},
+		{2, "bunch", "bunch", 0, 2, true},
+		{2, "bunch", "box", 0, 0, false},
+	}
+	for _, tt := range tests {
+		from, _ := Lookup(tt.from)
+		to, _ := Lookup(tt.to)
+		got, err := Convert(tt.quantity, from, to, tt.kgPerLitre)
+		if (err == nil) != tt.ok {
+			t.Errorf("Convert(%g %s to %s): error %v, want ok %v", tt.quantity, tt.from, tt.to, err, tt.ok)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Convert(%g %s to %s) = %g, want %g", tt.quantity, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"kg", "kg", true},
+		{" KGS. ", "kg", true},
+		{"केजी", "kg", true},
+		{"Qtl", "quintal", true},
+		{"gota", "piece", true},
+		{"sack", "", false},
+	}
+	for _, tt := range tests {
+		u, ok := Lookup(tt.name)
+		if ok != tt.ok || u.Code != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v, want %q, %v", tt.name, u.Code, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -47,7 +47,7 @@ const EditProductById = () => {
     const fetchProduct = async () => {
       try {
         const token = localStorage.getItem('token');
-        const response = await axios.get(`http://localhost:8080/products/${id}`, {
+        const response = await axios.get(`http://localhost:8080/products/me/${id}`, {
           headers: { Authorization: `Bearer ${token}` }
         });
         setProduct({
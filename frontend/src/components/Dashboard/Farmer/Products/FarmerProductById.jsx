@@ -43,7 +43,7 @@ const FarmerProductById = () => {
     const fetchProduct = async () => {
       try {
         const token = localStorage.getItem("token");
-        const response = await axios.get(`http://localhost:8080/products/${id}`, {
+        const response = await axios.get(`http://localhost:8080/products/me/${id}`, {
           headers: { Authorization: `Bearer ${token}` },
         });
 
@@ -32,7 +32,7 @@ const ProductsList = () => {
           throw new Error("Failed to fetch products");
         }
         const data = await response.json();
-        setProducts(data);
+        setProducts(data.products);
       } catch (err) {
         setError(err.message);
       } finally {